@@ -2,17 +2,28 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"log/slog"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/domain/repository"
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/cache"
 	"github.com/josimarz/ranking-backend/internal/infra/db/ddb"
+	"github.com/josimarz/ranking-backend/internal/infra/idempotency"
+	"github.com/josimarz/ranking-backend/internal/infra/metrics"
+	"github.com/josimarz/ranking-backend/internal/infra/remote"
 	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/infra/tracing"
 	"github.com/josimarz/ranking-backend/internal/infra/web/handler"
 	"github.com/josimarz/ranking-backend/internal/infra/web/server"
 )
@@ -22,45 +33,68 @@ type repositories struct {
 	attr      repository.AttributeRepository
 	entry     repository.EntryRepository
 	rankTable repository.RankTableRepository
+	// rankTableSource reads rank tables bypassing the cache, for usecases that
+	// must not act on stale data.
+	rankTableSource repository.RankTableRepository
+	// rankTableConsistent also waits for the index to show every finished
+	// write, for deciding which images are no longer used.
+	rankTableConsistent repository.RankTableRepository
 }
 
 type usecases struct {
-	createRank    *usecase.CreateRankUsecase
-	findRank      *usecase.FindRankUsecase
-	updateRank    *usecase.UpdateRankUsecase
-	deleteRank    *usecase.DeleteRankUsecase
-	createAttr    *usecase.CreateAttributeUsecase
-	findAttr      *usecase.FindAttributeUsecase
-	updateAttr    *usecase.UpdateAttributeUsecase
-	deleteAttr    *usecase.DeleteAttributeUsecase
-	createEntry   *usecase.CreateEntryUsecase
-	findEntry     *usecase.FindEntryUsecase
-	updateEntry   *usecase.UpdateEntryUsecase
-	deleteEntry   *usecase.DeleteEntryUsecase
-	findRankTable *usecase.FindRankTableUsecase
-	upload        *usecase.UploadUsecase
+	createRank    usecase.Usecase[usecase.CreateRankInput, usecase.CreateRankOutput]
+	findRank      usecase.Usecase[usecase.FindRankInput, usecase.FindRankOutput]
+	updateRank    usecase.Usecase[usecase.UpdateRankInput, usecase.UpdateRankOutput]
+	patchRank     usecase.Usecase[usecase.PatchRankInput, usecase.PatchRankOutput]
+	deleteRank    usecase.Usecase[usecase.DeleteRankInput, usecase.DeleteRankOutput]
+	createAttr    usecase.Usecase[usecase.CreateAttributeInput, usecase.CreateAttributeOutput]
+	findAttr      usecase.Usecase[usecase.FindAttributeInput, usecase.FindAttributeOutput]
+	updateAttr    usecase.Usecase[usecase.UpdateAttributeInput, usecase.UpdateAttributeOutput]
+	patchAttr     usecase.Usecase[usecase.PatchAttributeInput, usecase.PatchAttributeOutput]
+	deleteAttr    usecase.Usecase[usecase.DeleteAttributeInput, usecase.DeleteAttributeOutput]
+	createEntry   usecase.Usecase[usecase.CreateEntryInput, usecase.CreateEntryOutput]
+	findEntry     usecase.Usecase[usecase.FindEntryInput, usecase.FindEntryOutput]
+	updateEntry   usecase.Usecase[usecase.UpdateEntryInput, usecase.UpdateEntryOutput]
+	patchEntry    usecase.Usecase[usecase.PatchEntryInput, usecase.PatchEntryOutput]
+	deleteEntry   usecase.Usecase[usecase.DeleteEntryInput, usecase.DeleteEntryOutput]
+	addMedia      usecase.Usecase[usecase.AddMediaInput, usecase.EntryMediaOutput]
+	reorderMedia  usecase.Usecase[usecase.ReorderMediaInput, usecase.EntryMediaOutput]
+	removeMedia   usecase.Usecase[usecase.RemoveMediaInput, usecase.EntryMediaOutput]
+	findRankTable usecase.Usecase[usecase.FindRankTableInput, usecase.FindRankTableOutput]
+	batchScores   usecase.Usecase[usecase.BatchScoresInput, usecase.BatchScoresOutput]
+	upload        usecase.Usecase[usecase.UploadInput, usecase.UploadOutput]
+	presignUpload usecase.Usecase[usecase.PresignUploadInput, usecase.PresignUploadOutput]
+	confirmUpload usecase.Usecase[usecase.ConfirmUploadInput, usecase.UploadOutput]
+	collectImages usecase.Usecase[usecase.CollectOrphanImagesInput, usecase.CollectOrphanImagesOutput]
+	importImages  usecase.Usecase[usecase.ImportImagesInput, usecase.ImportImagesOutput]
 }
 
 type application struct {
 	logger         *slog.Logger
+	metrics        *metrics.Metrics
 	dynamodbClient *dynamodb.Client
 	s3Client       *s3.Client
 	storage        storage.FileStorage
+	presigner      storage.Presigner
+	signer         storage.Signer
+	verifier       storage.URLVerifier
 	repos          *repositories
 	usecases       *usecases
 	handlers       server.Handlers
-	server         server.Server
+	server         *server.Server
+	shutdownTrace  server.ShutdownFunc
 }
 
 func newApplication() *application {
 	return &application{
-		logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		logger:  slog.New(server.NewContextHandler(slog.NewTextHandler(os.Stdout, nil))),
+		metrics: metrics.New(),
 	}
 }
 
 func (a *application) start() {
+	a.initTracing()
 	a.connectToDatabase()
-	a.connectToS3()
 	a.initStorage()
 	a.initRepositories()
 	a.initUsecases()
@@ -68,6 +102,19 @@ func (a *application) start() {
 	a.startServer()
 }
 
+func (a *application) initTracing() {
+	cfg := tracing.Config{
+		ServiceName: "ranking-api",
+		Exporter:    a.getString("TRACING_EXPORTER", tracing.ExporterNone),
+	}
+	shutdown, err := tracing.Setup(context.Background(), cfg)
+	if err != nil {
+		a.logger.Error(err.Error())
+		os.Exit(1)
+	}
+	a.shutdownTrace = shutdown
+}
+
 func (a *application) connectToDatabase() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -91,69 +138,347 @@ func (a *application) connectToS3() {
 }
 
 func (a *application) initStorage() {
-	a.storage = storage.NewFileS3Storage(a.s3Client)
+	switch backend := a.getStorageBackend(); backend {
+	case storageS3:
+		a.connectToS3()
+		s3Storage := storage.NewFileS3Storage(a.s3Client)
+		a.storage = metrics.NewFileMetricsStorage(s3Storage, backend, a.metrics)
+		// Only S3 accepts uploads straight from clients.
+		a.presigner = s3Storage
+		a.signer = s3Storage
+	case storageFileSystem:
+		root := a.getString("STORAGE_ROOT", "files")
+		baseURL := a.getString("STORAGE_BASE_URL", fmt.Sprintf("http://localhost:%s/files", a.getPort()))
+		fsStorage := storage.NewFileSystemStorage(root, baseURL, a.getStorageSigningKey())
+		a.storage = metrics.NewFileMetricsStorage(fsStorage, backend, a.metrics)
+		a.signer = fsStorage
+		a.verifier = fsStorage
+	default:
+		a.logger.Error("unknown storage backend", "STORAGE_BACKEND", backend)
+		os.Exit(1)
+	}
 }
 
 func (a *application) initRepositories() {
+	rankTable := metrics.NewRankTableMetricsRepository(ddb.NewRankTableDynamodbRepository(a.dynamodbClient), a.metrics)
+	c := cache.NewRankTableCache(cache.NewLRU[string, *entity.RankTable](
+		a.getInt("RANK_TABLE_CACHE_SIZE", 1000),
+		a.getDuration("RANK_TABLE_CACHE_TTL", 30*time.Second),
+	))
 	a.repos = &repositories{
-		rank:      ddb.NewRankDynamodbRepository(a.dynamodbClient),
-		attr:      ddb.NewAttributeDynamodbRepository(a.dynamodbClient),
-		entry:     ddb.NewEntryDynamodbRepository(a.dynamodbClient),
-		rankTable: ddb.NewRankTableDynamodbRepository(a.dynamodbClient),
+		rank:                cache.NewRankCacheRepository(metrics.NewRankMetricsRepository(ddb.NewRankDynamodbRepository(a.dynamodbClient), a.metrics), c),
+		attr:                cache.NewAttributeCacheRepository(metrics.NewAttributeMetricsRepository(ddb.NewAttributeDynamodbRepository(a.dynamodbClient), a.metrics), c),
+		entry:               cache.NewEntryCacheRepository(metrics.NewEntryMetricsRepository(ddb.NewEntryDynamodbRepository(a.dynamodbClient), a.metrics), c),
+		rankTable:           cache.NewRankTableCacheRepository(rankTable, c, ddb.NewRankTableFence(a.dynamodbClient)),
+		rankTableSource:     rankTable,
+		rankTableConsistent: metrics.NewRankTableMetricsRepository(ddb.NewConsistentRankTableDynamodbRepository(a.dynamodbClient), a.metrics),
 	}
 }
 
 func (a *application) initUsecases() {
+	images := usecase.NewImageCleaner(a.logger, a.storage, a.repos.rankTableConsistent, a.getImageGCGracePeriod())
+	urls := usecase.NewImageSigner(a.storage, a.signer, a.repos.rank, a.getImageURLTTL())
+	upload := usecase.NewUploadUsecase(a.storage, imaging.DefaultVariants(), a.getImageLimits(), urls)
+	importer := usecase.NewImageImporter(remote.NewFetcher(a.getImportConfig()), upload)
 	a.usecases = &usecases{
 		createRank:    usecase.NewCreateRankUsecase(a.repos.rank),
 		findRank:      usecase.NewFindRankUsecase(a.repos.rank),
-		updateRank:    usecase.NewUpdateRankUsecase(a.repos.rank),
+		updateRank:    usecase.NewUpdateRankUsecase(a.repos.rank, urls),
+		patchRank:     usecase.NewPatchRankUsecase(a.repos.rank, urls),
 		deleteRank:    usecase.NewDeleteRankUsecase(a.repos.rank),
 		createAttr:    usecase.NewCreateAttributeUsecase(a.repos.attr),
 		findAttr:      usecase.NewFindAttributeUsecase(a.repos.attr),
 		updateAttr:    usecase.NewUpdateAttributeUsecase(a.repos.attr),
+		patchAttr:     usecase.NewPatchAttributeUsecase(a.repos.attr),
 		deleteAttr:    usecase.NewDeleteAttributeUsecase(a.repos.attr),
-		createEntry:   usecase.NewCreateEntryUsecase(a.repos.entry),
-		findEntry:     usecase.NewFindEntryUsecase(a.repos.entry),
-		updateEntry:   usecase.NewUpdateEntryUsecase(a.repos.entry),
-		deleteEntry:   usecase.NewDeleteEntryUsecase(a.repos.entry),
-		findRankTable: usecase.NewFindRankTableUsecase(a.repos.rankTable),
-		upload:        usecase.NewUploadUsecase(a.storage),
+		createEntry:   usecase.NewCreateEntryUsecase(a.repos.entry, urls, importer),
+		findEntry:     usecase.NewFindEntryUsecase(a.repos.entry, urls),
+		updateEntry:   usecase.NewUpdateEntryUsecase(a.repos.entry, images, urls, importer),
+		patchEntry:    usecase.NewPatchEntryUsecase(a.repos.entry, images, urls),
+		deleteEntry:   usecase.NewDeleteEntryUsecase(a.repos.entry, images),
+		addMedia:      usecase.NewAddMediaUsecase(a.repos.entry, urls),
+		reorderMedia:  usecase.NewReorderMediaUsecase(a.repos.entry, urls),
+		removeMedia:   usecase.NewRemoveMediaUsecase(a.repos.entry, images, urls),
+		findRankTable: usecase.NewFindRankTableUsecase(a.repos.rankTable, urls),
+		batchScores:   usecase.NewBatchScoresUsecase(a.repos.rankTableSource, a.repos.entry),
+		upload:        upload,
+		confirmUpload: usecase.NewConfirmUploadUsecase(upload),
+		collectImages: usecase.NewCollectOrphanImagesUsecase(images),
+		importImages:  usecase.NewImportImagesUsecase(a.repos.rankTableSource, a.repos.entry, importer, urls),
+	}
+	if a.presigner != nil {
+		a.usecases.presignUpload = usecase.NewPresignUploadUsecase(a.presigner, urls)
 	}
 }
 
 func (a *application) initHandlers() {
 	a.handlers = server.Handlers{
-		"POST /rank":                           handler.NewPostRankHandler(a.logger, a.usecases.createRank),
-		"GET /rank/{id}":                       handler.NewGetRankHandler(a.logger, a.usecases.findRank),
-		"PUT /rank/{id}":                       handler.NewPutRankHandler(a.logger, a.usecases.updateRank),
-		"DELETE /rank/{id}":                    handler.NewDeleteRankHandler(a.logger, a.usecases.deleteRank),
-		"POST /rank/{rankId}/attribute":        handler.NewPostAttributeHandler(a.logger, a.usecases.createAttr),
-		"GET /rank/{rankId}/attribute/{id}":    handler.NewGetAttributeHandler(a.logger, a.usecases.findAttr),
-		"PUT /rank/{rankId}/attribute/{id}":    handler.NewPutAttributeHandler(a.logger, a.usecases.updateAttr),
-		"DELETE /rank/{rankId}/attribute/{id}": handler.NewDeleteAttributeHandler(a.logger, a.usecases.deleteAttr),
-		"POST /rank/{rankId}/entry":            handler.NewPostEntryHandler(a.logger, a.usecases.createEntry),
-		"GET /rank/{rankId}/entry/{id}":        handler.NewGetEntryHandler(a.logger, a.usecases.findEntry),
-		"PUT /rank/{rankId}/entry/{id}":        handler.NewPutEntryHandler(a.logger, a.usecases.updateEntry),
-		"DELETE /rank/{rankId}/entry/{id}":     handler.NewDeleteEntryHandler(a.logger, a.usecases.deleteEntry),
-		"GET /rank/{id}/table":                 handler.NewGetRankTableHandler(a.logger, a.usecases.findRankTable),
-		"POST /rank/{id}/file":                 handler.NewPostFileHandler(a.logger, a.usecases.upload),
+		"POST /rank":                                       handler.NewPostRankHandler(a.logger, tracing.NewTracedUsecase(a.usecases.createRank)),
+		"GET /rank/{id}":                                   handler.NewGetRankHandler(a.logger, tracing.NewTracedUsecase(a.usecases.findRank)),
+		"PUT /rank/{id}":                                   handler.NewPutRankHandler(a.logger, tracing.NewTracedUsecase(a.usecases.updateRank)),
+		"PATCH /rank/{id}":                                 handler.NewPatchRankHandler(a.logger, tracing.NewTracedUsecase(a.usecases.patchRank)),
+		"DELETE /rank/{id}":                                handler.NewDeleteRankHandler(a.logger, tracing.NewTracedUsecase(a.usecases.deleteRank)),
+		"POST /rank/{rankId}/attribute":                    handler.NewPostAttributeHandler(a.logger, tracing.NewTracedUsecase(a.usecases.createAttr)),
+		"GET /rank/{rankId}/attribute/{id}":                handler.NewGetAttributeHandler(a.logger, tracing.NewTracedUsecase(a.usecases.findAttr)),
+		"PUT /rank/{rankId}/attribute/{id}":                handler.NewPutAttributeHandler(a.logger, tracing.NewTracedUsecase(a.usecases.updateAttr)),
+		"PATCH /rank/{rankId}/attribute/{id}":              handler.NewPatchAttributeHandler(a.logger, tracing.NewTracedUsecase(a.usecases.patchAttr)),
+		"DELETE /rank/{rankId}/attribute/{id}":             handler.NewDeleteAttributeHandler(a.logger, tracing.NewTracedUsecase(a.usecases.deleteAttr)),
+		"POST /rank/{rankId}/entry":                        handler.NewPostEntryHandler(a.logger, tracing.NewTracedUsecase(a.usecases.createEntry)),
+		"GET /rank/{rankId}/entry/{id}":                    handler.NewGetEntryHandler(a.logger, tracing.NewTracedUsecase(a.usecases.findEntry)),
+		"PUT /rank/{rankId}/entry/{id}":                    handler.NewPutEntryHandler(a.logger, tracing.NewTracedUsecase(a.usecases.updateEntry)),
+		"PATCH /rank/{rankId}/entry/{id}":                  handler.NewPatchEntryHandler(a.logger, tracing.NewTracedUsecase(a.usecases.patchEntry)),
+		"DELETE /rank/{rankId}/entry/{id}":                 handler.NewDeleteEntryHandler(a.logger, tracing.NewTracedUsecase(a.usecases.deleteEntry)),
+		"POST /rank/{rankId}/entry/{id}/media":             handler.NewPostMediaHandler(a.logger, tracing.NewTracedUsecase(a.usecases.addMedia)),
+		"POST /rank/{rankId}/entry/{id}/media:reorder":     handler.NewPostMediaReorderHandler(a.logger, tracing.NewTracedUsecase(a.usecases.reorderMedia)),
+		"DELETE /rank/{rankId}/entry/{id}/media/{mediaId}": handler.NewDeleteMediaHandler(a.logger, tracing.NewTracedUsecase(a.usecases.removeMedia)),
+		"GET /rank/{id}/table":                             handler.NewGetRankTableHandler(a.logger, tracing.NewTracedUsecase(a.usecases.findRankTable)),
+		"POST /rank/{id}/scores:batch":                     handler.NewPostScoresBatchHandler(a.logger, tracing.NewTracedUsecase(a.usecases.batchScores)),
+		"POST /rank/{id}/images:import":                    handler.NewPostImagesImportHandler(a.logger, tracing.NewTracedUsecase(a.usecases.importImages)),
+		"POST /rank/{id}/file":                             handler.NewPostFileHandler(a.logger, tracing.NewTracedUsecase(a.usecases.upload), a.getUploadConfig()),
+		"GET /metrics":                                     a.metrics.Handler(),
+		"GET /healthz":                                     handler.NewHealthzHandler(a.logger),
+		"GET /readyz":                                      handler.NewReadyzHandler(a.logger, a.healthCheckers(), 2*time.Second, 5*time.Second),
+		"GET /openapi.json":                                handler.NewOpenAPIHandler(a.logger, newOpenAPIDocument()),
+		"GET /docs":                                        handler.NewDocsHandler(a.logger, "Ranking API", "/openapi.json"),
 	}
+	if a.presigner != nil {
+		a.handlers["POST /rank/{id}/file:presign"] = handler.NewPostFilePresignHandler(a.logger, tracing.NewTracedUsecase(a.usecases.presignUpload))
+		a.handlers["POST /rank/{id}/file:confirm"] = handler.NewPostFileConfirmHandler(a.logger, tracing.NewTracedUsecase(a.usecases.confirmUpload))
+	}
+	if a.getStorageBackend() == storageFileSystem {
+		a.handlers["GET /files/{path...}"] = handler.NewGetFileHandler(a.logger, a.storage, a.verifier)
+	}
+	for pattern, h := range a.handlers {
+		a.handlers[pattern] = tracing.InstrumentHandler(pattern, a.metrics.InstrumentHandler(pattern, h))
+	}
+}
+
+func (a *application) healthCheckers() map[string]handler.HealthChecker {
+	checkers := map[string]handler.HealthChecker{
+		"dynamodb": ddb.NewTableHealthChecker(a.dynamodbClient),
+	}
+	if a.s3Client != nil {
+		checkers["s3"] = storage.NewBucketHealthChecker(a.s3Client)
+	}
+	return checkers
 }
 
 func (a *application) startServer() {
 	addr := fmt.Sprintf(":%s", a.getPort())
-	a.server = *server.NewServer(addr, a.handlers)
+	a.server = server.NewServer(addr, a.handlers, a.getServerConfig())
+	a.server.Use(
+		server.RequestId(),
+		server.AccessLog(a.logger),
+		server.Recover(a.logger),
+	)
+	if cfg, ok := a.getCORSConfig(); ok {
+		a.server.Use(server.CORS(cfg))
+	}
+	a.server.Use(server.Compress(a.getCompressConfig()))
+	rateLimitCfg := a.getRateLimitConfig()
+	a.server.Use(server.RateLimit(rateLimitCfg, server.NewInMemoryRateLimitStore()))
+	idempotencyCfg := a.getIdempotencyConfig()
+	idempotencyCfg.Client = rateLimitCfg.Client()
+	a.server.Use(idempotency.Middleware(a.logger, idempotencyCfg, a.idempotencyStore()))
+	a.startImageGC()
+	a.server.OnShutdown(a.closeClients)
+	a.server.OnShutdown(a.shutdownTrace)
 	a.logger.Info("starting server", "addr", addr)
 	if err := a.server.Start(); err != nil {
 		a.logger.Error(err.Error())
 		os.Exit(1)
 	}
+	a.logger.Info("server stopped")
 }
 
-func (*application) getPort() string {
-	if value, ok := os.LookupEnv("PORT"); ok {
+func (a *application) closeClients(ctx context.Context) error {
+	a.logger.Info("closing clients")
+	ddb.CloseDynamodbClient(a.dynamodbClient)
+	if a.s3Client != nil {
+		storage.CloseS3Client(a.s3Client)
+	}
+	return nil
+}
+
+func (a *application) getPort() string {
+	return a.getString("PORT", "8080")
+}
+
+const (
+	storageS3         = "s3"
+	storageFileSystem = "filesystem"
+)
+
+func (a *application) getStorageBackend() string {
+	return a.getString("STORAGE_BACKEND", storageS3)
+}
+
+// getStorageSigningKey returns the key signing the URLs of private files
+// served by the API. Without STORAGE_SIGNING_KEY a random key is used, so
+// signed URLs stop working on restart and are only accepted by the instance
+// that signed them.
+func (a *application) getStorageSigningKey() []byte {
+	if key := a.getString("STORAGE_SIGNING_KEY", ""); key != "" {
+		return []byte(key)
+	}
+	a.logger.Warn("STORAGE_SIGNING_KEY is not set, signed file URLs will not survive a restart")
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+func (a *application) getUploadConfig() handler.UploadConfig {
+	cfg := handler.DefaultUploadConfig()
+	cfg.MaxBytes = int64(a.getInt("UPLOAD_MAX_BYTES", int(cfg.MaxBytes)))
+	cfg.AllowedTypes = a.getList("UPLOAD_ALLOWED_TYPES", cfg.AllowedTypes)
+	return cfg
+}
+
+func (a *application) getImageLimits() imaging.Limits {
+	limits := imaging.DefaultLimits()
+	limits.MaxWidth = a.getInt("IMAGE_MAX_WIDTH", limits.MaxWidth)
+	limits.MaxHeight = a.getInt("IMAGE_MAX_HEIGHT", limits.MaxHeight)
+	limits.MaxPixels = a.getInt("IMAGE_MAX_PIXELS", limits.MaxPixels)
+	return limits
+}
+
+func (a *application) getImportConfig() remote.Config {
+	cfg := remote.DefaultConfig()
+	cfg.Timeout = a.getDuration("IMAGE_IMPORT_TIMEOUT", cfg.Timeout)
+	cfg.MaxBytes = int64(a.getInt("IMAGE_IMPORT_MAX_BYTES", int(cfg.MaxBytes)))
+	cfg.AllowPrivate = a.getBool("IMAGE_IMPORT_ALLOW_PRIVATE", cfg.AllowPrivate)
+	return cfg
+}
+
+func (a *application) getServerConfig() server.Config {
+	cfg := server.DefaultConfig()
+	cfg.ReadTimeout = a.getDuration("HTTP_READ_TIMEOUT", cfg.ReadTimeout)
+	cfg.ReadHeaderTimeout = a.getDuration("HTTP_READ_HEADER_TIMEOUT", cfg.ReadHeaderTimeout)
+	cfg.WriteTimeout = a.getDuration("HTTP_WRITE_TIMEOUT", cfg.WriteTimeout)
+	cfg.IdleTimeout = a.getDuration("HTTP_IDLE_TIMEOUT", cfg.IdleTimeout)
+	cfg.ShutdownTimeout = a.getDuration("HTTP_SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	cfg.MaxHeaderBytes = a.getInt("HTTP_MAX_HEADER_BYTES", cfg.MaxHeaderBytes)
+	return cfg
+}
+
+func (a *application) getCORSConfig() (server.CORSConfig, bool) {
+	cfg := server.DefaultCORSConfig()
+	cfg.AllowedOrigins = a.getList("CORS_ALLOWED_ORIGINS", nil)
+	if len(cfg.AllowedOrigins) == 0 {
+		return cfg, false
+	}
+	cfg.AllowedMethods = a.getList("CORS_ALLOWED_METHODS", cfg.AllowedMethods)
+	cfg.AllowedHeaders = a.getList("CORS_ALLOWED_HEADERS", cfg.AllowedHeaders)
+	cfg.ExposedHeaders = a.getList("CORS_EXPOSED_HEADERS", cfg.ExposedHeaders)
+	cfg.AllowCredentials = a.getBool("CORS_ALLOW_CREDENTIALS", cfg.AllowCredentials)
+	cfg.MaxAge = a.getDuration("CORS_MAX_AGE", cfg.MaxAge)
+	if err := cfg.Validate(); err != nil {
+		a.logger.Error("invalid CORS configuration", "CORS_ALLOWED_ORIGINS", cfg.AllowedOrigins, "CORS_ALLOW_CREDENTIALS", cfg.AllowCredentials, "error", err)
+		os.Exit(1)
+	}
+	return cfg, true
+}
+
+func (a *application) getCompressConfig() server.CompressConfig {
+	cfg := server.DefaultCompressConfig()
+	cfg.MinSize = a.getInt("COMPRESS_MIN_SIZE", cfg.MinSize)
+	return cfg
+}
+
+func (a *application) getRateLimitConfig() server.RateLimitConfig {
+	cfg := server.DefaultRateLimitConfig()
+	cfg.Read.Requests = a.getInt("RATE_LIMIT_READ_PER_MINUTE", cfg.Read.Requests)
+	cfg.Write.Requests = a.getInt("RATE_LIMIT_WRITE_PER_MINUTE", cfg.Write.Requests)
+	cfg.Upload.Requests = a.getInt("RATE_LIMIT_UPLOAD_PER_MINUTE", cfg.Upload.Requests)
+	cfg.APIKeys = a.getList("RATE_LIMIT_API_KEYS", cfg.APIKeys)
+	cfg.TrustProxy = a.getBool("TRUST_PROXY", cfg.TrustProxy)
+	for _, value := range a.getList("TRUSTED_PROXIES", nil) {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			a.logger.Error("invalid trusted proxy", "key", "TRUSTED_PROXIES", "value", value)
+			os.Exit(1)
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, prefix)
+	}
+	return cfg
+}
+
+func (a *application) getIdempotencyConfig() idempotency.Config {
+	cfg := idempotency.DefaultConfig()
+	cfg.TTL = a.getDuration("IDEMPOTENCY_TTL", cfg.TTL)
+	cfg.LockTTL = a.getDuration("IDEMPOTENCY_LOCK_TTL", cfg.LockTTL)
+	cfg.ShortTTL = a.getImageURLTTL()
+	return cfg
+}
+
+// getImageURLTTL returns how long signed image URLs are valid.
+func (a *application) getImageURLTTL() time.Duration {
+	return a.getDuration("IMAGE_URL_TTL", 15*time.Minute)
+}
+
+func (a *application) idempotencyStore() idempotency.Store {
+	if a.getString("IDEMPOTENCY_STORE", "dynamodb") == "memory" {
+		return idempotency.NewInMemoryStore()
+	}
+	return ddb.NewIdempotencyDynamodbStore(a.dynamodbClient)
+}
+
+func (*application) getString(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
 		return value
 	}
-	return "8080"
+	return fallback
+}
+
+func (a *application) getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		a.logger.Error("invalid duration", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return d
+}
+
+func (a *application) getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		a.logger.Error("invalid integer", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return n
+}
+
+func (a *application) getBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		a.logger.Error("invalid boolean", "key", key, "value", value)
+		os.Exit(1)
+	}
+	return b
+}
+
+func (*application) getList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+)
+
+const defaultImageGCGracePeriod = 24 * time.Hour
+
+// gcImages collects orphaned images once and prints the report as JSON. It
+// backs the gc-images subcommand.
+func gcImages(args []string) int {
+	a := newApplication()
+	// Keep stdout for the report.
+	a.logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	fs := flag.NewFlagSet("gc-images", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "report orphaned images without deleting them")
+	grace := fs.Duration("grace", a.getImageGCGracePeriod(), "spare images modified more recently than this")
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+	a.connectToDatabase()
+	a.initStorage()
+	a.initRepositories()
+	a.initUsecases()
+	defer a.closeClients(context.Background())
+	input := usecase.CollectOrphanImagesInput{GracePeriod: *grace, DryRun: *dryRun}
+	output, err := a.usecases.collectImages.Execute(context.Background(), input)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// getImageGCGracePeriod returns how long images are spared after they were
+// modified, by the orphan collector and when entries release them.
+func (a *application) getImageGCGracePeriod() time.Duration {
+	return a.getDuration("IMAGE_GC_GRACE_PERIOD", defaultImageGCGracePeriod)
+}
+
+// startImageGC collects orphaned images every IMAGE_GC_INTERVAL until the
+// server shuts down. It is disabled by default, since every instance of the
+// API runs its own worker.
+func (a *application) startImageGC() {
+	interval := a.getDuration("IMAGE_GC_INTERVAL", 0)
+	if interval <= 0 {
+		return
+	}
+	input := usecase.CollectOrphanImagesInput{
+		GracePeriod: a.getImageGCGracePeriod(),
+		DryRun:      a.getBool("IMAGE_GC_DRY_RUN", false),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				a.collectImages(ctx, input)
+			}
+		}
+	}()
+	a.server.OnShutdown(func(shutdownCtx context.Context) error {
+		cancel()
+		select {
+		case <-done:
+			return nil
+		case <-shutdownCtx.Done():
+			return shutdownCtx.Err()
+		}
+	})
+	a.logger.Info("image garbage collection enabled", "interval", interval, "grace_period", input.GracePeriod, "dry_run", input.DryRun)
+}
+
+func (a *application) collectImages(ctx context.Context, input usecase.CollectOrphanImagesInput) {
+	output, err := a.usecases.collectImages.Execute(ctx, input)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.logger.ErrorContext(ctx, "image garbage collection failed", "error", err)
+		}
+		return
+	}
+	if input.DryRun {
+		for _, orphan := range output.Orphans {
+			a.logger.InfoContext(ctx, "orphaned image", "path", orphan.Path, "size", orphan.Size, "last_modified", orphan.LastModified)
+		}
+	}
+	a.logger.InfoContext(ctx, "image garbage collection finished", "scanned", output.Scanned, "orphans", len(output.Orphans), "deleted", output.Deleted, "dry_run", input.DryRun)
+}
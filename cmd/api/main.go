@@ -1,6 +1,16 @@
 package main
 
+import "os"
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "healthcheck":
+			os.Exit(healthcheck())
+		case "gc-images":
+			os.Exit(gcImages(os.Args[2:]))
+		}
+	}
 	app := newApplication()
 	app.start()
 }
@@ -0,0 +1,462 @@
+package main
+
+import (
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra/web/openapi"
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+	"github.com/josimarz/ranking-backend/internal/mergepatch"
+)
+
+type rankBody struct {
+	Name   string `json:"name"`
+	Public bool   `json:"public"`
+}
+
+type attributeBody struct {
+	Name  string `json:"name"`
+	Desc  string `json:"description"`
+	Order int    `json:"order"`
+}
+
+type entryBody struct {
+	Name     string        `json:"name"`
+	ImageURL string        `json:"image_url"`
+	Scores   entity.Scores `json:"scores"`
+}
+
+// entryImportBody is entryBody for the operations that can import the image.
+type entryImportBody struct {
+	Name        string        `json:"name"`
+	ImageURL    string        `json:"image_url"`
+	Scores      entity.Scores `json:"scores"`
+	ImportImage bool          `json:"import_image"`
+}
+
+type mediaBody struct {
+	URL     string `json:"url"`
+	Alt     string `json:"alt"`
+	Caption string `json:"caption"`
+	// Kind is image or video, and image when omitted.
+	Kind  string `json:"kind"`
+	Cover bool   `json:"cover"`
+}
+
+type reorderMediaBody struct {
+	Ids     []string `json:"ids"`
+	CoverId string   `json:"cover_id"`
+}
+
+type scoresBatchBody struct {
+	Changes []usecase.ScoreChange `json:"changes"`
+}
+
+type presignUploadBody struct {
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+type confirmUploadBody struct {
+	UploadId string `json:"upload_id"`
+}
+
+type messageBody struct {
+	Message string `json:"message"`
+}
+
+type healthBody struct {
+	Status string `json:"status"`
+}
+
+type checkBody struct {
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type readinessBody struct {
+	Status string               `json:"status"`
+	Checks map[string]checkBody `json:"checks"`
+}
+
+func newOpenAPIDocument() *openapi.Document {
+	doc := openapi.New(openapi.Info{
+		Title:       "Ranking API",
+		Description: "Create ranks, describe their attributes and score their entries.",
+		Version:     "1.0.0",
+	})
+	doc.Add("POST /rank", &openapi.Operation{
+		OperationId: "createRank",
+		Summary:     "Create a rank",
+		Tags:        []string{"rank"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(rankBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"201": doc.JSONResponse("Rank created", usecase.CreateRankOutput{}),
+		}, "400", "409", "413", "422", "500"),
+	})
+	doc.Add("GET /rank/{id}", &openapi.Operation{
+		OperationId: "findRank",
+		Summary:     "Find a rank",
+		Tags:        []string{"rank"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Rank found", usecase.FindRankOutput{}),
+		}, "400", "404", "500"),
+	})
+	doc.Add("PUT /rank/{id}", &openapi.Operation{
+		OperationId: "updateRank",
+		Summary:     "Update a rank",
+		Tags:        []string{"rank"},
+		RequestBody: doc.JSONBody(rankBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Rank updated", usecase.UpdateRankOutput{}),
+		}, "400", "404", "413", "422", "500"),
+	})
+	doc.Add("PATCH /rank/{id}", &openapi.Operation{
+		OperationId: "patchRank",
+		Summary:     "Partially update a rank",
+		Tags:        []string{"rank"},
+		RequestBody: mergePatchBody(doc, rankBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Rank updated", usecase.PatchRankOutput{}),
+		}, "400", "404", "413", "415", "422", "500"),
+	})
+	doc.Add("DELETE /rank/{id}", &openapi.Operation{
+		OperationId: "deleteRank",
+		Summary:     "Delete a rank",
+		Tags:        []string{"rank"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Rank deleted", messageBody{}),
+		}, "404", "500"),
+	})
+	doc.Add("POST /rank/{rankId}/attribute", &openapi.Operation{
+		OperationId: "createAttribute",
+		Summary:     "Create an attribute",
+		Tags:        []string{"attribute"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(attributeBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"201": doc.JSONResponse("Attribute created", usecase.CreateAttributeOutput{}),
+		}, "400", "409", "413", "422", "500"),
+	})
+	doc.Add("GET /rank/{rankId}/attribute/{id}", &openapi.Operation{
+		OperationId: "findAttribute",
+		Summary:     "Find an attribute",
+		Tags:        []string{"attribute"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Attribute found", usecase.FindAttributeOutput{}),
+		}, "404", "500"),
+	})
+	doc.Add("PUT /rank/{rankId}/attribute/{id}", &openapi.Operation{
+		OperationId: "updateAttribute",
+		Summary:     "Update an attribute",
+		Tags:        []string{"attribute"},
+		RequestBody: doc.JSONBody(attributeBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Attribute updated", usecase.UpdateAttributeOutput{}),
+		}, "400", "404", "413", "422", "500"),
+	})
+	doc.Add("PATCH /rank/{rankId}/attribute/{id}", &openapi.Operation{
+		OperationId: "patchAttribute",
+		Summary:     "Partially update an attribute",
+		Tags:        []string{"attribute"},
+		RequestBody: mergePatchBody(doc, attributeBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Attribute updated", usecase.PatchAttributeOutput{}),
+		}, "400", "404", "413", "415", "422", "500"),
+	})
+	doc.Add("DELETE /rank/{rankId}/attribute/{id}", &openapi.Operation{
+		OperationId: "deleteAttribute",
+		Summary:     "Delete an attribute",
+		Tags:        []string{"attribute"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Attribute deleted", messageBody{}),
+		}, "404", "500"),
+	})
+	doc.Add("POST /rank/{rankId}/entry", &openapi.Operation{
+		OperationId: "createEntry",
+		Summary:     "Create an entry",
+		Tags:        []string{"entry"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(entryImportBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"201": doc.JSONResponse("Entry created", usecase.CreateEntryOutput{}),
+		}, "400", "409", "413", "422", "500"),
+	})
+	doc.Add("GET /rank/{rankId}/entry/{id}", &openapi.Operation{
+		OperationId: "findEntry",
+		Summary:     "Find an entry",
+		Tags:        []string{"entry"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Entry found", usecase.FindEntryOutput{}),
+		}, "404", "500"),
+	})
+	doc.Add("PUT /rank/{rankId}/entry/{id}", &openapi.Operation{
+		OperationId: "updateEntry",
+		Summary:     "Update an entry",
+		Tags:        []string{"entry"},
+		RequestBody: doc.JSONBody(entryImportBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Entry updated", usecase.UpdateEntryOutput{}),
+		}, "400", "404", "413", "422", "500"),
+	})
+	doc.Add("PATCH /rank/{rankId}/entry/{id}", &openapi.Operation{
+		OperationId: "patchEntry",
+		Summary:     "Partially update an entry",
+		Tags:        []string{"entry"},
+		RequestBody: mergePatchBody(doc, entryBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Entry updated", usecase.PatchEntryOutput{}),
+		}, "400", "404", "413", "415", "422", "500"),
+	})
+	doc.Add("DELETE /rank/{rankId}/entry/{id}", &openapi.Operation{
+		OperationId: "deleteEntry",
+		Summary:     "Delete an entry",
+		Tags:        []string{"entry"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Entry deleted", messageBody{}),
+		}, "404", "500"),
+	})
+	doc.Add("POST /rank/{rankId}/entry/{id}/media", &openapi.Operation{
+		OperationId: "addMedia",
+		Summary:     "Add a media item to the gallery of an entry",
+		Tags:        []string{"entry"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(mediaBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"201": doc.JSONResponse("Media of the entry", usecase.EntryMediaOutput{}),
+		}, "400", "404", "409", "413", "422", "500"),
+	})
+	doc.Add("POST /rank/{rankId}/entry/{id}/media:reorder", &openapi.Operation{
+		OperationId: "reorderMedia",
+		Summary:     "Reorder the gallery of an entry and optionally change its cover",
+		Tags:        []string{"entry"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(reorderMediaBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Media of the entry", usecase.EntryMediaOutput{}),
+		}, "400", "404", "409", "413", "422", "500"),
+	})
+	doc.Add("DELETE /rank/{rankId}/entry/{id}/media/{mediaId}", &openapi.Operation{
+		OperationId: "removeMedia",
+		Summary:     "Remove a media item from the gallery of an entry",
+		Tags:        []string{"entry"},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Media of the entry", usecase.EntryMediaOutput{}),
+		}, "404", "409", "500"),
+	})
+	doc.Add("GET /rank/{id}/table", &openapi.Operation{
+		OperationId: "findRankTable",
+		Summary:     "Find a rank with its attributes and entries",
+		Tags:        []string{"rank"},
+		Parameters: []openapi.Parameter{
+			{Name: "If-None-Match", In: "header", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "If-Modified-Since", In: "header", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": rankTableResponse(doc.JSONResponse("Rank table found", usecase.FindRankTableOutput{})),
+			"304": rankTableResponse(&openapi.Response{Description: "Rank table not modified"}),
+		}, "404", "500"),
+	})
+	doc.Add("POST /rank/{id}/scores:batch", &openapi.Operation{
+		OperationId: "batchScores",
+		Summary:     "Update scores of several entries of a rank",
+		Tags:        []string{"entry"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(scoresBatchBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Per-change results of the batch", usecase.BatchScoresOutput{}),
+		}, "400", "404", "409", "413", "422", "500"),
+	})
+	doc.Add("POST /rank/{id}/images:import", &openapi.Operation{
+		OperationId: "importImages",
+		Summary:     "Copy the images entries of a rank reference on other sites into the storage",
+		Tags:        []string{"entry"},
+		Parameters: []openapi.Parameter{
+			idempotencyKeyParameter,
+			{Name: "after", In: "query", Description: "The next value of a previous import, to import the images it left", Schema: &openapi.Schema{Type: "string", Format: "uuid"}},
+		},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Per-entry results of the import, of at most 20 entries", usecase.ImportImagesOutput{}),
+		}, "404", "409", "422", "500"),
+	})
+	doc.Add("POST /rank/{id}/file", &openapi.Operation{
+		OperationId: "uploadFile",
+		Summary:     "Upload an image for a rank",
+		Tags:        []string{"file"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]*openapi.MediaType{
+				"multipart/form-data": {Schema: &openapi.Schema{
+					Type: "object",
+					Properties: map[string]*openapi.Schema{
+						"image": {Type: "string", Format: "binary"},
+					},
+					Required: []string{"image"},
+				}},
+			},
+		},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("File uploaded", usecase.UploadOutput{}),
+		}, "400", "409", "413", "415", "422", "500"),
+	})
+	doc.Add("POST /rank/{id}/file:presign", &openapi.Operation{
+		OperationId: "presignUpload",
+		Summary:     "Get a presigned request to upload an image straight to S3",
+		Tags:        []string{"file"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(presignUploadBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Presigned upload request", usecase.PresignUploadOutput{}),
+		}, "400", "409", "413", "422", "500"),
+	})
+	doc.Add("POST /rank/{id}/file:confirm", &openapi.Operation{
+		OperationId: "confirmUpload",
+		Summary:     "Check an image uploaded with a presigned request and store its variants",
+		Tags:        []string{"file"},
+		Parameters:  []openapi.Parameter{idempotencyKeyParameter},
+		RequestBody: doc.JSONBody(confirmUploadBody{}),
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": doc.JSONResponse("Upload confirmed", usecase.UploadOutput{}),
+		}, "400", "404", "409", "413", "422", "500"),
+	})
+	doc.Add("GET /files/{path...}", &openapi.Operation{
+		OperationId: "getFile",
+		Summary:     "Download a file kept on the API's file system",
+		Tags:        []string{"file"},
+		Parameters: []openapi.Parameter{
+			{Name: "expires", In: "query", Description: "Expiry of a signed URL, required with signature for the files of private ranks", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "signature", In: "query", Description: "Signature of a signed URL, required for the files of private ranks", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "Range", In: "header", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "If-None-Match", In: "header", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "If-Modified-Since", In: "header", Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: responses(doc, map[string]*openapi.Response{
+			"200": fileResponse(&openapi.Response{Description: "File content", Content: fileContent()}),
+			"206": fileResponse(&openapi.Response{Description: "Requested range of the file", Content: fileContent()}),
+			"304": fileResponse(&openapi.Response{Description: "File not modified"}),
+		}, "403", "404", "500"),
+	})
+	doc.Add("GET /healthz", &openapi.Operation{
+		OperationId: "healthz",
+		Summary:     "Liveness probe",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": doc.JSONResponse("Service is alive", healthBody{}),
+		},
+	})
+	doc.Add("GET /readyz", &openapi.Operation{
+		OperationId: "readyz",
+		Summary:     "Readiness probe",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": doc.JSONResponse("Service is ready", readinessBody{}),
+			"503": doc.JSONResponse("A dependency is unavailable", readinessBody{}),
+		},
+	})
+	doc.Add("GET /metrics", &openapi.Operation{
+		OperationId: "metrics",
+		Summary:     "Prometheus metrics",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "Metrics in Prometheus text format",
+				Content: map[string]*openapi.MediaType{
+					"text/plain": {Schema: &openapi.Schema{Type: "string"}},
+				},
+			},
+		},
+	})
+	doc.Add("GET /openapi.json", &openapi.Operation{
+		OperationId: "openapi",
+		Summary:     "OpenAPI document",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": doc.JSONResponse("OpenAPI document", &openapi.Schema{Type: "object"}),
+		},
+	})
+	doc.Add("GET /docs", &openapi.Operation{
+		OperationId: "docs",
+		Summary:     "API documentation page",
+		Tags:        []string{"operations"},
+		Responses: map[string]*openapi.Response{
+			"200": {
+				Description: "HTML documentation page",
+				Content: map[string]*openapi.MediaType{
+					"text/html": {Schema: &openapi.Schema{Type: "string"}},
+				},
+			},
+		},
+	})
+	return doc
+}
+
+var idempotencyKeyParameter = openapi.Parameter{
+	Name:        "Idempotency-Key",
+	In:          "header",
+	Description: "Retries with the same key replay the first response instead of repeating the request. Requests with a key must not have a body larger than 16 MiB",
+	Schema:      &openapi.Schema{Type: "string"},
+}
+
+// fileResponse documents the headers of downloaded files.
+func fileResponse(res *openapi.Response) *openapi.Response {
+	res.Headers = map[string]*openapi.Header{
+		"ETag":          {Schema: &openapi.Schema{Type: "string"}},
+		"Last-Modified": {Schema: &openapi.Schema{Type: "string"}},
+		"Cache-Control": {Schema: &openapi.Schema{Type: "string"}},
+	}
+	return res
+}
+
+func fileContent() map[string]*openapi.MediaType {
+	return map[string]*openapi.MediaType{
+		"application/octet-stream": {Schema: &openapi.Schema{Type: "string", Format: "binary"}},
+	}
+}
+
+// rankTableResponse documents the validators and caching policy of the rank
+// table. Public ranks may be cached by shared caches; private ones never.
+func rankTableResponse(res *openapi.Response) *openapi.Response {
+	res.Headers = map[string]*openapi.Header{
+		"ETag":          {Schema: &openapi.Schema{Type: "string"}},
+		"Last-Modified": {Schema: &openapi.Schema{Type: "string"}},
+		"Cache-Control": {
+			Description: "public, max-age=60 for public ranks, private, no-store otherwise",
+			Schema:      &openapi.Schema{Type: "string"},
+		},
+	}
+	return res
+}
+
+func mergePatchBody(doc *openapi.Document, v any) *openapi.RequestBody {
+	return &openapi.RequestBody{
+		Required: true,
+		Content: map[string]*openapi.MediaType{
+			mergepatch.ContentType: {Schema: doc.SchemaOf(v)},
+		},
+	}
+}
+
+func responses(doc *openapi.Document, success map[string]*openapi.Response, errors ...string) map[string]*openapi.Response {
+	for _, status := range errors {
+		success[status] = &openapi.Response{
+			Description: errorDescriptions[status],
+			Content: map[string]*openapi.MediaType{
+				problem.ContentType: {Schema: doc.SchemaOf(problem.Problem{})},
+			},
+		}
+	}
+	return success
+}
+
+var errorDescriptions = map[string]string{
+	"400": "Malformed request",
+	"403": "Access denied",
+	"404": "Resource not found",
+	"409": "Conflict with another request, such as one with the same Idempotency-Key still being processed",
+	"413": "Request body too large",
+	"415": "Unsupported media type",
+	"422": "Validation failed",
+	"500": "Internal server error",
+}
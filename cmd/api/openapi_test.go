@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+)
+
+func TestOpenAPIDocument(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", storageFileSystem)
+	app := newApplication()
+	app.repos = &repositories{}
+	app.presigner = storage.NewInMemoryStorage()
+	app.initUsecases()
+	app.initHandlers()
+	doc := newOpenAPIDocument()
+	t.Run("routes", func(t *testing.T) {
+		for pattern := range app.handlers {
+			if !doc.Has(pattern) {
+				t.Errorf("route %q is missing from the OpenAPI document", pattern)
+			}
+		}
+	})
+	t.Run("schemas", func(t *testing.T) {
+		for _, name := range []string{"CreateRankOutput", "FindRankTableOutput", "EntryOutput", "Problem", "FieldError"} {
+			if _, ok := doc.Components.Schemas[name]; !ok {
+				t.Errorf("schema %q is missing from the OpenAPI document", name)
+			}
+		}
+	})
+	t.Run("marshal", func(t *testing.T) {
+		if _, err := json.Marshal(doc); err != nil {
+			t.Errorf("Marshal() got %v, want %v", err, nil)
+		}
+	})
+}
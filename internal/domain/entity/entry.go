@@ -13,6 +13,8 @@ type Entry struct {
 	ImageURL string
 	Scores   Scores
 	RankId   string
+	// Media are ordered as shown to clients.
+	Media []Media
 }
 
 func NewEntry(name, imageURL string, scores Scores, rankId string) *Entry {
@@ -31,3 +33,31 @@ func ValidateEntry(v *validator.Validator, entry *Entry) {
 	v.Check(validator.IsURL(entry.ImageURL), "image_url", "must be a valid URL")
 	v.Check(validator.IsUUID(entry.RankId), "rank_id", "must be a valid UUID")
 }
+
+// Cover returns the media item that is the cover of the entry, or nil.
+func (e *Entry) Cover() *Media {
+	for i := range e.Media {
+		if e.Media[i].Cover {
+			return &e.Media[i]
+		}
+	}
+	return nil
+}
+
+// CoverURL returns the URL of the image shown for the entry, which is the
+// ImageURL of entries without a cover.
+func (e *Entry) CoverURL() string {
+	if cover := e.Cover(); cover != nil {
+		return cover.URL
+	}
+	return e.ImageURL
+}
+
+// URLs returns the URLs the entry references, its image first.
+func (e *Entry) URLs() []string {
+	urls := []string{e.ImageURL}
+	for _, media := range e.Media {
+		urls = append(urls, media.URL)
+	}
+	return urls
+}
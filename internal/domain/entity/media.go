@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"github.com/josimarz/ranking-backend/internal/validator"
+)
+
+const (
+	MediaImage = "image"
+	MediaVideo = "video"
+)
+
+// MaxMedia bounds the media of an entry, which are stored along with it.
+const MaxMedia = 20
+
+type Media struct {
+	Id      string
+	URL     string
+	Alt     string
+	Caption string
+	Kind    string
+	// Cover marks the image shown for the entry in the rank table. At most
+	// one media item of an entry is the cover.
+	Cover bool
+}
+
+func NewMedia(url, alt, caption, kind string, cover bool) *Media {
+	return &Media{
+		Id:      uuid.NewString(),
+		URL:     url,
+		Alt:     alt,
+		Caption: caption,
+		Kind:    kind,
+		Cover:   cover,
+	}
+}
+
+func ValidateMedia(v *validator.Validator, media *Media) {
+	v.Check(validator.IsUUID(media.Id), "id", "must be a valid UUID")
+	v.Check(validator.IsURL(media.URL), "url", "must be a valid URL")
+	v.Check(len(media.Alt) <= 250, "alt", "must be a maximum of 250 characters long")
+	v.Check(len(media.Caption) <= 500, "caption", "must be a maximum of 500 characters long")
+	v.Check(media.Kind == MediaImage || media.Kind == MediaVideo, "kind", "must be image or video")
+	v.Check(!media.Cover || media.Kind == MediaImage, "cover", "must be an image")
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/josimarz/ranking-backend/internal/validator"
+)
+
+func TestValidateMedia(t *testing.T) {
+	v := validator.New()
+	media := NewMedia("https://videogame.com/smd-box.png", "Box art", "The box of the console", MediaImage, true)
+	ValidateMedia(v, media)
+	if got := v.Valid(); !got {
+		t.Errorf("media validation failed: got %v, want %v", got, true)
+	}
+
+	media.Id = "123"
+	media.URL = "videogame/smd-box.png"
+	media.Kind = "audio"
+	ValidateMedia(v, media)
+	if got := v.Valid(); got {
+		t.Errorf("media validation failed: got %v, want %v", got, false)
+	}
+
+	want := map[string]string{
+		"id":    "must be a valid UUID",
+		"url":   "must be a valid URL",
+		"kind":  "must be image or video",
+		"cover": "must be an image",
+	}
+	if got := v.Errors(); !reflect.DeepEqual(got, want) {
+		t.Errorf("validation returned wrong errors: got %v, want %v", got, want)
+	}
+}
+
+func TestEntryCover(t *testing.T) {
+	entry := NewEntry("Sega Mega Drive", "https://videogame.com/smd.png", Scores{}, "")
+	if got := entry.CoverURL(); got != entry.ImageURL {
+		t.Errorf("CoverURL() of an entry without media got %v, want %v", got, entry.ImageURL)
+	}
+	entry.Media = []Media{
+		*NewMedia("https://videogame.com/smd-trailer", "", "", MediaVideo, false),
+		*NewMedia("https://videogame.com/smd-box.png", "", "", MediaImage, true),
+	}
+	if got, want := entry.CoverURL(), entry.Media[1].URL; got != want {
+		t.Errorf("CoverURL() got %v, want %v", got, want)
+	}
+	want := []string{entry.ImageURL, entry.Media[0].URL, entry.Media[1].URL}
+	if got := entry.URLs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("URLs() got %v, want %v", got, want)
+	}
+}
@@ -1,9 +1,14 @@
 package entity
 
+import "time"
+
 type RankTable struct {
 	Id      string
 	Name    string
 	Public  bool
 	Attrs   []Attribute
 	Entries []Entry
+	// UpdatedAt is the last time the rank or any of its attributes and
+	// entries changed.
+	UpdatedAt time.Time
 }
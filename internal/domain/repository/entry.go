@@ -2,13 +2,30 @@ package repository
 
 import (
 	"context"
+	"errors"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 )
 
+// ErrStale is returned by conditional updates of entries that changed since
+// they were read, or no longer exist.
+var ErrStale = errors.New("entry changed since it was read")
+
 type EntryRepository interface {
 	Create(context.Context, *entity.Entry) error
 	FindById(context.Context, string, string) (*entity.Entry, error)
+	// Update sets the name, image URL and scores of the entry, leaving its
+	// media as they are, as they are only changed through UpdateMedia. It
+	// returns ErrStale if the entry no longer exists.
 	Update(context.Context, *entity.Entry) error
 	Delete(context.Context, *entity.Entry) error
+	UpdateScores(context.Context, []*entity.Entry) error
+	// UpdateImageURL sets the image URL of the entry to entry.ImageURL,
+	// leaving the rest of it as it is, provided its image URL is still the
+	// given one. It returns ErrStale otherwise.
+	UpdateImageURL(ctx context.Context, entry *entity.Entry, from string) error
+	// UpdateMedia sets the media of the entry to entry.Media, leaving the rest
+	// of it as it is, provided its media are still the given ones. It
+	// returns ErrStale otherwise.
+	UpdateMedia(ctx context.Context, entry *entity.Entry, from []entity.Media) error
 }
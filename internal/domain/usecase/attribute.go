@@ -5,6 +5,7 @@ import (
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/validator"
 )
 
 type CreateAttributeInput *entity.Attribute
@@ -114,6 +115,73 @@ func (uc *UpdateAttributeUsecase) Execute(ctx context.Context, input UpdateAttri
 	}, nil
 }
 
+type PatchAttributeInput struct {
+	RankId string
+	Id     string
+	Patch  []byte
+}
+
+type PatchAttributeOutput struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Desc   string `json:"description"`
+	Order  int    `json:"order"`
+	RankId string `json:"rank_id"`
+}
+
+type attributeDocument struct {
+	Name  string `json:"name"`
+	Desc  string `json:"description"`
+	Order int    `json:"order"`
+}
+
+type PatchAttributeUsecase struct {
+	repo repository.AttributeRepository
+}
+
+func NewPatchAttributeUsecase(repo repository.AttributeRepository) *PatchAttributeUsecase {
+	return &PatchAttributeUsecase{repo}
+}
+
+func (uc *PatchAttributeUsecase) Execute(ctx context.Context, input PatchAttributeInput) (*PatchAttributeOutput, error) {
+	attr, err := uc.repo.FindById(ctx, input.RankId, input.Id)
+	if err != nil {
+		return nil, err
+	}
+	if attr == nil {
+		return nil, &ResourceNotFoundError{name: "attribute", id: input.Id}
+	}
+	doc, err := applyPatch(attributeDocument{
+		Name:  attr.Name,
+		Desc:  attr.Desc,
+		Order: attr.Order,
+	}, input.Patch)
+	if err != nil {
+		return nil, err
+	}
+	patched := &entity.Attribute{
+		Id:     attr.Id,
+		Name:   doc.Name,
+		Desc:   doc.Desc,
+		Order:  doc.Order,
+		RankId: attr.RankId,
+	}
+	v := validator.New()
+	if entity.ValidateAttribute(v, patched); !v.Valid() {
+		return nil, NewValidationError(v.Errors())
+	}
+	if err := uc.repo.Update(ctx, patched); err != nil {
+		return nil, err
+	}
+	return &PatchAttributeOutput{
+		Id:     patched.Id,
+		Name:   patched.Name,
+		Desc:   patched.Desc,
+		Order:  patched.Order,
+		RankId: patched.RankId,
+	}, nil
+}
+
 type DeleteAttributeInput struct {
 	RankId string
 	Id     string
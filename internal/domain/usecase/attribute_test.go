@@ -84,6 +84,43 @@ func TestUpdateAttributeUsecase(t *testing.T) {
 	})
 }
 
+func TestPatchAttributeUsecase(t *testing.T) {
+	ctx := context.Background()
+	repo := &inmemory.AttributeInMemoryRepository{}
+	uc := NewPatchAttributeUsecase(repo)
+	attr := mock.Attrs[2]
+	if err := repo.Create(ctx, &attr); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("Execute", func(t *testing.T) {
+		input := PatchAttributeInput{
+			RankId: attr.RankId,
+			Id:     attr.Id,
+			Patch:  []byte(`{"order":4}`),
+		}
+		want := &PatchAttributeOutput{
+			Id:     attr.Id,
+			Name:   attr.Name,
+			Desc:   attr.Desc,
+			Order:  4,
+			RankId: attr.RankId,
+		}
+		if got, err := uc.Execute(ctx, input); err != nil || *got != *want {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, want, nil)
+		}
+		input.Patch = []byte(`{"order":0}`)
+		var validationErr *ValidationError
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) || validationErr.Errors["order"] == "" {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want a validation error for order", ctx, input, got, err)
+		}
+		input.Id = "3c1d0a9e-6b4f-4e7a-8f2d-9a0b1c2d3e4f"
+		notFoundErr := &ResourceNotFoundError{name: "attribute", id: input.Id}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+}
+
 func TestDeleteAttributeUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.AttributeInMemoryRepository{}
@@ -2,12 +2,19 @@ package usecase
 
 import (
 	"context"
+	"errors"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/validator"
 )
 
-type CreateEntryInput *entity.Entry
+type CreateEntryInput struct {
+	*entity.Entry
+	// ImportImage replaces an ImageURL pointing to another site with a copy
+	// of the image in the storage.
+	ImportImage bool
+}
 
 type CreateEntryOutput struct {
 	Id       string        `json:"id"`
@@ -18,21 +25,35 @@ type CreateEntryOutput struct {
 }
 
 type CreateEntryUsecase struct {
-	repo repository.EntryRepository
+	repo     repository.EntryRepository
+	urls     *ImageSigner
+	importer *ImageImporter
 }
 
-func NewCreateEntryUsecase(repo repository.EntryRepository) *CreateEntryUsecase {
-	return &CreateEntryUsecase{repo}
+func NewCreateEntryUsecase(repo repository.EntryRepository, urls *ImageSigner, importer *ImageImporter) *CreateEntryUsecase {
+	return &CreateEntryUsecase{repo, urls, importer}
 }
 
 func (uc *CreateEntryUsecase) Execute(ctx context.Context, input CreateEntryInput) (*CreateEntryOutput, error) {
-	if err := uc.repo.Create(ctx, input); err != nil {
+	if input.ImportImage {
+		imageURL, err := uc.importer.importImage(ctx, input.RankId, input.ImageURL)
+		if err != nil {
+			return nil, err
+		}
+		input.ImageURL = imageURL
+	}
+	input.ImageURL = uc.urls.canonical(input.ImageURL)
+	if err := uc.repo.Create(ctx, input.Entry); err != nil {
+		return nil, err
+	}
+	imageURL, err := uc.urls.signForRank(ctx, input.RankId, input.ImageURL)
+	if err != nil {
 		return nil, err
 	}
 	return &CreateEntryOutput{
 		Id:       input.Id,
 		Name:     input.Name,
-		ImageURL: input.ImageURL,
+		ImageURL: imageURL,
 		Scores:   input.Scores,
 		RankId:   input.RankId,
 	}, nil
@@ -49,14 +70,16 @@ type FindEntryOutput struct {
 	ImageURL string        `json:"image_url"`
 	Score    entity.Scores `json:"scores"`
 	RankId   string        `json:"rank_id"`
+	Media    []MediaOutput `json:"media"`
 }
 
 type FindEntryUsecase struct {
 	repo repository.EntryRepository
+	urls *ImageSigner
 }
 
-func NewFindEntryUsecase(repo repository.EntryRepository) *FindEntryUsecase {
-	return &FindEntryUsecase{repo}
+func NewFindEntryUsecase(repo repository.EntryRepository, urls *ImageSigner) *FindEntryUsecase {
+	return &FindEntryUsecase{repo, urls}
 }
 
 func (uc *FindEntryUsecase) Execute(ctx context.Context, input FindEntryInput) (*FindEntryOutput, error) {
@@ -67,16 +90,34 @@ func (uc *FindEntryUsecase) Execute(ctx context.Context, input FindEntryInput) (
 	if entry == nil {
 		return nil, &ResourceNotFoundError{name: "entry", id: input.Id}
 	}
+	private, err := uc.urls.isPrivate(ctx, entry.RankId)
+	if err != nil {
+		return nil, err
+	}
+	imageURL, err := uc.urls.sign(ctx, private, entry.ImageURL)
+	if err != nil {
+		return nil, err
+	}
+	media, err := mediaOutputs(ctx, uc.urls, private, entry.Media)
+	if err != nil {
+		return nil, err
+	}
 	return &FindEntryOutput{
 		Id:       entry.Id,
 		Name:     entry.Name,
-		ImageURL: entry.ImageURL,
+		ImageURL: imageURL,
 		Score:    entry.Scores,
 		RankId:   entry.RankId,
+		Media:    media,
 	}, nil
 }
 
-type UpdateEntryInput *entity.Entry
+type UpdateEntryInput struct {
+	*entity.Entry
+	// ImportImage replaces an ImageURL pointing to another site with a copy
+	// of the image in the storage.
+	ImportImage bool
+}
 
 type UpdateEntryOutput struct {
 	Id       string        `json:"id"`
@@ -87,11 +128,14 @@ type UpdateEntryOutput struct {
 }
 
 type UpdateEntryUsecase struct {
-	repo repository.EntryRepository
+	repo     repository.EntryRepository
+	images   *ImageCleaner
+	urls     *ImageSigner
+	importer *ImageImporter
 }
 
-func NewUpdateEntryUsecase(repo repository.EntryRepository) *UpdateEntryUsecase {
-	return &UpdateEntryUsecase{repo}
+func NewUpdateEntryUsecase(repo repository.EntryRepository, images *ImageCleaner, urls *ImageSigner, importer *ImageImporter) *UpdateEntryUsecase {
+	return &UpdateEntryUsecase{repo, images, urls, importer}
 }
 
 func (uc *UpdateEntryUsecase) Execute(ctx context.Context, input UpdateEntryInput) (*UpdateEntryOutput, error) {
@@ -102,18 +146,115 @@ func (uc *UpdateEntryUsecase) Execute(ctx context.Context, input UpdateEntryInpu
 	if entry == nil {
 		return nil, &ResourceNotFoundError{name: "entry", id: input.Id}
 	}
-	if err := uc.repo.Update(ctx, input); err != nil {
+	if input.ImportImage {
+		imageURL, err := uc.importer.importImage(ctx, input.RankId, input.ImageURL)
+		if err != nil {
+			return nil, err
+		}
+		input.ImageURL = imageURL
+	}
+	input.ImageURL = uc.urls.canonical(input.ImageURL)
+	err = uc.repo.Update(ctx, input.Entry)
+	if errors.Is(err, repository.ErrStale) {
+		// Deleted since it was read.
+		return nil, &ResourceNotFoundError{name: "entry", id: input.Id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.images.releaseReplaced(ctx, entry.RankId, entry.ImageURL, input.ImageURL)
+	imageURL, err := uc.urls.signForRank(ctx, input.RankId, input.ImageURL)
+	if err != nil {
 		return nil, err
 	}
 	return &UpdateEntryOutput{
 		Id:       input.Id,
 		Name:     input.Name,
-		ImageURL: input.ImageURL,
+		ImageURL: imageURL,
 		Scores:   input.Scores,
 		RankId:   input.RankId,
 	}, nil
 }
 
+type PatchEntryInput struct {
+	RankId string
+	Id     string
+	Patch  []byte
+}
+
+type PatchEntryOutput struct {
+	Id       string        `json:"id"`
+	Name     string        `json:"name"`
+	ImageURL string        `json:"image_url"`
+	Scores   entity.Scores `json:"scores"`
+	RankId   string        `json:"rank_id"`
+}
+
+type entryDocument struct {
+	Name     string        `json:"name"`
+	ImageURL string        `json:"image_url"`
+	Scores   entity.Scores `json:"scores"`
+}
+
+type PatchEntryUsecase struct {
+	repo   repository.EntryRepository
+	images *ImageCleaner
+	urls   *ImageSigner
+}
+
+func NewPatchEntryUsecase(repo repository.EntryRepository, images *ImageCleaner, urls *ImageSigner) *PatchEntryUsecase {
+	return &PatchEntryUsecase{repo, images, urls}
+}
+
+func (uc *PatchEntryUsecase) Execute(ctx context.Context, input PatchEntryInput) (*PatchEntryOutput, error) {
+	entry, err := uc.repo.FindById(ctx, input.RankId, input.Id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, &ResourceNotFoundError{name: "entry", id: input.Id}
+	}
+	doc, err := applyPatch(entryDocument{
+		Name:     entry.Name,
+		ImageURL: entry.ImageURL,
+		Scores:   entry.Scores,
+	}, input.Patch)
+	if err != nil {
+		return nil, err
+	}
+	patched := &entity.Entry{
+		Id:       entry.Id,
+		Name:     doc.Name,
+		ImageURL: uc.urls.canonical(doc.ImageURL),
+		Scores:   doc.Scores,
+		RankId:   entry.RankId,
+	}
+	v := validator.New()
+	if entity.ValidateEntry(v, patched); !v.Valid() {
+		return nil, NewValidationError(v.Errors())
+	}
+	err = uc.repo.Update(ctx, patched)
+	if errors.Is(err, repository.ErrStale) {
+		// Deleted since it was read.
+		return nil, &ResourceNotFoundError{name: "entry", id: input.Id}
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.images.releaseReplaced(ctx, entry.RankId, entry.ImageURL, patched.ImageURL)
+	imageURL, err := uc.urls.signForRank(ctx, patched.RankId, patched.ImageURL)
+	if err != nil {
+		return nil, err
+	}
+	return &PatchEntryOutput{
+		Id:       patched.Id,
+		Name:     patched.Name,
+		ImageURL: imageURL,
+		Scores:   patched.Scores,
+		RankId:   patched.RankId,
+	}, nil
+}
+
 type DeleteEntryInput struct {
 	RankId string
 	Id     string
@@ -122,11 +263,12 @@ type DeleteEntryInput struct {
 type DeleteEntryOutput struct{}
 
 type DeleteEntryUsecase struct {
-	repo repository.EntryRepository
+	repo   repository.EntryRepository
+	images *ImageCleaner
 }
 
-func NewDeleteEntryUsecase(repo repository.EntryRepository) *DeleteEntryUsecase {
-	return &DeleteEntryUsecase{repo}
+func NewDeleteEntryUsecase(repo repository.EntryRepository, images *ImageCleaner) *DeleteEntryUsecase {
+	return &DeleteEntryUsecase{repo, images}
 }
 
 func (uc *DeleteEntryUsecase) Execute(ctx context.Context, input DeleteEntryInput) (*DeleteEntryOutput, error) {
@@ -140,5 +282,8 @@ func (uc *DeleteEntryUsecase) Execute(ctx context.Context, input DeleteEntryInpu
 	if err := uc.repo.Delete(ctx, entry); err != nil {
 		return nil, err
 	}
+	for _, url := range entry.URLs() {
+		uc.images.releaseReplaced(ctx, entry.RankId, url, "")
+	}
 	return &DeleteEntryOutput{}, nil
 }
@@ -3,18 +3,22 @@ package usecase
 import (
 	"context"
 	"errors"
+	"log/slog"
+	"maps"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
 func TestCreateEntryUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := NewCreateEntryUsecase(repo)
+	uc := NewCreateEntryUsecase(repo, NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute), nil)
 	t.Run("Execute", func(t *testing.T) {
 		want := &CreateEntryOutput{
 			Id:       mock.Entries[0].Id,
@@ -23,7 +27,7 @@ func TestCreateEntryUsecase(t *testing.T) {
 			Scores:   mock.Entries[0].Scores,
 			RankId:   mock.Entries[0].RankId,
 		}
-		if got, err := uc.Execute(ctx, &mock.Entries[0]); err != nil || !reflect.DeepEqual(*got, *want) {
+		if got, err := uc.Execute(ctx, CreateEntryInput{Entry: &mock.Entries[0]}); err != nil || !reflect.DeepEqual(*got, *want) {
 			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, mock.Entries[0], got, err, want, nil)
 		}
 	})
@@ -32,7 +36,7 @@ func TestCreateEntryUsecase(t *testing.T) {
 func TestFindEntryUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := NewFindEntryUsecase(repo)
+	uc := NewFindEntryUsecase(repo, NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	t.Run("Execute", func(t *testing.T) {
 		entry := mock.Entries[0]
 		input := FindEntryInput{
@@ -45,6 +49,7 @@ func TestFindEntryUsecase(t *testing.T) {
 			ImageURL: entry.ImageURL,
 			Score:    entry.Scores,
 			RankId:   entry.RankId,
+			Media:    []MediaOutput{},
 		}
 		if got, err := uc.Execute(ctx, input); err != nil || !reflect.DeepEqual(*got, *want) {
 			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, want, nil)
@@ -61,7 +66,7 @@ func TestFindEntryUsecase(t *testing.T) {
 func TestUpdateEntryUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := NewUpdateEntryUsecase(repo)
+	uc := NewUpdateEntryUsecase(repo, NewImageCleaner(slog.New(slog.DiscardHandler), storage.NewInMemoryStorage(), &inmemory.RankTableInMemoryRepository{}, 0), NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute), nil)
 	t.Run("Execute", func(t *testing.T) {
 		entry := mock.Entries[0]
 		entry.Name = "Sega Dreamcast"
@@ -78,22 +83,64 @@ func TestUpdateEntryUsecase(t *testing.T) {
 			Scores:   entry.Scores,
 			RankId:   entry.RankId,
 		}
-		if got, err := uc.Execute(ctx, &entry); err != nil || !reflect.DeepEqual(*got, *want) {
+		if got, err := uc.Execute(ctx, UpdateEntryInput{Entry: &entry}); err != nil || !reflect.DeepEqual(*got, *want) {
 			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, entry, got, err, want, nil)
 		}
 		entry.Id = "cdd8c04f-cbde-4400-8448-7eaf7440a030"
 		entry.RankId = "bc288ada-cfb4-425b-80e3-bb0b5b3ff4f5"
 		notFoundErr := &ResourceNotFoundError{name: "entry", id: entry.Id}
-		if got, err := uc.Execute(ctx, &entry); got != nil || !errors.As(err, &notFoundErr) {
+		if got, err := uc.Execute(ctx, UpdateEntryInput{Entry: &entry}); got != nil || !errors.As(err, &notFoundErr) {
 			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, entry, got, err, nil, notFoundErr)
 		}
 	})
 }
 
+func TestPatchEntryUsecase(t *testing.T) {
+	ctx := context.Background()
+	repo := &inmemory.EntryInMemoryRepository{}
+	uc := NewPatchEntryUsecase(repo, NewImageCleaner(slog.New(slog.DiscardHandler), storage.NewInMemoryStorage(), &inmemory.RankTableInMemoryRepository{}, 0), NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
+	entry := mock.Entries[1]
+	entry.Scores = maps.Clone(entry.Scores)
+	if err := repo.Create(ctx, &entry); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("Execute", func(t *testing.T) {
+		input := PatchEntryInput{
+			RankId: entry.RankId,
+			Id:     entry.Id,
+			Patch:  []byte(`{"name":"NES","scores":{"Graphics":75,"Sound":null}}`),
+		}
+		want := &PatchEntryOutput{
+			Id:       entry.Id,
+			Name:     "NES",
+			ImageURL: entry.ImageURL,
+			Scores:   entity.Scores{"Controls": 70, "Graphics": 75},
+			RankId:   entry.RankId,
+		}
+		if got, err := uc.Execute(ctx, input); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, want, nil)
+		}
+		input.Patch = []byte(`{"name":"NE","rank_id":"1"}`)
+		var validationErr *ValidationError
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) || validationErr.Errors["rank_id"] == "" {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want a validation error for rank_id", ctx, input, got, err)
+		}
+		input.Patch = []byte(`{"scores":{"Sound":"loud"}}`)
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) || validationErr.Errors["scores.Sound"] == "" {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want a validation error for scores.Sound", ctx, input, got, err)
+		}
+		input.Id = "1d0b4b8e-3b0f-4f0e-9d1c-1fb5d1d2a0a3"
+		notFoundErr := &ResourceNotFoundError{name: "entry", id: input.Id}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+}
+
 func TestDeleteEntryUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := NewDeleteEntryUsecase(repo)
+	uc := NewDeleteEntryUsecase(repo, NewImageCleaner(slog.New(slog.DiscardHandler), storage.NewInMemoryStorage(), &inmemory.RankTableInMemoryRepository{}, 0))
 	t.Run("Execute", func(t *testing.T) {
 		entry := mock.Entries[0]
 		input := DeleteEntryInput{
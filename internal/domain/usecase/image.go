@@ -0,0 +1,340 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/validator"
+)
+
+// ImageCleaner deletes the files of images that entries no longer use.
+type ImageCleaner struct {
+	logger  *slog.Logger
+	storage storage.FileStorage
+	tables  repository.RankTableRepository
+	grace   time.Duration
+	now     func() time.Time
+}
+
+// NewImageCleaner needs a rank table repository that is complete and
+// reflects every finished write, since a stale or partial table could hide an
+// entry that still uses the image. Nothing is deleted when reading the table
+// fails. Images modified within grace are spared, as the orphan collector
+// does with the same grace period.
+func NewImageCleaner(logger *slog.Logger, storage storage.FileStorage, tables repository.RankTableRepository, grace time.Duration) *ImageCleaner {
+	return &ImageCleaner{logger, storage, tables, grace, time.Now}
+}
+
+// Release deletes the image at url when the API uploaded it for rankId and no
+// entry of the rank uses it anymore. It must be called after the entry that
+// used the image was changed. URLs pointing elsewhere are left alone.
+//
+// Uploads of the same content share files, so an image modified within the
+// grace period may have just been handed to a client that has not saved its
+// entry yet. It is left for the orphan collector.
+func (c *ImageCleaner) Release(ctx context.Context, rankId, url string) error {
+	files, ok := c.imageFiles(rankId, url)
+	if !ok {
+		return nil
+	}
+	table, err := c.tables.FindById(ctx, rankId)
+	if err != nil {
+		return err
+	}
+	if table != nil {
+		for _, entry := range table.Entries {
+			for _, url := range entry.URLs() {
+				if other, ok := c.imageFiles(rankId, url); ok && other == files {
+					return nil
+				}
+			}
+		}
+	}
+	infos, err := c.files(ctx, files)
+	if err != nil {
+		return err
+	}
+	cutoff := c.now().Add(-c.grace)
+	if slices.ContainsFunc(infos, func(info storage.FileInfo) bool {
+		return info.LastModified.After(cutoff)
+	}) {
+		return nil
+	}
+	for _, info := range infos {
+		if err := c.storage.Delete(ctx, info.Path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// files returns the files held by what imageFiles returned.
+func (c *ImageCleaner) files(ctx context.Context, files string) ([]storage.FileInfo, error) {
+	if strings.HasSuffix(files, "/") {
+		return c.storage.List(ctx, files)
+	}
+	info, err := c.storage.Stat(ctx, files)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []storage.FileInfo{*info}, nil
+}
+
+// releaseReplaced releases oldURL once an entry moved to newURL. The entry is
+// saved by then, so a failure only leaves unused files behind, for the
+// orphan collector to delete, and is logged rather than returned.
+func (c *ImageCleaner) releaseReplaced(ctx context.Context, rankId, oldURL, newURL string) {
+	if oldURL == newURL {
+		return
+	}
+	if err := c.Release(ctx, rankId, oldURL); err != nil {
+		c.logger.WarnContext(ctx, "could not release image", "rank_id", rankId, "url", oldURL, "error", err)
+	}
+}
+
+// imageFiles returns what holds the image at url: the {rankId}/{imageId}/
+// prefix shared by its variants, or the path of images uploaded as a single
+// file.
+func (c *ImageCleaner) imageFiles(rankId, url string) (string, bool) {
+	if url == "" {
+		return "", false
+	}
+	path, ok := c.storage.PathOf(url)
+	if !ok {
+		return "", false
+	}
+	return imageFilesOf(rankId, path)
+}
+
+// imageFilesOf is imageFiles for the path of a file in the storage.
+func imageFilesOf(rankId, path string) (string, bool) {
+	if !strings.HasPrefix(path, rankId+"/") {
+		return "", false
+	}
+	segments := strings.Split(path, "/")
+	switch {
+	case len(segments) == 3 && segments[1] != "":
+		return segments[0] + "/" + segments[1] + "/", true
+	case len(segments) == 2 && segments[1] != "":
+		return path, true
+	}
+	return "", false
+}
+
+type CollectOrphanImagesInput struct {
+	// GracePeriod spares files modified more recently, as they may belong to
+	// uploads whose entry is not saved yet.
+	GracePeriod time.Duration
+	// DryRun reports the orphans without deleting them.
+	DryRun bool
+}
+
+type OrphanImage struct {
+	Path         string    `json:"path"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+}
+
+type CollectOrphanImagesOutput struct {
+	Scanned int           `json:"scanned"`
+	Orphans []OrphanImage `json:"orphans"`
+	Deleted int           `json:"deleted"`
+	DryRun  bool          `json:"dry_run"`
+}
+
+type CollectOrphanImagesUsecase struct {
+	cleaner *ImageCleaner
+	now     func() time.Time
+}
+
+func NewCollectOrphanImagesUsecase(cleaner *ImageCleaner) *CollectOrphanImagesUsecase {
+	return &CollectOrphanImagesUsecase{cleaner, time.Now}
+}
+
+// Execute finds the files stored under a rank that no entry of the rank
+// uses, and deletes them unless input.DryRun is set. Only files under a rank
+// id are considered, so files the API did not upload are left alone. Ranks
+// that no longer exist have all their files collected.
+func (uc *CollectOrphanImagesUsecase) Execute(ctx context.Context, input CollectOrphanImagesInput) (*CollectOrphanImagesOutput, error) {
+	infos, err := uc.cleaner.storage.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[string][]storage.FileInfo)
+	for _, info := range infos {
+		rankId, _, ok := strings.Cut(info.Path, "/")
+		if ok && validator.IsUUID(rankId) {
+			ranks[rankId] = append(ranks[rankId], info)
+		}
+	}
+	output := &CollectOrphanImagesOutput{Orphans: []OrphanImage{}, DryRun: input.DryRun}
+	cutoff := uc.now().Add(-input.GracePeriod)
+	for _, rankId := range slices.Sorted(maps.Keys(ranks)) {
+		used, err := uc.usedImages(ctx, rankId)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range ranks[rankId] {
+			output.Scanned++
+			if files, ok := imageFilesOf(rankId, info.Path); ok && used[files] {
+				continue
+			}
+			if info.LastModified.After(cutoff) {
+				continue
+			}
+			output.Orphans = append(output.Orphans, OrphanImage{info.Path, info.Size, info.LastModified})
+			if input.DryRun {
+				continue
+			}
+			if err := uc.cleaner.storage.Delete(ctx, info.Path); err != nil {
+				return nil, err
+			}
+			output.Deleted++
+		}
+	}
+	return output, nil
+}
+
+// usedImages returns what holds the images and media of the entries of a
+// rank, as returned by imageFiles.
+func (uc *CollectOrphanImagesUsecase) usedImages(ctx context.Context, rankId string) (map[string]bool, error) {
+	table, err := uc.cleaner.tables.FindById(ctx, rankId)
+	if err != nil || table == nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	for _, entry := range table.Entries {
+		for _, url := range entry.URLs() {
+			if files, ok := uc.cleaner.imageFiles(rankId, url); ok {
+				used[files] = true
+			}
+		}
+	}
+	return used, nil
+}
+
+// ImageSigner keeps the images of private ranks private: their files are
+// stored privately and clients get URLs that expire.
+type ImageSigner struct {
+	storage storage.FileStorage
+	// signer is nil for storages that cannot keep files private, whose URLs
+	// are returned as they are.
+	signer  storage.Signer
+	ranks   repository.RankRepository
+	expires time.Duration
+}
+
+func NewImageSigner(storage storage.FileStorage, signer storage.Signer, ranks repository.RankRepository, expires time.Duration) *ImageSigner {
+	return &ImageSigner{storage, signer, ranks, expires}
+}
+
+// isPrivate tells whether the files of the rank must be stored privately.
+func (s *ImageSigner) isPrivate(ctx context.Context, rankId string) (bool, error) {
+	if s.signer == nil {
+		return false, nil
+	}
+	rank, err := s.ranks.FindById(ctx, rankId)
+	if err != nil || rank == nil {
+		return false, err
+	}
+	return !rank.Public, nil
+}
+
+// sign returns the URL clients use to read the image at url, which expires
+// when the rank is private.
+func (s *ImageSigner) sign(ctx context.Context, private bool, url string) (string, error) {
+	if !private || s.signer == nil || url == "" {
+		return url, nil
+	}
+	path, ok := s.storage.PathOf(url)
+	if !ok {
+		return url, nil
+	}
+	return s.signer.SignURL(ctx, path, s.expires)
+}
+
+// signForRank is sign for an image of the rank with the given id.
+func (s *ImageSigner) signForRank(ctx context.Context, rankId, url string) (string, error) {
+	if url == "" {
+		return url, nil
+	}
+	// Spare looking the rank up for entries without an image.
+	private, err := s.isPrivate(ctx, rankId)
+	if err != nil {
+		return "", err
+	}
+	return s.sign(ctx, private, url)
+}
+
+// canonical strips the signature from URLs that clients send back, so that
+// entries keep URLs that do not expire.
+func (s *ImageSigner) canonical(url string) string {
+	if path, ok := s.storage.PathOf(url); ok {
+		return s.storage.URLOf(path)
+	}
+	return url
+}
+
+// updateRank saves rank, whose previous version is old, along with the
+// visibility of its files. The files of a private rank are made private
+// before it is saved, so they are never readable while the rank is private,
+// and on every update, which catches files left public by an update that
+// failed or uploaded while the rank was being made private. The files of a
+// rank becoming public are made public once it is saved.
+func (s *ImageSigner) updateRank(ctx context.Context, repo repository.RankRepository, old, rank *entity.Rank) error {
+	if !rank.Public {
+		if err := s.setVisibility(ctx, rank.Id, false); err != nil {
+			return err
+		}
+	}
+	if err := repo.Update(ctx, rank); err != nil {
+		return err
+	}
+	if rank.Public && !old.Public {
+		return s.setVisibility(ctx, rank.Id, true)
+	}
+	return nil
+}
+
+// setVisibility makes the files of the rank public or private.
+func (s *ImageSigner) setVisibility(ctx context.Context, rankId string, public bool) error {
+	if s.signer == nil {
+		return nil
+	}
+	infos, err := s.storage.List(ctx, rankId+"/")
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if err := s.signer.SetPrivate(ctx, info.Path, !public); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// signUpload signs the URLs of every variant of an upload.
+func (s *ImageSigner) signUpload(ctx context.Context, private bool, output *UploadOutput) error {
+	for name, url := range output.Variants {
+		signed, err := s.sign(ctx, private, url)
+		if err != nil {
+			return err
+		}
+		output.Variants[name] = signed
+		if output.URL == url {
+			output.URL = signed
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/mock"
+)
+
+func TestImageCleaner(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	files := storage.NewInMemoryStorage()
+	entries := &inmemory.EntryInMemoryRepository{}
+	(&inmemory.RankInMemoryRepository{}).Create(ctx, &mock.Rank)
+	c := NewImageCleaner(slog.New(slog.DiscardHandler), files, &inmemory.RankTableInMemoryRepository{}, 0)
+	rankId := mock.Rank.Id
+	upload := func(path string) string {
+		url, err := files.Upload(ctx, path, strings.NewReader("image"), storage.UploadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return url
+	}
+	exists := func(path string) bool {
+		_, err := files.Stat(ctx, path)
+		return err == nil
+	}
+
+	thumbnail := upload(rankId + "/img-1/thumbnail.jpg")
+	upload(rankId + "/img-1/original.jpg")
+	legacy := upload(rankId + "/legacy.png")
+	other := upload("e9e4d0a4-1d5e-4c39-a1c5-6b1f6e7b2d10/img-2/original.jpg")
+	shared := upload(rankId + "/img-3/original.jpg")
+	entries.Create(ctx, &entity.Entry{Id: "a", Name: "A", ImageURL: shared, RankId: rankId})
+
+	for _, url := range []string{thumbnail, legacy, other, shared, "https://videogame.com/nes.png", ""} {
+		if err := c.Release(ctx, rankId, url); err != nil {
+			t.Errorf("Release(%v, %v, %v) got %v, want %v", ctx, rankId, url, err, nil)
+		}
+	}
+	for path, want := range map[string]bool{
+		rankId + "/img-1/thumbnail.jpg":                           false,
+		rankId + "/img-1/original.jpg":                            false,
+		rankId + "/legacy.png":                                    false,
+		"e9e4d0a4-1d5e-4c39-a1c5-6b1f6e7b2d10/img-2/original.jpg": true,
+		rankId + "/img-3/original.jpg":                            true,
+	} {
+		if got := exists(path); got != want {
+			t.Errorf("file %v exists got %v, want %v", path, got, want)
+		}
+	}
+	t.Run("releaseReplaced", func(t *testing.T) {
+		var logs bytes.Buffer
+		c := NewImageCleaner(slog.New(slog.NewTextHandler(&logs, nil)), files, &failingRankTableRepository{}, 0)
+		c.releaseReplaced(ctx, rankId, shared, "")
+		if !strings.Contains(logs.String(), "could not release image") || !exists(rankId+"/img-3/original.jpg") {
+			t.Errorf("releaseReplaced() with a failing repository logged %q, want the failure logged and the image kept", logs.String())
+		}
+	})
+}
+
+func TestImageCleanerGracePeriod(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	files := storage.NewInMemoryStorage()
+	ranks := &inmemory.RankInMemoryRepository{}
+	entries := &inmemory.EntryInMemoryRepository{}
+	ranks.Create(ctx, &mock.Rank)
+	rankId := mock.Rank.Id
+	cleaner := NewImageCleaner(slog.New(slog.DiscardHandler), files, &inmemory.RankTableInMemoryRepository{}, time.Hour)
+	urls := NewImageSigner(files, nil, ranks, time.Minute)
+	upload := NewUploadUsecase(files, imaging.DefaultVariants(), imaging.DefaultLimits(), urls)
+	create := NewCreateEntryUsecase(entries, urls, nil)
+	remove := NewDeleteEntryUsecase(entries, cleaner)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+		t.Fatal(err)
+	}
+	uploadImage := func() string {
+		t.Helper()
+		output, err := upload.Execute(ctx, UploadInput{RankId: rankId, File: bytes.NewReader(buf.Bytes())})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return output.URL
+	}
+	exists := func(url string) bool {
+		path, _ := files.PathOf(url)
+		_, err := files.Stat(ctx, path)
+		return err == nil
+	}
+
+	first := CreateEntryInput{Entry: &entity.Entry{Id: "a", Name: "A", ImageURL: uploadImage(), RankId: rankId}}
+	if _, err := create.Execute(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	// The same content is deduplicated to the files of the first entry, which
+	// is deleted before the second entry is saved.
+	second := CreateEntryInput{Entry: &entity.Entry{Id: "b", Name: "B", ImageURL: uploadImage(), RankId: rankId}}
+	if second.ImageURL != first.ImageURL {
+		t.Fatalf("upload of the same content got %v, want %v", second.ImageURL, first.ImageURL)
+	}
+	if _, err := remove.Execute(ctx, DeleteEntryInput{RankId: rankId, Id: first.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := create.Execute(ctx, second); err != nil {
+		t.Fatal(err)
+	}
+	if !exists(second.ImageURL) {
+		t.Errorf("image %v of the saved entry was deleted within the grace period", second.ImageURL)
+	}
+
+	cleaner.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := remove.Execute(ctx, DeleteEntryInput{RankId: rankId, Id: second.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if exists(second.ImageURL) {
+		t.Errorf("image %v was not deleted after the grace period", second.ImageURL)
+	}
+}
+
+type failingRankTableRepository struct{}
+
+func (r *failingRankTableRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	return nil, errors.New("table unavailable")
+}
+
+func TestCollectOrphanImagesUsecase(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	files := storage.NewInMemoryStorage()
+	(&inmemory.RankInMemoryRepository{}).Create(ctx, &mock.Rank)
+	uc := NewCollectOrphanImagesUsecase(NewImageCleaner(slog.New(slog.DiscardHandler), files, &inmemory.RankTableInMemoryRepository{}, 0))
+	rankId := mock.Rank.Id
+	deletedRankId := "e9e4d0a4-1d5e-4c39-a1c5-6b1f6e7b2d10"
+	upload := func(path string) string {
+		url, err := files.Upload(ctx, path, strings.NewReader("image"), storage.UploadOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return url
+	}
+	used := upload(rankId + "/img-1/original.jpg")
+	upload(rankId + "/img-1/thumbnail.jpg")
+	upload(rankId + "/img-2/original.jpg")
+	upload(rankId + "/legacy.png")
+	upload(deletedRankId + "/img-3/original.jpg")
+	upload("not-a-rank/file.png")
+	(&inmemory.EntryInMemoryRepository{}).Create(ctx, &entity.Entry{Id: "a", Name: "A", ImageURL: used, RankId: rankId})
+	orphans := []string{
+		rankId + "/img-2/original.jpg",
+		rankId + "/legacy.png",
+		deletedRankId + "/img-3/original.jpg",
+	}
+
+	t.Run("grace period", func(t *testing.T) {
+		input := CollectOrphanImagesInput{GracePeriod: time.Hour}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Scanned != 5 || len(got.Orphans) != 0 || got.Deleted != 0 {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want no orphans", ctx, input, got, err)
+		}
+	})
+	uc.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	t.Run("dry run", func(t *testing.T) {
+		input := CollectOrphanImagesInput{GracePeriod: time.Hour, DryRun: true}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Deleted != 0 || len(got.Orphans) != len(orphans) {
+			t.Fatalf("Execute(%v, %v) got (%+v, %v), want %v orphans", ctx, input, got, err, len(orphans))
+		}
+		for i, orphan := range got.Orphans {
+			if orphan.Path != orphans[i] {
+				t.Errorf("Execute(%v, %v) got orphan %v, want %v", ctx, input, orphan.Path, orphans[i])
+			}
+			if _, err := files.Stat(ctx, orphan.Path); err != nil {
+				t.Errorf("Execute(%v, %v) deleted %v in dry run", ctx, input, orphan.Path)
+			}
+		}
+	})
+	t.Run("Execute", func(t *testing.T) {
+		input := CollectOrphanImagesInput{GracePeriod: time.Hour}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Deleted != len(orphans) {
+			t.Fatalf("Execute(%v, %v) got (%+v, %v), want %v deleted", ctx, input, got, err, len(orphans))
+		}
+		infos, err := files.List(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, info := range infos {
+			paths = append(paths, info.Path)
+		}
+		want := []string{rankId + "/img-1/original.jpg", rankId + "/img-1/thumbnail.jpg", "not-a-rank/file.png"}
+		if !slices.Equal(paths, want) {
+			t.Errorf("files after Execute(%v, %v) got %v, want %v", ctx, input, paths, want)
+		}
+	})
+}
+
+func TestImageSigner(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	files := storage.NewInMemoryStorage()
+	ranks := &inmemory.RankInMemoryRepository{}
+	entries := &inmemory.EntryInMemoryRepository{}
+	rank := &entity.Rank{Id: "5d1f3a2b-7c4e-4b8a-9f6d-0e2c1b3a4d5f", Name: "Private", Public: false}
+	if err := ranks.Create(ctx, rank); err != nil {
+		t.Fatal(err)
+	}
+	urls := NewImageSigner(files, files, ranks, time.Minute)
+	signed := func(url string) bool {
+		return strings.Contains(url, "?expires=")
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+		t.Fatal(err)
+	}
+	upload, err := NewUploadUsecase(files, imaging.DefaultVariants(), imaging.DefaultLimits(), urls).Execute(ctx, UploadInput{RankId: rank.Id, File: &buf})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("upload", func(t *testing.T) {
+		for name, url := range upload.Variants {
+			if !signed(url) {
+				t.Errorf("variant %v of a private rank got URL %v, want a signed URL", name, url)
+			}
+			path, _ := files.PathOf(url)
+			if !files.IsPrivate(path) {
+				t.Errorf("variant %v of a private rank is not private", name)
+			}
+		}
+	})
+	t.Run("entry", func(t *testing.T) {
+		input := CreateEntryInput{Entry: &entity.Entry{Id: "8c2a4e6f-1b3d-4f5a-8e7c-9d0b2a4c6e8f", Name: "Entry", ImageURL: upload.URL, RankId: rank.Id}}
+		got, err := NewCreateEntryUsecase(entries, urls, nil).Execute(ctx, input)
+		if err != nil || !signed(got.ImageURL) {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want a signed image URL", ctx, input, got, err)
+		}
+		entry, _ := entries.FindById(ctx, rank.Id, input.Id)
+		if path, _ := files.PathOf(upload.URL); entry.ImageURL != files.URLOf(path) {
+			t.Errorf("entry stored image URL %v, want %v", entry.ImageURL, files.URLOf(path))
+		}
+	})
+	t.Run("reconcile", func(t *testing.T) {
+		path, _ := files.PathOf(upload.URL)
+		if err := files.SetPrivate(ctx, path, false); err != nil {
+			t.Fatal(err)
+		}
+		input := PatchRankInput{Id: rank.Id, Patch: []byte(`{"name":"Still private"}`)}
+		if _, err := NewPatchRankUsecase(ranks, urls).Execute(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+		if !files.IsPrivate(path) {
+			t.Errorf("file %v left public is still public after updating the private rank", path)
+		}
+	})
+	t.Run("visibility", func(t *testing.T) {
+		input := PatchRankInput{Id: rank.Id, Patch: []byte(`{"public":true}`)}
+		if _, err := NewPatchRankUsecase(ranks, urls).Execute(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+		infos, _ := files.List(ctx, rank.Id+"/")
+		for _, info := range infos {
+			if files.IsPrivate(info.Path) {
+				t.Errorf("file %v of a public rank is private", info.Path)
+			}
+		}
+		find := FindEntryInput{RankId: rank.Id, Id: "8c2a4e6f-1b3d-4f5a-8e7c-9d0b2a4c6e8f"}
+		if got, err := NewFindEntryUsecase(entries, urls).Execute(ctx, find); err != nil || signed(got.ImageURL) {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want an unsigned image URL", ctx, find, got, err)
+		}
+	})
+}
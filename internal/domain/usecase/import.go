@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/infra/remote"
+	"github.com/josimarz/ranking-backend/internal/validator"
+)
+
+// ImageImporter copies images entries reference on other sites into the
+// storage, so that they do not break once the sites move or block them.
+type ImageImporter struct {
+	fetcher *remote.Fetcher
+	upload  *UploadUsecase
+}
+
+func NewImageImporter(fetcher *remote.Fetcher, upload *UploadUsecase) *ImageImporter {
+	return &ImageImporter{fetcher, upload}
+}
+
+// importImage stores a copy of the image at url for the rank and returns its
+// URL. URLs of the storage are returned as they are.
+func (i *ImageImporter) importImage(ctx context.Context, rankId, url string) (string, error) {
+	if url == "" || !i.isRemote(url) {
+		return url, nil
+	}
+	data, err := i.fetcher.Fetch(ctx, url)
+	switch {
+	case errors.Is(err, remote.ErrInvalidURL):
+		return "", NewValidationError(map[string]string{"image_url": "must be an http or https URL"})
+	case errors.Is(err, remote.ErrForbiddenAddress):
+		return "", NewValidationError(map[string]string{"image_url": "must not point to a private address"})
+	case errors.Is(err, remote.ErrTooLarge):
+		return "", NewValidationError(map[string]string{"image_url": "points to an image that is too large"})
+	case errors.Is(err, remote.ErrUnavailable):
+		return "", NewValidationError(map[string]string{"image_url": "could not be fetched"})
+	case err != nil:
+		return "", err
+	}
+	output, err := i.upload.Execute(ctx, UploadInput{RankId: rankId, File: bytes.NewReader(data)})
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return "", NewValidationError(map[string]string{"image_url": validationErr.Errors["image"]})
+	}
+	if err != nil {
+		return "", err
+	}
+	return output.URL, nil
+}
+
+func (i *ImageImporter) isRemote(url string) bool {
+	_, ok := i.upload.storage.PathOf(url)
+	return !ok
+}
+
+const (
+	ImageImportImported = "imported"
+	ImageImportFailed   = "failed"
+)
+
+const (
+	// maxImportedImages bounds the images a single request imports. Clients
+	// import the others by continuing from ImportImagesOutput.Next.
+	maxImportedImages = 20
+	// importConcurrency bounds the images fetched and stored at once.
+	importConcurrency = 4
+)
+
+type ImportImagesInput struct {
+	RankId string
+	// After continues an import from the entry following the one with this
+	// id, as returned in ImportImagesOutput.Next.
+	After string
+}
+
+type ImageImportResult struct {
+	EntryId   string `json:"entry_id"`
+	SourceURL string `json:"source_url"`
+	ImageURL  string `json:"image_url,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+type ImportImagesOutput struct {
+	RankId   string              `json:"rank_id"`
+	Imported int                 `json:"imported"`
+	Failed   int                 `json:"failed"`
+	Results  []ImageImportResult `json:"results"`
+	// Next is set when images are left to import, and is the after
+	// parameter continuing the import.
+	Next string `json:"next,omitempty"`
+}
+
+type ImportImagesUsecase struct {
+	rankTableRepo repository.RankTableRepository
+	entryRepo     repository.EntryRepository
+	importer      *ImageImporter
+	urls          *ImageSigner
+	max           int
+}
+
+func NewImportImagesUsecase(rankTableRepo repository.RankTableRepository, entryRepo repository.EntryRepository, importer *ImageImporter, urls *ImageSigner) *ImportImagesUsecase {
+	return &ImportImagesUsecase{rankTableRepo, entryRepo, importer, urls, maxImportedImages}
+}
+
+// Execute imports the images of the entries of the rank that reference an
+// image on another site, going through the entries by id and stopping after
+// maxImportedImages of them. A failed import leaves the entry unchanged and
+// is reported in the per-entry results, as is an entry whose image URL
+// changed meanwhile, which keeps the new URL.
+func (uc *ImportImagesUsecase) Execute(ctx context.Context, input ImportImagesInput) (*ImportImagesOutput, error) {
+	if input.After != "" && !validator.IsUUID(input.After) {
+		return nil, NewValidationError(map[string]string{"after": "must be a valid UUID"})
+	}
+	table, err := uc.rankTableRepo.FindById(ctx, input.RankId)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, &ResourceNotFoundError{name: "rank", id: input.RankId}
+	}
+	var pending []entity.Entry
+	for _, entry := range table.Entries {
+		if entry.Id > input.After && entry.ImageURL != "" && uc.importer.isRemote(entry.ImageURL) {
+			entry.RankId = table.Id
+			pending = append(pending, entry)
+		}
+	}
+	slices.SortFunc(pending, func(a, b entity.Entry) int { return strings.Compare(a.Id, b.Id) })
+	output := &ImportImagesOutput{RankId: table.Id, Results: []ImageImportResult{}}
+	if len(pending) > uc.max {
+		pending = pending[:uc.max]
+		output.Next = pending[len(pending)-1].Id
+	}
+	imageURLs, errs := uc.importImages(ctx, pending)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	for i, entry := range pending {
+		result := ImageImportResult{EntryId: entry.Id, SourceURL: entry.ImageURL}
+		imageURL, err := imageURLs[i], errs[i]
+		if err == nil {
+			imageURL, err = uc.updateEntry(ctx, entry, imageURL)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			result.Status = ImageImportFailed
+			result.Error = importError(err)
+			output.Failed++
+		} else {
+			result.Status = ImageImportImported
+			result.ImageURL, err = uc.urls.sign(ctx, !table.Public, imageURL)
+			if err != nil {
+				return nil, err
+			}
+			output.Imported++
+		}
+		output.Results = append(output.Results, result)
+	}
+	return output, nil
+}
+
+// importImages imports the images of the entries, importConcurrency at a
+// time, and returns their URLs and errors in the order of the entries.
+func (uc *ImportImagesUsecase) importImages(ctx context.Context, entries []entity.Entry) ([]string, []error) {
+	imageURLs := make([]string, len(entries))
+	errs := make([]error, len(entries))
+	sem := make(chan struct{}, importConcurrency)
+	var wg sync.WaitGroup
+	for i, entry := range entries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			imageURLs[i], errs[i] = uc.importer.importImage(ctx, entry.RankId, entry.ImageURL)
+		}()
+	}
+	wg.Wait()
+	return imageURLs, errs
+}
+
+// updateEntry points the entry to its imported image, unless its image URL
+// changed since the rank table was read.
+func (uc *ImportImagesUsecase) updateEntry(ctx context.Context, entry entity.Entry, imageURL string) (string, error) {
+	updated := entry
+	updated.ImageURL = uc.urls.canonical(imageURL)
+	if err := uc.entryRepo.UpdateImageURL(ctx, &updated, entry.ImageURL); err != nil {
+		return "", err
+	}
+	return updated.ImageURL, nil
+}
+
+// importError describes to clients why importing an image failed.
+func importError(err error) string {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return "image_url " + validationErr.Errors["image_url"]
+	}
+	if errors.Is(err, repository.ErrStale) {
+		return "the entry changed during the import"
+	}
+	return "the image could not be stored"
+}
@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/remote"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+)
+
+func TestImageImporter(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write(buf.Bytes())
+		case "/text":
+			w.Write([]byte("not an image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	ranks := &inmemory.RankInMemoryRepository{}
+	entries := &inmemory.EntryInMemoryRepository{}
+	rank := &entity.Rank{Id: "3e7a1c5b-9d2f-4a6e-8b0c-1f4d7a2e5c9b", Name: "Imported", Public: true}
+	if err := ranks.Create(ctx, rank); err != nil {
+		t.Fatal(err)
+	}
+	files := storage.NewInMemoryStorage()
+	urls := NewImageSigner(files, nil, ranks, time.Minute)
+	upload := NewUploadUsecase(files, imaging.DefaultVariants(), imaging.DefaultLimits(), urls)
+	cfg := remote.DefaultConfig()
+	cfg.AllowPrivate = true
+	importer := NewImageImporter(remote.NewFetcher(cfg), upload)
+	t.Run("CreateEntryUsecase", func(t *testing.T) {
+		uc := NewCreateEntryUsecase(entries, urls, importer)
+		input := CreateEntryInput{
+			Entry:       entity.NewEntry("Remote", srv.URL+"/image.png", entity.Scores{}, rank.Id),
+			ImportImage: true,
+		}
+		got, err := uc.Execute(ctx, input)
+		if err != nil {
+			t.Fatalf("Execute(%v, %v) got %v, want %v", ctx, input, err, nil)
+		}
+		if _, ok := files.PathOf(got.ImageURL); !ok {
+			t.Errorf("Execute(%v, %v) got image URL %v, want a URL of the storage", ctx, input, got.ImageURL)
+		}
+		for path, want := range map[string]string{
+			"/text":    "must be a PNG, JPEG, GIF or WebP image",
+			"/missing": "could not be fetched",
+		} {
+			input := CreateEntryInput{
+				Entry:       entity.NewEntry("Remote", srv.URL+path, entity.Scores{}, rank.Id),
+				ImportImage: true,
+			}
+			var validationErr *ValidationError
+			if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) || validationErr.Errors["image_url"] != want {
+				t.Errorf("Execute(%v, %v) got (%v, %v), want image_url error %q", ctx, input, got, err, want)
+			}
+		}
+	})
+	t.Run("private address", func(t *testing.T) {
+		uc := NewCreateEntryUsecase(entries, urls, NewImageImporter(remote.NewFetcher(remote.DefaultConfig()), upload))
+		input := CreateEntryInput{
+			Entry:       entity.NewEntry("Remote", srv.URL+"/image.png", entity.Scores{}, rank.Id),
+			ImportImage: true,
+		}
+		var validationErr *ValidationError
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) || validationErr.Errors["image_url"] == "" {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want an image_url error", ctx, input, got, err)
+		}
+	})
+	t.Run("ImportImagesUsecase", func(t *testing.T) {
+		imported := entity.NewEntry("Imported", srv.URL+"/image.png", entity.Scores{}, rank.Id)
+		missing := entity.NewEntry("Missing", srv.URL+"/missing", entity.Scores{}, rank.Id)
+		for _, entry := range []*entity.Entry{imported, missing} {
+			if err := entries.Create(ctx, entry); err != nil {
+				t.Fatal(err)
+			}
+		}
+		uc := NewImportImagesUsecase(&inmemory.RankTableInMemoryRepository{}, entries, importer, urls)
+		input := ImportImagesInput{RankId: rank.Id}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Imported != 1 || got.Failed != 1 || len(got.Results) != 2 {
+			t.Fatalf("Execute(%v, %v) got (%+v, %v), want 1 imported and 1 failed", ctx, input, got, err)
+		}
+		for _, result := range got.Results {
+			entry, _ := entries.FindById(ctx, rank.Id, result.EntryId)
+			switch result.EntryId {
+			case imported.Id:
+				if result.Status != ImageImportImported || entry.ImageURL != result.ImageURL {
+					t.Errorf("entry %v got result %+v and image URL %v", entry.Id, result, entry.ImageURL)
+				}
+			case missing.Id:
+				if result.Status != ImageImportFailed || entry.ImageURL != missing.ImageURL {
+					t.Errorf("entry %v got result %+v and image URL %v", entry.Id, result, entry.ImageURL)
+				}
+			}
+		}
+		input.After = "not-an-id"
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.Is(err, ErrValidation) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrValidation)
+		}
+		input.After = ""
+		input.RankId = "b1d3f5a7-2c4e-4f6a-8b9d-0e1f2a3b4c5d"
+		notFoundErr := &ResourceNotFoundError{name: "rank", id: input.RankId}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+	t.Run("next", func(t *testing.T) {
+		inmemory.ClearDatabase()
+		if err := ranks.Create(ctx, rank); err != nil {
+			t.Fatal(err)
+		}
+		ids := []string{"1a2b3c4d-0000-4000-8000-000000000001", "1a2b3c4d-0000-4000-8000-000000000002", "1a2b3c4d-0000-4000-8000-000000000003"}
+		for _, id := range ids {
+			if err := entries.Create(ctx, &entity.Entry{Id: id, Name: "Entry " + id[len(id)-1:], ImageURL: srv.URL + "/image.png", RankId: rank.Id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		uc := NewImportImagesUsecase(&inmemory.RankTableInMemoryRepository{}, entries, importer, urls)
+		uc.max = 2
+		input := ImportImagesInput{RankId: rank.Id}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Imported != 2 || got.Next != ids[1] {
+			t.Fatalf("Execute(%v, %v) got (%+v, %v), want 2 imported and next %v", ctx, input, got, err, ids[1])
+		}
+		input.After = got.Next
+		got, err = uc.Execute(ctx, input)
+		if err != nil || got.Imported != 1 || got.Results[0].EntryId != ids[2] || got.Next != "" {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want %v imported and no next", ctx, input, got, err, ids[2])
+		}
+	})
+	t.Run("stale", func(t *testing.T) {
+		inmemory.ClearDatabase()
+		entry := entity.NewEntry("Changed", "https://videogame.com/new.png", entity.Scores{}, rank.Id)
+		if err := entries.Create(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+		read := *entry
+		read.ImageURL = srv.URL + "/image.png"
+		tables := &stubRankTableRepository{&entity.RankTable{Id: rank.Id, Public: true, Entries: []entity.Entry{read}}}
+		uc := NewImportImagesUsecase(tables, entries, importer, urls)
+		input := ImportImagesInput{RankId: rank.Id}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Failed != 1 || got.Results[0].Error != "the entry changed during the import" {
+			t.Fatalf("Execute(%v, %v) got (%+v, %v), want the entry reported as changed", ctx, input, got, err)
+		}
+		if stored, _ := entries.FindById(ctx, rank.Id, entry.Id); stored.ImageURL != entry.ImageURL {
+			t.Errorf("entry image URL got %v, want %v", stored.ImageURL, entry.ImageURL)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+)
+
+type MediaOutput struct {
+	Id      string `json:"id"`
+	URL     string `json:"url"`
+	Alt     string `json:"alt"`
+	Caption string `json:"caption"`
+	Kind    string `json:"kind"`
+	Cover   bool   `json:"cover"`
+}
+
+type EntryMediaOutput struct {
+	EntryId string        `json:"entry_id"`
+	RankId  string        `json:"rank_id"`
+	Media   []MediaOutput `json:"media"`
+}
+
+// mediaOutputs signs the URLs of the media of entries of private ranks.
+func mediaOutputs(ctx context.Context, urls *ImageSigner, private bool, media []entity.Media) ([]MediaOutput, error) {
+	outputs := make([]MediaOutput, 0, len(media))
+	for _, m := range media {
+		url, err := urls.sign(ctx, private, m.URL)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, MediaOutput{
+			Id:      m.Id,
+			URL:     url,
+			Alt:     m.Alt,
+			Caption: m.Caption,
+			Kind:    m.Kind,
+			Cover:   m.Cover,
+		})
+	}
+	return outputs, nil
+}
+
+func entryMediaOutput(ctx context.Context, urls *ImageSigner, entry *entity.Entry) (*EntryMediaOutput, error) {
+	private, err := urls.isPrivate(ctx, entry.RankId)
+	if err != nil {
+		return nil, err
+	}
+	media, err := mediaOutputs(ctx, urls, private, entry.Media)
+	if err != nil {
+		return nil, err
+	}
+	return &EntryMediaOutput{
+		EntryId: entry.Id,
+		RankId:  entry.RankId,
+		Media:   media,
+	}, nil
+}
+
+// setCover makes the media item with the given id the only cover of the
+// entry.
+func setCover(media []entity.Media, id string) {
+	for i := range media {
+		media[i].Cover = media[i].Id == id
+	}
+}
+
+// mediaUpdateAttempts bounds how many times a media change is applied again
+// after losing a race with another change of the same entry.
+const mediaUpdateAttempts = 3
+
+// updateMedia reads the entry, computes its new media with change and saves
+// only them, provided the media did not change since read. Otherwise it starts
+// over with the entry read again. It returns the entry as saved.
+func updateMedia(ctx context.Context, repo repository.EntryRepository, rankId, entryId string, change func(*entity.Entry) ([]entity.Media, error)) (*entity.Entry, error) {
+	for range mediaUpdateAttempts {
+		entry, err := repo.FindById(ctx, rankId, entryId)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return nil, &ResourceNotFoundError{name: "entry", id: entryId}
+		}
+		media, err := change(entry)
+		if err != nil {
+			return nil, err
+		}
+		updated := *entry
+		updated.Media = media
+		err = repo.UpdateMedia(ctx, &updated, entry.Media)
+		if errors.Is(err, repository.ErrStale) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	return nil, NewConflictError("the media of the entry kept changing, try again")
+}
+
+type AddMediaInput struct {
+	RankId  string
+	EntryId string
+	Media   *entity.Media
+}
+
+type AddMediaUsecase struct {
+	repo repository.EntryRepository
+	urls *ImageSigner
+}
+
+func NewAddMediaUsecase(repo repository.EntryRepository, urls *ImageSigner) *AddMediaUsecase {
+	return &AddMediaUsecase{repo, urls}
+}
+
+// Execute appends the media item to the entry. A new cover replaces the
+// previous one.
+func (uc *AddMediaUsecase) Execute(ctx context.Context, input AddMediaInput) (*EntryMediaOutput, error) {
+	media := *input.Media
+	media.URL = uc.urls.canonical(media.URL)
+	updated, err := updateMedia(ctx, uc.repo, input.RankId, input.EntryId, func(entry *entity.Entry) ([]entity.Media, error) {
+		if len(entry.Media) >= entity.MaxMedia {
+			return nil, NewValidationError(map[string]string{"media": fmt.Sprintf("must not have more than %d items", entity.MaxMedia)})
+		}
+		updated := append(slices.Clone(entry.Media), media)
+		if media.Cover {
+			setCover(updated, media.Id)
+		}
+		return updated, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entryMediaOutput(ctx, uc.urls, updated)
+}
+
+type ReorderMediaInput struct {
+	RankId  string
+	EntryId string
+	// Ids lists every media item of the entry in the new order.
+	Ids []string
+	// CoverId, when set, names the media item that becomes the cover.
+	CoverId string
+}
+
+type ReorderMediaUsecase struct {
+	repo repository.EntryRepository
+	urls *ImageSigner
+}
+
+func NewReorderMediaUsecase(repo repository.EntryRepository, urls *ImageSigner) *ReorderMediaUsecase {
+	return &ReorderMediaUsecase{repo, urls}
+}
+
+func (uc *ReorderMediaUsecase) Execute(ctx context.Context, input ReorderMediaInput) (*EntryMediaOutput, error) {
+	updated, err := updateMedia(ctx, uc.repo, input.RankId, input.EntryId, func(entry *entity.Entry) ([]entity.Media, error) {
+		return reorder(entry.Media, input)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entryMediaOutput(ctx, uc.urls, updated)
+}
+
+// reorder returns the media in the order of input.Ids, with the cover
+// input.CoverId names.
+func reorder(current []entity.Media, input ReorderMediaInput) ([]entity.Media, error) {
+	byId := make(map[string]entity.Media, len(current))
+	for _, m := range current {
+		byId[m.Id] = m
+	}
+	media := make([]entity.Media, 0, len(input.Ids))
+	for _, id := range input.Ids {
+		m, ok := byId[id]
+		if !ok {
+			break
+		}
+		delete(byId, id)
+		media = append(media, m)
+	}
+	if len(media) != len(input.Ids) || len(media) != len(current) {
+		return nil, NewValidationError(map[string]string{"ids": "must list every media item of the entry exactly once"})
+	}
+	if input.CoverId != "" {
+		i := slices.IndexFunc(media, func(m entity.Media) bool { return m.Id == input.CoverId })
+		if i < 0 || media[i].Kind != entity.MediaImage {
+			return nil, NewValidationError(map[string]string{"cover_id": "must be an image of the entry"})
+		}
+		setCover(media, input.CoverId)
+	}
+	return media, nil
+}
+
+type RemoveMediaInput struct {
+	RankId  string
+	EntryId string
+	Id      string
+}
+
+type RemoveMediaUsecase struct {
+	repo   repository.EntryRepository
+	images *ImageCleaner
+	urls   *ImageSigner
+}
+
+func NewRemoveMediaUsecase(repo repository.EntryRepository, images *ImageCleaner, urls *ImageSigner) *RemoveMediaUsecase {
+	return &RemoveMediaUsecase{repo, images, urls}
+}
+
+// Execute removes the media item from the entry, deleting its image when the
+// API stored it and nothing else uses it.
+func (uc *RemoveMediaUsecase) Execute(ctx context.Context, input RemoveMediaInput) (*EntryMediaOutput, error) {
+	var removed entity.Media
+	updated, err := updateMedia(ctx, uc.repo, input.RankId, input.EntryId, func(entry *entity.Entry) ([]entity.Media, error) {
+		i := slices.IndexFunc(entry.Media, func(m entity.Media) bool { return m.Id == input.Id })
+		if i < 0 {
+			return nil, &ResourceNotFoundError{name: "media", id: input.Id}
+		}
+		removed = entry.Media[i]
+		return slices.Delete(slices.Clone(entry.Media), i, i+1), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	uc.images.releaseReplaced(ctx, updated.RankId, removed.URL, "")
+	return entryMediaOutput(ctx, uc.urls, updated)
+}
@@ -0,0 +1,143 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/mock"
+)
+
+func TestMediaUsecases(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	mockRankTable(ctx)
+	files := storage.NewInMemoryStorage()
+	entries := &inmemory.EntryInMemoryRepository{}
+	urls := NewImageSigner(files, nil, &inmemory.RankInMemoryRepository{}, time.Minute)
+	images := NewImageCleaner(slog.New(slog.DiscardHandler), files, &inmemory.RankTableInMemoryRepository{}, 0)
+	entry := mock.Entries[0]
+	stored, err := files.Upload(ctx, entry.RankId+"/box.png", strings.NewReader("image"), storage.UploadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(output *EntryMediaOutput) []string {
+		var ids []string
+		for _, m := range output.Media {
+			ids = append(ids, m.Id)
+		}
+		return ids
+	}
+	box := entity.NewMedia(stored, "Box art", "", entity.MediaImage, true)
+	trailer := entity.NewMedia("https://videogame.com/neo-geo-cd-trailer", "", "Trailer", entity.MediaVideo, false)
+	t.Run("AddMediaUsecase", func(t *testing.T) {
+		uc := NewAddMediaUsecase(entries, urls)
+		for _, media := range []*entity.Media{box, trailer} {
+			input := AddMediaInput{RankId: entry.RankId, EntryId: entry.Id, Media: media}
+			if _, err := uc.Execute(ctx, input); err != nil {
+				t.Fatalf("Execute(%v, %v) got %v, want %v", ctx, input, err, nil)
+			}
+		}
+		got, _ := entries.FindById(ctx, entry.RankId, entry.Id)
+		if len(got.Media) != 2 || got.Media[0].Id != box.Id || got.CoverURL() != stored {
+			t.Errorf("entry got media %+v, want box and trailer with box as cover", got.Media)
+		}
+		input := AddMediaInput{RankId: entry.RankId, EntryId: "3f87c939-eea6-4a8f-946f-aff81983a306", Media: box}
+		notFoundErr := &ResourceNotFoundError{name: "entry", id: input.EntryId}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+	t.Run("UpdateEntryUsecase", func(t *testing.T) {
+		uc := NewUpdateEntryUsecase(entries, images, urls, nil)
+		input := UpdateEntryInput{Entry: &entity.Entry{Id: entry.Id, Name: "Neo Geo", ImageURL: entry.ImageURL, Scores: entry.Scores, RankId: entry.RankId}}
+		if _, err := uc.Execute(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := entries.FindById(ctx, entry.RankId, entry.Id); len(got.Media) != 2 {
+			t.Errorf("updating the entry got media %+v, want them kept", got.Media)
+		}
+	})
+	t.Run("ReorderMediaUsecase", func(t *testing.T) {
+		uc := NewReorderMediaUsecase(entries, urls)
+		input := ReorderMediaInput{RankId: entry.RankId, EntryId: entry.Id, Ids: []string{trailer.Id, box.Id}}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || strings.Join(ids(got), ",") != trailer.Id+","+box.Id || !got.Media[1].Cover {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want trailer then box", ctx, input, got, err)
+		}
+		var validationErr *ValidationError
+		for _, input := range []ReorderMediaInput{
+			{RankId: entry.RankId, EntryId: entry.Id, Ids: []string{box.Id}},
+			{RankId: entry.RankId, EntryId: entry.Id, Ids: []string{box.Id, box.Id}},
+			{RankId: entry.RankId, EntryId: entry.Id, Ids: []string{box.Id, trailer.Id}, CoverId: trailer.Id},
+		} {
+			if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) {
+				t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrValidation)
+			}
+		}
+	})
+	t.Run("RemoveMediaUsecase", func(t *testing.T) {
+		uc := NewRemoveMediaUsecase(entries, images, urls)
+		input := RemoveMediaInput{RankId: entry.RankId, EntryId: entry.Id, Id: box.Id}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || strings.Join(ids(got), ",") != trailer.Id {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want only the trailer", ctx, input, got, err)
+		}
+		path, _ := files.PathOf(stored)
+		if _, err := files.Stat(ctx, path); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Stat(%v, %v) of the removed image got %v, want %v", ctx, path, err, storage.ErrNotFound)
+		}
+		notFoundErr := &ResourceNotFoundError{name: "media", id: input.Id}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+	t.Run("stale", func(t *testing.T) {
+		current, _ := entries.FindById(ctx, entry.RankId, entry.Id)
+		other := entity.NewMedia("https://videogame.com/neo-geo-cd-manual", "", "Manual", entity.MediaVideo, false)
+		repo := &racingEntryRepository{race: func(ctx context.Context) error {
+			updated := *current
+			updated.Media = append(slices.Clone(current.Media), *other)
+			return entries.UpdateMedia(ctx, &updated, current.Media)
+		}}
+		input := AddMediaInput{RankId: entry.RankId, EntryId: entry.Id, Media: box}
+		got, err := NewAddMediaUsecase(repo, urls).Execute(ctx, input)
+		if err != nil || strings.Join(ids(got), ",") != trailer.Id+","+other.Id+","+box.Id {
+			t.Errorf("Execute(%v, %v) got (%+v, %v), want the media added meanwhile kept", ctx, input, got, err)
+		}
+		repo = &racingEntryRepository{stale: true}
+		var conflictErr *ConflictError
+		if got, err := NewAddMediaUsecase(repo, urls).Execute(ctx, input); got != nil || !errors.As(err, &conflictErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrConflict)
+		}
+	})
+}
+
+// racingEntryRepository runs race before the first media update, as another
+// request would, and fails every media update when stale is set.
+type racingEntryRepository struct {
+	inmemory.EntryInMemoryRepository
+	race  func(context.Context) error
+	stale bool
+}
+
+func (r *racingEntryRepository) UpdateMedia(ctx context.Context, entry *entity.Entry, from []entity.Media) error {
+	if r.stale {
+		return repository.ErrStale
+	}
+	if race := r.race; race != nil {
+		r.race = nil
+		if err := race(ctx); err != nil {
+			return err
+		}
+	}
+	return r.EntryInMemoryRepository.UpdateMedia(ctx, entry, from)
+}
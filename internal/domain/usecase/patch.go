@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/josimarz/ranking-backend/internal/mergepatch"
+)
+
+// applyPatch merges an RFC 7396 patch into the JSON representation of doc and
+// decodes the result into a fresh value, so members removed by the patch end up
+// with their zero value instead of keeping the previous one.
+func applyPatch[T any](doc T, patch []byte) (*T, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := mergepatch.Apply(data, patch)
+	if err != nil {
+		return nil, NewValidationError(map[string]string{"body": "must be a valid JSON merge patch"})
+	}
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+	result := new(T)
+	if err := dec.Decode(result); err != nil {
+		return nil, patchError(err)
+	}
+	return result, nil
+}
+
+func patchError(err error) error {
+	var unmarshalTypeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &unmarshalTypeErr) && unmarshalTypeErr.Field != "":
+		msg := fmt.Sprintf("must be of type %v", unmarshalTypeErr.Type)
+		return NewValidationError(map[string]string{unmarshalTypeErr.Field: msg})
+	case errors.As(err, &unmarshalTypeErr):
+		return NewValidationError(map[string]string{"body": "must be a JSON object"})
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return NewValidationError(map[string]string{field: "cannot be patched"})
+	default:
+		return err
+	}
+}
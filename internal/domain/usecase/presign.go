@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/validator"
+)
+
+const (
+	maxPresignedUploadSize = 10 << 20
+	presignedUploadExpiry  = 15 * time.Minute
+	// presignedVariant names the file clients upload with a presigned
+	// request. It is replaced by the variants of the image on confirmation.
+	presignedVariant = "original"
+)
+
+// presignedUploadTypes maps the content types accepted for presigned uploads
+// to the extension of the stored file.
+var presignedUploadTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+type PresignUploadInput struct {
+	RankId      string
+	ContentType string
+	Size        int64
+}
+
+type PresignUploadOutput struct {
+	UploadId string `json:"upload_id"`
+	Method   string `json:"method"`
+	URL      string `json:"url"`
+	// Headers must be sent with the upload as given.
+	Headers   map[string]string `json:"headers"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+type PresignUploadUsecase struct {
+	presigner storage.Presigner
+	urls      *ImageSigner
+}
+
+func NewPresignUploadUsecase(presigner storage.Presigner, urls *ImageSigner) *PresignUploadUsecase {
+	return &PresignUploadUsecase{presigner, urls}
+}
+
+// Execute lets the client upload an image straight to the storage. The
+// upload is only usable once confirmed with ConfirmUploadUsecase.
+func (uc *PresignUploadUsecase) Execute(ctx context.Context, input PresignUploadInput) (*PresignUploadOutput, error) {
+	ext, ok := presignedUploadTypes[input.ContentType]
+	v := validator.New()
+	v.Check(ok, "content_type", "must be image/jpeg, image/png, image/gif or image/webp")
+	v.Check(input.Size > 0, "size", "must be greater than zero")
+	v.Check(input.Size <= maxPresignedUploadSize, "size", fmt.Sprintf("must not be more than %d bytes", maxPresignedUploadSize))
+	if !v.Valid() {
+		return nil, NewValidationError(v.Errors())
+	}
+	private, err := uc.urls.isPrivate(ctx, input.RankId)
+	if err != nil {
+		return nil, err
+	}
+	id := uuid.NewString()
+	path := fmt.Sprintf("%s/%s/%s%s", input.RankId, id, presignedVariant, ext)
+	upload, err := uc.presigner.PresignUpload(ctx, path, input.ContentType, input.Size, private, presignedUploadExpiry)
+	if err != nil {
+		return nil, err
+	}
+	output := &PresignUploadOutput{
+		UploadId:  id,
+		Method:    upload.Method,
+		URL:       upload.URL,
+		Headers:   make(map[string]string, len(upload.Header)),
+		ExpiresAt: upload.ExpiresAt.UTC(),
+	}
+	for name := range upload.Header {
+		output.Headers[name] = upload.Header.Get(name)
+	}
+	return output, nil
+}
+
+type ConfirmUploadInput struct {
+	RankId   string
+	UploadId string
+}
+
+type ConfirmUploadUsecase struct {
+	upload *UploadUsecase
+}
+
+func NewConfirmUploadUsecase(upload *UploadUsecase) *ConfirmUploadUsecase {
+	return &ConfirmUploadUsecase{upload}
+}
+
+// Execute checks that the file uploaded with a presigned request is an image
+// of the type it was presigned for, and stores it like UploadUsecase does:
+// decoded within the configured limits and re-encoded into every variant,
+// which drops metadata like the GPS position. The uploaded file is deleted
+// once processed, and files failing the checks are deleted too.
+func (uc *ConfirmUploadUsecase) Execute(ctx context.Context, input ConfirmUploadInput) (*UploadOutput, error) {
+	if !validator.IsUUID(input.UploadId) {
+		return nil, NewValidationError(map[string]string{"upload_id": "must be a valid UUID"})
+	}
+	info, contentType, err := uc.find(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size > maxPresignedUploadSize {
+		return nil, uc.reject(ctx, info.Path, fmt.Sprintf("must not be more than %d bytes", maxPresignedUploadSize))
+	}
+	body, _, err := uc.upload.storage.Open(ctx, info.Path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(body, maxPresignedUploadSize+1))
+	body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPresignedUploadSize {
+		return nil, uc.reject(ctx, info.Path, fmt.Sprintf("must not be more than %d bytes", maxPresignedUploadSize))
+	}
+	if http.DetectContentType(data) != contentType {
+		return nil, uc.reject(ctx, info.Path, "content does not match "+contentType)
+	}
+	output, err := uc.upload.Execute(ctx, UploadInput{RankId: input.RankId, File: bytes.NewReader(data)})
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return nil, uc.reject(ctx, info.Path, validationErr.Errors["image"])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.upload.storage.Delete(ctx, info.Path); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// find returns the file of the upload and the content type it was presigned
+// for.
+func (uc *ConfirmUploadUsecase) find(ctx context.Context, input ConfirmUploadInput) (*storage.FileInfo, string, error) {
+	infos, err := uc.upload.storage.List(ctx, input.RankId+"/"+input.UploadId+"/")
+	if err != nil {
+		return nil, "", err
+	}
+	for _, info := range infos {
+		for contentType, ext := range presignedUploadTypes {
+			if info.Path == fmt.Sprintf("%s/%s/%s%s", input.RankId, input.UploadId, presignedVariant, ext) {
+				return &info, contentType, nil
+			}
+		}
+	}
+	return nil, "", &ResourceNotFoundError{name: "upload", id: input.UploadId}
+}
+
+func (uc *ConfirmUploadUsecase) reject(ctx context.Context, path, msg string) error {
+	if err := uc.upload.storage.Delete(ctx, path); err != nil {
+		return err
+	}
+	return NewValidationError(map[string]string{"image": msg})
+}
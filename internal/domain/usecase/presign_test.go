@@ -0,0 +1,120 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+)
+
+func TestPresignUploadUsecase(t *testing.T) {
+	ctx := context.Background()
+	uc := NewPresignUploadUsecase(storage.NewInMemoryStorage(), NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
+	t.Run("Execute", func(t *testing.T) {
+		input := PresignUploadInput{
+			RankId:      "58b95233-b624-44c5-8175-cbbbd03a37ef",
+			ContentType: "image/png",
+			Size:        1024,
+		}
+		got, err := uc.Execute(ctx, input)
+		if got == nil || err != nil {
+			t.Fatalf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, "output", nil)
+		}
+		want := fmt.Sprintf("http://fake-url/%s/%s/original.png?signature=fake", input.RankId, got.UploadId)
+		if got.URL != want || got.Headers["Content-Type"] != "image/png" {
+			t.Errorf("Execute(%v, %v) got %+v, want URL %v", ctx, input, got, want)
+		}
+		for _, input := range []PresignUploadInput{
+			{RankId: input.RankId, ContentType: "image/svg+xml", Size: 1024},
+			{RankId: input.RankId, ContentType: "image/png", Size: 0},
+			{RankId: input.RankId, ContentType: "image/png", Size: maxPresignedUploadSize + 1},
+		} {
+			if got, err := uc.Execute(ctx, input); got != nil || !errors.Is(err, ErrValidation) {
+				t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrValidation)
+			}
+		}
+	})
+}
+
+func TestConfirmUploadUsecase(t *testing.T) {
+	ctx := context.Background()
+	files := storage.NewInMemoryStorage()
+	urls := NewImageSigner(files, nil, &inmemory.RankInMemoryRepository{}, time.Minute)
+	limits := imaging.DefaultLimits()
+	limits.MaxWidth = 32
+	uc := NewConfirmUploadUsecase(NewUploadUsecase(files, imaging.DefaultVariants(), limits, urls))
+	rankId := "58b95233-b624-44c5-8175-cbbbd03a37ef"
+	encode := func(width int) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, width, 16))); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	upload := func(uploadId, ext string, data []byte) string {
+		path := fmt.Sprintf("%s/%s/original%s", rankId, uploadId, ext)
+		if _, err := files.Upload(ctx, path, bytes.NewReader(data), storage.UploadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	t.Run("Execute", func(t *testing.T) {
+		input := ConfirmUploadInput{RankId: rankId, UploadId: "6b7f1d6e-5a63-4c8f-9d0e-2f1b3c4d5e6f"}
+		path := upload(input.UploadId, ".png", encode(16))
+		got, err := uc.Execute(ctx, input)
+		if err != nil || len(got.Variants) != len(imaging.DefaultVariants()) {
+			t.Fatalf("Execute(%v, %v) got (%+v, %v), want every variant", ctx, input, got, err)
+		}
+		for name, url := range got.Variants {
+			stored, _ := files.PathOf(url)
+			if _, err := files.Stat(ctx, stored); err != nil || !strings.HasPrefix(stored, rankId+"/") || strings.Contains(stored, input.UploadId) {
+				t.Errorf("Execute(%v, %v) got variant %v at %v, want it stored under the hash of the image", ctx, input, name, url)
+			}
+		}
+		if _, err := files.Stat(ctx, path); !errors.Is(err, storage.ErrNotFound) {
+			t.Errorf("Execute(%v, %v) did not delete the uploaded file", ctx, input)
+		}
+	})
+	for name, tc := range map[string]struct {
+		uploadId string
+		ext      string
+		data     []byte
+	}{
+		"mismatch":  {"0d2c6b6a-1f0e-4b7a-8c3d-9e8f7a6b5c4d", ".jpg", encode(16)},
+		"corrupt":   {"1e3d7c7b-2a1f-4c8b-9d4e-0f9a8b7c6d5e", ".png", encode(16)[:64]},
+		"too large": {"2f4e8d8c-3b2a-4d9c-8e5f-1a0b9c8d7e6f", ".png", encode(64)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := upload(tc.uploadId, tc.ext, tc.data)
+			input := ConfirmUploadInput{RankId: rankId, UploadId: tc.uploadId}
+			if got, err := uc.Execute(ctx, input); got != nil || !errors.Is(err, ErrValidation) {
+				t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrValidation)
+			}
+			if _, err := files.Stat(ctx, path); err == nil {
+				t.Errorf("Execute(%v, %v) did not delete the rejected file", ctx, input)
+			}
+		})
+	}
+	t.Run("not found", func(t *testing.T) {
+		input := ConfirmUploadInput{RankId: rankId, UploadId: "7e6d5c4b-3a29-4180-9f8e-7d6c5b4a3928"}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.Is(err, ErrNotFound) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrNotFound)
+		}
+		input.UploadId = "../other"
+		if _, err := files.Upload(ctx, rankId+"/../other/original.png", strings.NewReader(""), storage.UploadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.Is(err, ErrValidation) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrValidation)
+		}
+	})
+}
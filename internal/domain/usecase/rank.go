@@ -5,6 +5,7 @@ import (
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/validator"
 )
 
 type CreateRankInput *entity.Rank
@@ -77,12 +78,15 @@ type UpdateRankOutput struct {
 
 type UpdateRankUsecase struct {
 	repo repository.RankRepository
+	urls *ImageSigner
 }
 
-func NewUpdateRankUsecase(repo repository.RankRepository) *UpdateRankUsecase {
-	return &UpdateRankUsecase{repo}
+func NewUpdateRankUsecase(repo repository.RankRepository, urls *ImageSigner) *UpdateRankUsecase {
+	return &UpdateRankUsecase{repo, urls}
 }
 
+// Execute updates the rank, making its images private when it stops being
+// public and the other way around.
 func (uc *UpdateRankUsecase) Execute(ctx context.Context, input UpdateRankInput) (*UpdateRankOutput, error) {
 	rank, err := uc.repo.FindById(ctx, input.Id)
 	if err != nil {
@@ -91,7 +95,7 @@ func (uc *UpdateRankUsecase) Execute(ctx context.Context, input UpdateRankInput)
 	if rank == nil {
 		return nil, &ResourceNotFoundError{name: "rank", id: input.Id}
 	}
-	if err := uc.repo.Update(ctx, input); err != nil {
+	if err := uc.urls.updateRank(ctx, uc.repo, rank, input); err != nil {
 		return nil, err
 	}
 	return &UpdateRankOutput{
@@ -101,6 +105,67 @@ func (uc *UpdateRankUsecase) Execute(ctx context.Context, input UpdateRankInput)
 	}, nil
 }
 
+type PatchRankInput struct {
+	Id    string
+	Patch []byte
+}
+
+type PatchRankOutput struct {
+	Id     string `json:"id"`
+	Name   string `json:"name"`
+	Public bool   `json:"public"`
+}
+
+type rankDocument struct {
+	Name   string `json:"name"`
+	Public bool   `json:"public"`
+}
+
+type PatchRankUsecase struct {
+	repo repository.RankRepository
+	urls *ImageSigner
+}
+
+func NewPatchRankUsecase(repo repository.RankRepository, urls *ImageSigner) *PatchRankUsecase {
+	return &PatchRankUsecase{repo, urls}
+}
+
+// Execute patches the rank, making its images private when it stops being
+// public and the other way around.
+func (uc *PatchRankUsecase) Execute(ctx context.Context, input PatchRankInput) (*PatchRankOutput, error) {
+	rank, err := uc.repo.FindById(ctx, input.Id)
+	if err != nil {
+		return nil, err
+	}
+	if rank == nil {
+		return nil, &ResourceNotFoundError{name: "rank", id: input.Id}
+	}
+	doc, err := applyPatch(rankDocument{
+		Name:   rank.Name,
+		Public: rank.Public,
+	}, input.Patch)
+	if err != nil {
+		return nil, err
+	}
+	patched := &entity.Rank{
+		Id:     rank.Id,
+		Name:   doc.Name,
+		Public: doc.Public,
+	}
+	v := validator.New()
+	if entity.ValidateRank(v, patched); !v.Valid() {
+		return nil, NewValidationError(v.Errors())
+	}
+	if err := uc.urls.updateRank(ctx, uc.repo, rank, patched); err != nil {
+		return nil, err
+	}
+	return &PatchRankOutput{
+		Id:     patched.Id,
+		Name:   patched.Name,
+		Public: patched.Public,
+	}, nil
+}
+
 type DeleteRankInput struct {
 	Id string
 }
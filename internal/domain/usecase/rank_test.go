@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
@@ -53,7 +56,7 @@ func TestFindRankUsecase(t *testing.T) {
 func TestUpdateRankUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.RankInMemoryRepository{}
-	uc := NewUpdateRankUsecase(repo)
+	uc := NewUpdateRankUsecase(repo, NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	t.Run("Execute", func(t *testing.T) {
 		rank := mock.Rank
 		rank.Name = "Video Games"
@@ -74,6 +77,44 @@ func TestUpdateRankUsecase(t *testing.T) {
 	})
 }
 
+func TestPatchRankUsecase(t *testing.T) {
+	ctx := context.Background()
+	repo := &inmemory.RankInMemoryRepository{}
+	uc := NewPatchRankUsecase(repo, NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
+	rank := entity.Rank{
+		Id:     "6f0f2c4e-8a4b-4c8e-9a55-1f3b0f9f6a11",
+		Name:   "Handheld Consoles",
+		Public: true,
+	}
+	if err := repo.Create(ctx, &rank); err != nil {
+		t.Fatal(err)
+	}
+	t.Run("Execute", func(t *testing.T) {
+		input := PatchRankInput{
+			Id:    rank.Id,
+			Patch: []byte(`{"public":false}`),
+		}
+		want := &PatchRankOutput{
+			Id:     rank.Id,
+			Name:   rank.Name,
+			Public: false,
+		}
+		if got, err := uc.Execute(ctx, input); err != nil || *got != *want {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, want, nil)
+		}
+		input.Patch = []byte(`{"name":null}`)
+		var validationErr *ValidationError
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) || validationErr.Errors["name"] == "" {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want a validation error for name", ctx, input, got, err)
+		}
+		input.Id = "0b7e1d55-2f6a-4f55-8a7e-5b5a3cf2b0de"
+		notFoundErr := &ResourceNotFoundError{name: "rank", id: input.Id}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+}
+
 func TestDeleteRankUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.RankInMemoryRepository{}
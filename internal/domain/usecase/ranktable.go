@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/domain/repository"
@@ -23,6 +24,10 @@ type entryOutput struct {
 	Name     string        `json:"name"`
 	ImageURL string        `json:"image_url"`
 	Scores   entity.Scores `json:"scores"`
+	// CoverURL is the URL of the cover image, or ImageURL for entries
+	// without one.
+	CoverURL   string `json:"cover_url"`
+	MediaCount int    `json:"media_count"`
 }
 
 type FindRankTableOutput struct {
@@ -31,14 +36,17 @@ type FindRankTableOutput struct {
 	Public  bool              `json:"public"`
 	Attrs   []attributeOutput `json:"attributes"`
 	Entries []entryOutput     `json:"entries"`
+	// UpdatedAt is exposed through the Last-Modified header.
+	UpdatedAt time.Time `json:"-"`
 }
 
 type FindRankTableUsecase struct {
 	repo repository.RankTableRepository
+	urls *ImageSigner
 }
 
-func NewFindRankTableUsecase(repo repository.RankTableRepository) *FindRankTableUsecase {
-	return &FindRankTableUsecase{repo}
+func NewFindRankTableUsecase(repo repository.RankTableRepository, urls *ImageSigner) *FindRankTableUsecase {
+	return &FindRankTableUsecase{repo, urls}
 }
 
 func (uc *FindRankTableUsecase) Execute(ctx context.Context, input FindRankTableInput) (*FindRankTableOutput, error) {
@@ -50,9 +58,10 @@ func (uc *FindRankTableUsecase) Execute(ctx context.Context, input FindRankTable
 		return nil, &ResourceNotFoundError{name: "rank", id: input.Id}
 	}
 	output := &FindRankTableOutput{
-		Id:     table.Id,
-		Name:   table.Name,
-		Public: table.Public,
+		Id:        table.Id,
+		Name:      table.Name,
+		Public:    table.Public,
+		UpdatedAt: table.UpdatedAt,
 	}
 	for _, attr := range table.Attrs {
 		output.Attrs = append(output.Attrs, attributeOutput{
@@ -63,11 +72,21 @@ func (uc *FindRankTableUsecase) Execute(ctx context.Context, input FindRankTable
 		})
 	}
 	for _, entry := range table.Entries {
+		imageURL, err := uc.urls.sign(ctx, !table.Public, entry.ImageURL)
+		if err != nil {
+			return nil, err
+		}
+		coverURL, err := uc.urls.sign(ctx, !table.Public, entry.CoverURL())
+		if err != nil {
+			return nil, err
+		}
 		output.Entries = append(output.Entries, entryOutput{
-			Id:       entry.Id,
-			Name:     entry.Name,
-			ImageURL: entry.ImageURL,
-			Scores:   entry.Scores,
+			Id:         entry.Id,
+			Name:       entry.Name,
+			ImageURL:   imageURL,
+			Scores:     entry.Scores,
+			CoverURL:   coverURL,
+			MediaCount: len(entry.Media),
 		})
 	}
 	return output, nil
@@ -5,21 +5,28 @@ import (
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
 func TestFindRankTableUsecase(t *testing.T) {
 	ctx := context.Background()
 	repo := &inmemory.RankTableInMemoryRepository{}
-	uc := NewFindRankTableUsecase(repo)
+	uc := NewFindRankTableUsecase(repo, NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	mockRankTable(ctx)
 	t.Run("Execute", func(t *testing.T) {
+		table, err := repo.FindById(ctx, mock.Rank.Id)
+		if err != nil {
+			t.Fatal(err)
+		}
 		want := &FindRankTableOutput{
-			Id:     mock.Rank.Id,
-			Name:   mock.Rank.Name,
-			Public: mock.Rank.Public,
+			Id:        mock.Rank.Id,
+			Name:      mock.Rank.Name,
+			Public:    mock.Rank.Public,
+			UpdatedAt: table.UpdatedAt,
 		}
 		for _, attr := range mock.Attrs {
 			want.Attrs = append(want.Attrs, attributeOutput{
@@ -35,6 +42,7 @@ func TestFindRankTableUsecase(t *testing.T) {
 				Name:     entry.Name,
 				ImageURL: entry.ImageURL,
 				Scores:   entry.Scores,
+				CoverURL: entry.ImageURL,
 			})
 		}
 		input := FindRankTableInput{
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+)
+
+const (
+	maxScoreChanges = 1000
+	// scoresChunkSize matches the number of items DynamoDB accepts in a
+	// single transaction.
+	scoresChunkSize = 100
+)
+
+const (
+	ScoreChangeApplied = "applied"
+	ScoreChangeFailed  = "failed"
+)
+
+type ScoreChange struct {
+	EntryId   string `json:"entry_id"`
+	Attribute string `json:"attribute"`
+	Score     int    `json:"score"`
+}
+
+type BatchScoresInput struct {
+	RankId  string
+	Changes []ScoreChange
+}
+
+type ScoreChangeResult struct {
+	EntryId   string `json:"entry_id"`
+	Attribute string `json:"attribute"`
+	Score     int    `json:"score"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+type BatchScoresOutput struct {
+	RankId  string              `json:"rank_id"`
+	Applied int                 `json:"applied"`
+	Failed  int                 `json:"failed"`
+	Results []ScoreChangeResult `json:"results"`
+}
+
+type BatchScoresUsecase struct {
+	rankTableRepo repository.RankTableRepository
+	entryRepo     repository.EntryRepository
+}
+
+func NewBatchScoresUsecase(rankTableRepo repository.RankTableRepository, entryRepo repository.EntryRepository) *BatchScoresUsecase {
+	return &BatchScoresUsecase{rankTableRepo, entryRepo}
+}
+
+// Execute validates the whole batch against the rank's attributes and entries
+// before writing anything. Changes are then grouped by entry and written in
+// transactions of up to scoresChunkSize entries, so a failed chunk leaves the
+// other chunks applied and is reported in the per-item results.
+func (uc *BatchScoresUsecase) Execute(ctx context.Context, input BatchScoresInput) (*BatchScoresOutput, error) {
+	table, err := uc.rankTableRepo.FindById(ctx, input.RankId)
+	if err != nil {
+		return nil, err
+	}
+	if table == nil {
+		return nil, &ResourceNotFoundError{name: "rank", id: input.RankId}
+	}
+	if err := validateScoreChanges(table, input.Changes); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*entity.Entry)
+	var order []string
+	for _, entry := range table.Entries {
+		entries[entry.Id] = &entity.Entry{
+			Id:     entry.Id,
+			RankId: table.Id,
+			Scores: make(entity.Scores),
+		}
+	}
+	// Only the changed scores are written, so that scores changed since the
+	// rank table was read are kept.
+	changesByEntry := make(map[string][]int)
+	for i, change := range input.Changes {
+		entries[change.EntryId].Scores[change.Attribute] = change.Score
+		if _, ok := changesByEntry[change.EntryId]; !ok {
+			order = append(order, change.EntryId)
+		}
+		changesByEntry[change.EntryId] = append(changesByEntry[change.EntryId], i)
+	}
+
+	output := &BatchScoresOutput{
+		RankId:  table.Id,
+		Results: make([]ScoreChangeResult, len(input.Changes)),
+	}
+	var lastErr error
+	for start := 0; start < len(order); start += scoresChunkSize {
+		chunk := order[start:min(start+scoresChunkSize, len(order))]
+		items := make([]*entity.Entry, 0, len(chunk))
+		for _, id := range chunk {
+			items = append(items, entries[id])
+		}
+		status, msg := ScoreChangeApplied, ""
+		if err := uc.entryRepo.UpdateScores(ctx, items); err != nil {
+			status, msg, lastErr = ScoreChangeFailed, "the transaction containing this change was not applied", err
+		}
+		for _, id := range chunk {
+			for _, i := range changesByEntry[id] {
+				change := input.Changes[i]
+				output.Results[i] = ScoreChangeResult{
+					EntryId:   change.EntryId,
+					Attribute: change.Attribute,
+					Score:     change.Score,
+					Status:    status,
+					Error:     msg,
+				}
+				if status == ScoreChangeApplied {
+					output.Applied++
+				} else {
+					output.Failed++
+				}
+			}
+		}
+	}
+	if output.Applied == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return output, nil
+}
+
+func validateScoreChanges(table *entity.RankTable, changes []ScoreChange) error {
+	errors := make(map[string]string)
+	if len(changes) == 0 || len(changes) > maxScoreChanges {
+		errors["changes"] = fmt.Sprintf("must contain between 1 and %d changes", maxScoreChanges)
+		return NewValidationError(errors)
+	}
+	attrs := make(map[string]bool, len(table.Attrs))
+	for _, attr := range table.Attrs {
+		attrs[attr.Name] = true
+	}
+	entries := make(map[string]bool, len(table.Entries))
+	for _, entry := range table.Entries {
+		entries[entry.Id] = true
+	}
+	seen := make(map[ScoreChange]int)
+	for i, change := range changes {
+		if !entries[change.EntryId] {
+			errors[fmt.Sprintf("changes[%d].entry_id", i)] = "must be an entry of the rank"
+		}
+		if !attrs[change.Attribute] {
+			errors[fmt.Sprintf("changes[%d].attribute", i)] = "must be an attribute of the rank"
+		}
+		key := ScoreChange{EntryId: change.EntryId, Attribute: change.Attribute}
+		if j, ok := seen[key]; ok {
+			errors[fmt.Sprintf("changes[%d]", i)] = fmt.Sprintf("duplicates changes[%d]", j)
+		} else {
+			seen[key] = i
+		}
+	}
+	if len(errors) > 0 {
+		return NewValidationError(errors)
+	}
+	return nil
+}
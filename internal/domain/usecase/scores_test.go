@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/mock"
+)
+
+type stubRankTableRepository struct {
+	table *entity.RankTable
+}
+
+func (r *stubRankTableRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	return r.table, nil
+}
+
+type failingEntryRepository struct {
+	inmemory.EntryInMemoryRepository
+	calls  int
+	failAt int
+}
+
+func (r *failingEntryRepository) UpdateScores(ctx context.Context, entries []*entity.Entry) error {
+	r.calls++
+	if r.calls == r.failAt {
+		return errors.New("transaction cancelled")
+	}
+	return nil
+}
+
+func TestBatchScoresUsecase(t *testing.T) {
+	ctx := context.Background()
+	mockRankTable(ctx)
+	entryRepo := &inmemory.EntryInMemoryRepository{}
+	uc := NewBatchScoresUsecase(&inmemory.RankTableInMemoryRepository{}, entryRepo)
+	t.Run("Execute", func(t *testing.T) {
+		input := BatchScoresInput{
+			RankId: mock.Rank.Id,
+			Changes: []ScoreChange{
+				{EntryId: mock.Entries[0].Id, Attribute: "Controls", Score: 95},
+				{EntryId: mock.Entries[1].Id, Attribute: "Sound", Score: 60},
+				{EntryId: mock.Entries[0].Id, Attribute: "Graphics", Score: 99},
+			},
+		}
+		got, err := uc.Execute(ctx, input)
+		if err != nil || got.Applied != 3 || got.Failed != 0 {
+			t.Fatalf("Execute(%v, %v) got (%v, %v), want 3 applied changes", ctx, input, got, err)
+		}
+		for i, result := range got.Results {
+			if result.Status != ScoreChangeApplied || result.EntryId != input.Changes[i].EntryId {
+				t.Errorf("result #%d got %v, want an applied change of %v", i, result, input.Changes[i])
+			}
+		}
+		entry, err := entryRepo.FindById(ctx, mock.Rank.Id, mock.Entries[0].Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := entity.Scores{"Controls": 95, "Graphics": 99, "Sound": mock.Entries[0].Scores["Sound"]}
+		if fmt.Sprint(entry.Scores) != fmt.Sprint(want) {
+			t.Errorf("saved scores got %v, want %v", entry.Scores, want)
+		}
+	})
+	t.Run("validation", func(t *testing.T) {
+		input := BatchScoresInput{
+			RankId: mock.Rank.Id,
+			Changes: []ScoreChange{
+				{EntryId: mock.Entries[0].Id, Attribute: "Controls", Score: 10},
+				{EntryId: "0f8b1a43-2b7c-4d41-9b0e-3d1f5c9a7e22", Attribute: "Speed", Score: 10},
+				{EntryId: mock.Entries[0].Id, Attribute: "Controls", Score: 20},
+			},
+		}
+		var validationErr *ValidationError
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &validationErr) {
+			t.Fatalf("Execute(%v, %v) got (%v, %v), want a validation error", ctx, input, got, err)
+		}
+		for _, key := range []string{"changes[1].entry_id", "changes[1].attribute", "changes[2]"} {
+			if _, ok := validationErr.Errors[key]; !ok {
+				t.Errorf("validation errors %v are missing %q", validationErr.Errors, key)
+			}
+		}
+		input.Changes = nil
+		if _, err := uc.Execute(ctx, input); !errors.As(err, &validationErr) || validationErr.Errors["changes"] == "" {
+			t.Errorf("Execute(%v, %v) got %v, want a validation error for changes", ctx, input, err)
+		}
+		input.RankId = "5b8d3c1e-7f2a-4e6b-9c0d-1a2b3c4d5e6f"
+		notFoundErr := &ResourceNotFoundError{name: "rank", id: input.RankId}
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.As(err, &notFoundErr) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, notFoundErr)
+		}
+	})
+	t.Run("stale", func(t *testing.T) {
+		entry := mock.Entries[1]
+		stale := &entity.RankTable{
+			Id:      mock.Rank.Id,
+			Attrs:   mock.Attrs,
+			Entries: []entity.Entry{{Id: entry.Id, Scores: entity.Scores{"Controls": 1, "Sound": 1}}},
+		}
+		uc := NewBatchScoresUsecase(&stubRankTableRepository{stale}, entryRepo)
+		input := BatchScoresInput{RankId: stale.Id, Changes: []ScoreChange{{EntryId: entry.Id, Attribute: "Controls", Score: 42}}}
+		if _, err := uc.Execute(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := entryRepo.FindById(ctx, entry.RankId, entry.Id)
+		if got.Scores["Controls"] != 42 || got.Scores["Sound"] != 60 {
+			t.Errorf("saved scores got %v, want Controls 42 and the Sound score of the repository kept", got.Scores)
+		}
+	})
+	t.Run("chunks", func(t *testing.T) {
+		table := &entity.RankTable{
+			Id:    mock.Rank.Id,
+			Attrs: []entity.Attribute{{Name: "Controls"}},
+		}
+		var changes []ScoreChange
+		for i := range 150 {
+			id := fmt.Sprintf("entry-%03d", i)
+			table.Entries = append(table.Entries, entity.Entry{Id: id})
+			changes = append(changes, ScoreChange{EntryId: id, Attribute: "Controls", Score: i})
+		}
+		repo := &failingEntryRepository{failAt: 2}
+		uc := NewBatchScoresUsecase(&stubRankTableRepository{table}, repo)
+		got, err := uc.Execute(ctx, BatchScoresInput{RankId: table.Id, Changes: changes})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if repo.calls != 2 || got.Applied != 100 || got.Failed != 50 {
+			t.Errorf("Execute() got %d calls, %d applied and %d failed, want 2 calls, 100 applied and 50 failed", repo.calls, got.Applied, got.Failed)
+		}
+		if got.Results[99].Status != ScoreChangeApplied || got.Results[100].Status != ScoreChangeFailed {
+			t.Errorf("Execute() got results %v and %v around the chunk boundary", got.Results[99], got.Results[100])
+		}
+		repo = &failingEntryRepository{failAt: 1}
+		uc = NewBatchScoresUsecase(&stubRankTableRepository{table}, repo)
+		if got, err := uc.Execute(ctx, BatchScoresInput{RankId: table.Id, Changes: changes[:10]}); got != nil || err == nil {
+			t.Errorf("Execute() got (%v, %v), want an error when nothing was applied", got, err)
+		}
+	})
+}
@@ -1,40 +1,147 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
+	"strings"
 
-	"github.com/google/uuid"
+	"github.com/josimarz/ranking-backend/internal/imaging"
 	"github.com/josimarz/ranking-backend/internal/infra/storage"
 )
 
 type UploadInput struct {
-	RankId   string
-	Filename string
-	File     io.Reader
+	RankId string
+	File   io.Reader
 }
 
 type UploadOutput struct {
-	URL string `json:"url"`
+	// URL is the address of the last, and largest, variant.
+	URL      string            `json:"url"`
+	Variants map[string]string `json:"variants"`
 }
 
 type UploadUsecase struct {
-	storage storage.FileStorage
+	storage  storage.FileStorage
+	variants []imaging.Variant
+	limits   imaging.Limits
+	urls     *ImageSigner
 }
 
-func NewUploadUsecase(storage storage.FileStorage) *UploadUsecase {
-	return &UploadUsecase{storage}
+func NewUploadUsecase(storage storage.FileStorage, variants []imaging.Variant, limits imaging.Limits, urls *ImageSigner) *UploadUsecase {
+	return &UploadUsecase{storage, variants, limits, urls}
 }
 
+// sha256Metadata names the metadata holding the SHA-256 of a stored file, so
+// its integrity can be verified.
+const sha256Metadata = "sha256"
+
+// Execute re-encodes the image into every configured variant and stores them
+// under {rankId}/{hash}/, where hash is the SHA-256 of the uploaded content.
+// Uploading content already stored for the rank returns the existing URLs.
+// The images of private ranks are stored privately and their URLs expire.
 func (uc *UploadUsecase) Execute(ctx context.Context, input UploadInput) (*UploadOutput, error) {
-	ext := filepath.Ext(input.Filename)
-	filename := uuid.NewString()
-	path := fmt.Sprintf("%s/%s%s", input.RankId, filename, ext)
-	url, err := uc.storage.Upload(ctx, path, input.File)
+	data, err := io.ReadAll(input.File)
+	if err != nil {
+		return nil, err
+	}
+	private, err := uc.urls.isPrivate(ctx, input.RankId)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	prefix := fmt.Sprintf("%s/%s/", input.RankId, hex.EncodeToString(sum[:]))
+	output, err := uc.existing(ctx, prefix, private)
 	if err != nil {
 		return nil, err
 	}
-	return &UploadOutput{url}, nil
+	if output != nil {
+		if err := uc.urls.signUpload(ctx, private, output); err != nil {
+			return nil, err
+		}
+		return output, nil
+	}
+	results, err := imaging.Process(data, uc.variants, uc.limits)
+	switch {
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		return nil, NewValidationError(map[string]string{"image": "must be a PNG, JPEG, GIF or WebP image"})
+	case errors.Is(err, imaging.ErrInvalidImage):
+		return nil, NewValidationError(map[string]string{"image": "could not be decoded"})
+	case errors.Is(err, imaging.ErrTooLarge):
+		return nil, NewValidationError(map[string]string{"image": uc.tooLargeMessage()})
+	case err != nil:
+		return nil, err
+	}
+	output = &UploadOutput{Variants: make(map[string]string, len(results))}
+	for _, res := range results {
+		path := prefix + res.Variant.Name + res.Format.Ext()
+		sum := sha256.Sum256(res.Data)
+		metadata := map[string]string{sha256Metadata: hex.EncodeToString(sum[:])}
+		opts := storage.UploadOptions{Metadata: metadata, Private: private}
+		url, err := uc.storage.Upload(ctx, path, bytes.NewReader(res.Data), opts)
+		if err != nil {
+			return nil, err
+		}
+		output.Variants[res.Variant.Name] = url
+		output.URL = url
+	}
+	if err := uc.urls.signUpload(ctx, private, output); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+func (uc *UploadUsecase) tooLargeMessage() string {
+	var bounds []string
+	if uc.limits.MaxWidth > 0 {
+		bounds = append(bounds, fmt.Sprintf("%d pixels wide", uc.limits.MaxWidth))
+	}
+	if uc.limits.MaxHeight > 0 {
+		bounds = append(bounds, fmt.Sprintf("%d pixels high", uc.limits.MaxHeight))
+	}
+	if uc.limits.MaxPixels > 0 {
+		bounds = append(bounds, fmt.Sprintf("%d pixels in total", uc.limits.MaxPixels))
+	}
+	return "must not be more than " + strings.Join(bounds, ", ")
+}
+
+// existing returns the output of a previous upload of the files under
+// prefix, or nil when a variant is missing. The files are touched, so the
+// orphan collector spares them until the client saves an entry using them,
+// as it does for new uploads.
+func (uc *UploadUsecase) existing(ctx context.Context, prefix string, private bool) (*UploadOutput, error) {
+	infos, err := uc.storage.List(ctx, prefix)
+	if err != nil || len(infos) == 0 {
+		return nil, err
+	}
+	paths := make(map[string]string, len(infos))
+	for _, info := range infos {
+		name := strings.TrimPrefix(info.Path, prefix)
+		paths[strings.TrimSuffix(name, filepath.Ext(name))] = info.Path
+	}
+	output := &UploadOutput{Variants: make(map[string]string, len(uc.variants))}
+	for _, v := range uc.variants {
+		path, ok := paths[v.Name]
+		if !ok {
+			return nil, nil
+		}
+		output.Variants[v.Name] = uc.storage.URLOf(path)
+		output.URL = output.Variants[v.Name]
+	}
+	for _, path := range paths {
+		err := uc.storage.Touch(ctx, path, private)
+		if errors.Is(err, storage.ErrNotFound) {
+			// Deleted since listed, so the image is uploaded again.
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return output, nil
 }
@@ -1,26 +1,94 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
 	"github.com/josimarz/ranking-backend/internal/infra/storage"
 )
 
 func TestUploadUsecase(t *testing.T) {
 	ctx := context.Background()
 	storage := storage.NewInMemoryStorage()
-	uc := NewUploadUsecase(storage)
+	uc := NewUploadUsecase(storage, imaging.DefaultVariants(), imaging.DefaultLimits(), NewImageSigner(storage, nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	t.Run("Execute", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 256, 256))); err != nil {
+			t.Fatal(err)
+		}
 		input := UploadInput{
-			RankId:   "58b95233-b624-44c5-8175-cbbbd03a37ef",
-			Filename: "file.png",
-			File:     strings.NewReader("file content"),
+			RankId: "58b95233-b624-44c5-8175-cbbbd03a37ef",
+			File:   &buf,
+		}
+		got, err := uc.Execute(ctx, input)
+		if got == nil || err != nil {
+			t.Fatalf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, "output", nil)
+		}
+		for _, v := range imaging.DefaultVariants() {
+			if got.Variants[v.Name] == "" {
+				t.Errorf("Execute(%v, %v) got no URL for variant %v", ctx, input, v.Name)
+			}
+		}
+		if got.URL != got.Variants["original"] {
+			t.Errorf("Execute(%v, %v) got URL %v, want %v", ctx, input, got.URL, got.Variants["original"])
+		}
+
+		input.File = strings.NewReader("file content")
+		if got, err := uc.Execute(ctx, input); got != nil || !errors.Is(err, ErrValidation) {
+			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, nil, ErrValidation)
+		}
+	})
+	t.Run("deduplicate", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewNRGBA(image.Rect(0, 0, 32, 32))); err != nil {
+			t.Fatal(err)
+		}
+		input := UploadInput{RankId: "2f0c9d1e-8b7a-4c6d-9e5f-4a3b2c1d0e9f", File: bytes.NewReader(buf.Bytes())}
+		first, err := uc.Execute(ctx, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before, err := storage.List(ctx, input.RankId+"/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		input.File = bytes.NewReader(buf.Bytes())
+		second, err := uc.Execute(ctx, input)
+		if err != nil || !reflect.DeepEqual(first, second) {
+			t.Errorf("Execute(%v, %v) of the same content got (%v, %v), want (%v, %v)", ctx, input, second, err, first, nil)
+		}
+		infos, err := storage.List(ctx, input.RankId+"/")
+		if err != nil || len(infos) != len(imaging.DefaultVariants()) {
+			t.Errorf("List() after uploading the same content twice got (%v, %v), want %v files", infos, err, len(imaging.DefaultVariants()))
+		}
+		for i, info := range infos {
+			if i < len(before) && !info.LastModified.After(before[i].LastModified) {
+				t.Errorf("file %v modified at %v after uploading the same content, want after %v", info.Path, info.LastModified, before[i].LastModified)
+			}
 		}
-		var want *UploadOutput
-		if got, err := uc.Execute(ctx, input); got == nil || err != nil {
-			t.Errorf("Execute(%v, %v) got (%v, %v), want (%v, %v)", ctx, input, got, err, want, nil)
+		for _, info := range infos {
+			body, stat, err := storage.Open(ctx, info.Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(body)
+			body.Close()
+			sum := sha256.Sum256(data)
+			if got, want := stat.Metadata["sha256"], hex.EncodeToString(sum[:]); got != want {
+				t.Errorf("file %v got sha256 metadata %v, want %v", info.Path, got, want)
+			}
 		}
 	})
 }
@@ -2,9 +2,16 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
+var (
+	ErrNotFound   = errors.New("resource not found")
+	ErrConflict   = errors.New("resource conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
 type Usecase[I any, O any] interface {
 	Execute(context.Context, I) (*O, error)
 }
@@ -17,3 +24,39 @@ type ResourceNotFoundError struct {
 func (e *ResourceNotFoundError) Error() string {
 	return fmt.Sprintf("%v not found: %v", e.name, e.id)
 }
+
+func (e *ResourceNotFoundError) Unwrap() error {
+	return ErrNotFound
+}
+
+type ConflictError struct {
+	msg string
+}
+
+func NewConflictError(msg string) *ConflictError {
+	return &ConflictError{msg}
+}
+
+func (e *ConflictError) Error() string {
+	return e.msg
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+type ValidationError struct {
+	Errors map[string]string
+}
+
+func NewValidationError(errors map[string]string) *ValidationError {
+	return &ValidationError{errors}
+}
+
+func (e *ValidationError) Error() string {
+	return ErrValidation.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
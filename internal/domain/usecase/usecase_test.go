@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -14,4 +15,29 @@ func TestResourceNotFoundError(t *testing.T) {
 			t.Errorf("Error() got %v, want %v", got, want)
 		}
 	})
+	t.Run("Is", func(t *testing.T) {
+		if !errors.Is(e, ErrNotFound) {
+			t.Errorf("Is(%v, %v) got %v, want %v", e, ErrNotFound, false, true)
+		}
+	})
+}
+
+func TestErrorTaxonomy(t *testing.T) {
+	tests := []struct {
+		err  error
+		kind error
+	}{
+		{NewConflictError("rank already exists"), ErrConflict},
+		{NewValidationError(map[string]string{"name": "must be provided"}), ErrValidation},
+	}
+	for _, tt := range tests {
+		if !errors.Is(tt.err, tt.kind) {
+			t.Errorf("Is(%v, %v) got %v, want %v", tt.err, tt.kind, false, true)
+		}
+		for _, other := range []error{ErrNotFound, ErrConflict, ErrValidation} {
+			if other != tt.kind && errors.Is(tt.err, other) {
+				t.Errorf("Is(%v, %v) got %v, want %v", tt.err, other, true, false)
+			}
+		}
+	}
 }
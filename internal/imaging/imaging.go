@@ -0,0 +1,181 @@
+// Package imaging turns uploaded images into the variants served by the API.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const jpegQuality = 85
+
+var (
+	ErrUnsupportedFormat = errors.New("image format is not supported")
+	ErrInvalidImage      = errors.New("image could not be decoded")
+	ErrTooLarge          = errors.New("image dimensions are too large")
+)
+
+// Limits bound the dimensions of the images accepted, which keeps a small
+// file that declares huge dimensions from exhausting memory when decoded.
+// Zero means unbounded.
+type Limits struct {
+	MaxWidth  int
+	MaxHeight int
+	MaxPixels int
+}
+
+func DefaultLimits() Limits {
+	return Limits{
+		MaxWidth:  10_000,
+		MaxHeight: 10_000,
+		MaxPixels: 50_000_000,
+	}
+}
+
+func (l Limits) allow(width, height int) bool {
+	return (l.MaxWidth == 0 || width <= l.MaxWidth) &&
+		(l.MaxHeight == 0 || height <= l.MaxHeight) &&
+		(l.MaxPixels == 0 || width*height <= l.MaxPixels)
+}
+
+type Variant struct {
+	Name string
+	// MaxWidth and MaxHeight bound the size of the variant, which keeps the
+	// aspect ratio of the original. Zero means unbounded.
+	MaxWidth  int
+	MaxHeight int
+}
+
+// DefaultVariants are ordered from the smallest to the largest.
+func DefaultVariants() []Variant {
+	return []Variant{
+		{Name: "thumbnail", MaxWidth: 128, MaxHeight: 128},
+		{Name: "medium", MaxWidth: 640, MaxHeight: 640},
+		{Name: "original"},
+	}
+}
+
+type Format string
+
+const (
+	JPEG Format = "jpeg"
+	PNG  Format = "png"
+)
+
+func (f Format) Ext() string {
+	if f == PNG {
+		return ".png"
+	}
+	return ".jpg"
+}
+
+func (f Format) ContentType() string {
+	return "image/" + string(f)
+}
+
+type Result struct {
+	Variant Variant
+	Format  Format
+	Width   int
+	Height  int
+	Data    []byte
+}
+
+// Process decodes data and encodes one image for each variant. Every result
+// has the same format, chosen by OutputFormat.
+func Process(data []byte, variants []Variant, limits Limits) ([]Result, error) {
+	img, err := Decode(data, limits)
+	if err != nil {
+		return nil, err
+	}
+	format := OutputFormat(img)
+	results := make([]Result, 0, len(variants))
+	for _, v := range variants {
+		resized := Resize(img, v.MaxWidth, v.MaxHeight)
+		var buf bytes.Buffer
+		if err := Encode(&buf, resized, format); err != nil {
+			return nil, err
+		}
+		results = append(results, Result{
+			Variant: v,
+			Format:  format,
+			Width:   resized.Bounds().Dx(),
+			Height:  resized.Bounds().Dy(),
+			Data:    buf.Bytes(),
+		})
+	}
+	return results, nil
+}
+
+// Decode reads a PNG, JPEG, GIF or WebP image and rotates it as its EXIF
+// orientation says. Only the first frame of an animated GIF is kept. Images
+// exceeding limits are rejected with ErrTooLarge before being decoded.
+func Decode(data []byte, limits Limits) (image.Image, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	switch {
+	case errors.Is(err, image.ErrFormat):
+		return nil, ErrUnsupportedFormat
+	case err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	case cfg.Width <= 0 || cfg.Height <= 0:
+		return nil, ErrInvalidImage
+	case !limits.allow(cfg.Width, cfg.Height):
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if format == "jpeg" {
+		img = orient(img, exifOrientation(data))
+	}
+	return img, nil
+}
+
+// OutputFormat keeps transparency as PNG and encodes everything else as JPEG.
+func OutputFormat(img image.Image) Format {
+	if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return JPEG
+	}
+	return PNG
+}
+
+// Resize scales img down to fit within maxWidth by maxHeight. Images that
+// already fit are returned unchanged, so they are never scaled up.
+func Resize(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := 1.0
+	if maxWidth > 0 && w > maxWidth {
+		scale = min(scale, float64(maxWidth)/float64(w))
+	}
+	if maxHeight > 0 && h > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(h))
+	}
+	if scale == 1 {
+		return img
+	}
+	dw := max(1, int(math.Round(float64(w)*scale)))
+	dh := max(1, int(math.Round(float64(h)*scale)))
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// Encode writes img in format. The encoders write no metadata, so whatever
+// the upload carried, EXIF included, is dropped.
+func Encode(w io.Writer, img image.Image, format Format) error {
+	if format == PNG {
+		return png.Encode(w, img)
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+}
@@ -0,0 +1,167 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	variants := DefaultVariants()
+	t.Run("jpeg", func(t *testing.T) {
+		results, err := Process(encodeJPEG(t, newImage(1000, 500, color.Opaque), nil), variants, DefaultLimits())
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []struct {
+			name          string
+			width, height int
+		}{
+			{"thumbnail", 128, 64},
+			{"medium", 640, 320},
+			{"original", 1000, 500},
+		}
+		if len(results) != len(want) {
+			t.Fatalf("Process() got %d results, want %d", len(results), len(want))
+		}
+		for i, res := range results {
+			if res.Variant.Name != want[i].name || res.Width != want[i].width || res.Height != want[i].height {
+				t.Errorf("Process() result %d got %v %dx%d, want %v %dx%d", i, res.Variant.Name, res.Width, res.Height, want[i].name, want[i].width, want[i].height)
+			}
+			if res.Format != JPEG {
+				t.Errorf("Process() result %d got format %v, want %v", i, res.Format, JPEG)
+			}
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(res.Data))
+			if err != nil || format != "jpeg" || cfg.Width != res.Width || cfg.Height != res.Height {
+				t.Errorf("Process() result %d data got %v %dx%d (%v)", i, format, cfg.Width, cfg.Height, err)
+			}
+		}
+	})
+	t.Run("transparent png", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, newImage(64, 64, color.Transparent)); err != nil {
+			t.Fatal(err)
+		}
+		results, err := Process(buf.Bytes(), variants, DefaultLimits())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, res := range results {
+			if res.Format != PNG || res.Width != 64 || res.Height != 64 {
+				t.Errorf("Process() result %v got %v %dx%d, want %v 64x64", res.Variant.Name, res.Format, res.Width, res.Height, PNG)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			data []byte
+			want error
+		}{
+			{"unsupported", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"/>"), ErrUnsupportedFormat},
+			{"truncated", encodeJPEG(t, newImage(32, 32, color.Opaque), nil)[:200], ErrInvalidImage},
+			{"too many pixels", pngWithSize(t, 9_000, 9_000), ErrTooLarge},
+			{"too wide", pngWithSize(t, 20_000, 10), ErrTooLarge},
+			{"too high", pngWithSize(t, 10, 20_000), ErrTooLarge},
+		}
+		for _, tt := range tests {
+			if _, err := Process(tt.data, variants, DefaultLimits()); !errors.Is(err, tt.want) {
+				t.Errorf("Process() with %v data got %v, want %v", tt.name, err, tt.want)
+			}
+		}
+	})
+}
+
+func TestDecodeOrientation(t *testing.T) {
+	src := newImage(40, 20, color.White)
+	for y := range 10 {
+		for x := range 10 {
+			src.Set(x, y, color.Black)
+		}
+	}
+	data := encodeJPEG(t, src, exifSegment(6))
+	if got := exifOrientation(data); got != 6 {
+		t.Fatalf("exifOrientation() got %v, want %v", got, 6)
+	}
+	img, err := Decode(data, DefaultLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 20 || b.Dy() != 40 {
+		t.Fatalf("Decode() got %dx%d, want 20x40", b.Dx(), b.Dy())
+	}
+	// Rotated clockwise, the black top-left corner ends up at the top right.
+	if r, _, _, _ := img.At(15, 5).RGBA(); r > 0x4000 {
+		t.Errorf("Decode() did not rotate the image: top right pixel is not black")
+	}
+	if r, _, _, _ := img.At(5, 5).RGBA(); r < 0xC000 {
+		t.Errorf("Decode() did not rotate the image: top left pixel is not white")
+	}
+
+	results, err := Process(data, []Variant{{Name: "original"}}, DefaultLimits())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(results[0].Data, []byte("Exif")) {
+		t.Errorf("Process() kept the EXIF metadata")
+	}
+}
+
+func newImage(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// encodeJPEG encodes img and inserts app1 right after the start of image.
+func encodeJPEG(t *testing.T, img image.Image, app1 []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+}
+
+// exifSegment builds an APP1 segment holding a big-endian TIFF structure with
+// a single orientation entry.
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2A\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientationTag)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3) // SHORT
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// pngWithSize returns a PNG whose header declares w by h pixels.
+func pngWithSize(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newImage(1, 1, color.Black)); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// The IHDR chunk follows the 8 byte signature: length, type, data, crc.
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	return data
+}
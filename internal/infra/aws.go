@@ -1,6 +1,12 @@
 package infra
 
-import "os"
+import (
+	"net/http"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
+)
 
 func IsRunningOnLambda() bool {
 	_, exists := os.LookupEnv("AWS_LAMBDA_FUNCTION_NAME")
@@ -13,3 +19,19 @@ func EndpointURL() string {
 	}
 	return "http://localhost:4566"
 }
+
+func NewHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+}
+
+func CloseHTTPClient(client aws.HTTPClient) {
+	if c, ok := client.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+func InstrumentConfig(cfg *aws.Config) {
+	otelaws.AppendMiddlewares(&cfg.APIOptions)
+}
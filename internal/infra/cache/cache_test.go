@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/mock"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Set(ctx, "a", 1)
+	c.Set(ctx, "b", 2)
+	if got, ok := c.Get(ctx, "a"); !ok || got != 1 {
+		t.Errorf("Get(%v) got (%v, %v), want (%v, %v)", "a", got, ok, 1, true)
+	}
+	c.Set(ctx, "c", 3)
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Errorf("least recently used item was not evicted")
+	}
+	if got := c.Len(); got != 2 {
+		t.Errorf("Len() got %v, want %v", got, 2)
+	}
+	c.Delete(ctx, "a")
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Errorf("deleted item is still cached")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get(ctx, "c"); ok {
+		t.Errorf("expired item is still cached")
+	}
+	if got := c.Len(); got != 0 {
+		t.Errorf("Len() got %v, want %v", got, 0)
+	}
+}
+
+type countingRankTableRepository struct {
+	repository.RankTableRepository
+	calls int
+}
+
+func (r *countingRankTableRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	r.calls++
+	return r.RankTableRepository.FindById(ctx, id)
+}
+
+func TestRankTableCacheRepository(t *testing.T) {
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	c := NewRankTableCache(NewLRU[string, *entity.RankTable](10, time.Minute))
+	source := &countingRankTableRepository{RankTableRepository: &inmemory.RankTableInMemoryRepository{}}
+	r := NewRankTableCacheRepository(source, c, nil)
+	ranks := NewRankCacheRepository(&inmemory.RankInMemoryRepository{}, c)
+	attrs := NewAttributeCacheRepository(&inmemory.AttributeInMemoryRepository{}, c)
+	entries := NewEntryCacheRepository(&inmemory.EntryInMemoryRepository{}, c)
+	rank := mock.Rank
+	ranks.Create(ctx, &rank)
+
+	find := func() *entity.RankTable {
+		t.Helper()
+		table, err := r.FindById(ctx, rank.Id)
+		if err != nil || table == nil {
+			t.Fatalf("FindById(%v, %v) got (%v, %v)", ctx, rank.Id, table, err)
+		}
+		return table
+	}
+	find()
+	find()
+	if source.calls != 1 {
+		t.Errorf("repository was called %v times, want %v", source.calls, 1)
+	}
+
+	attr := mock.Attrs[0]
+	entry := mock.Entries[0]
+	writes := []struct {
+		name  string
+		write func()
+	}{
+		{"rank.Update", func() { rank.Name = "Consoles"; ranks.Update(ctx, &rank) }},
+		{"attribute.Create", func() { attrs.Create(ctx, &attr) }},
+		{"attribute.Update", func() { attrs.Update(ctx, &attr) }},
+		{"attribute.Delete", func() { attrs.Delete(ctx, &attr) }},
+		{"entry.Create", func() { entries.Create(ctx, &entry) }},
+		{"entry.Update", func() { entries.Update(ctx, &entry) }},
+		{"entry.UpdateScores", func() { entries.UpdateScores(ctx, []*entity.Entry{&entry}) }},
+		{"entry.Delete", func() { entries.Delete(ctx, &entry) }},
+		{"rank.Create", func() { ranks.Create(ctx, &rank) }},
+	}
+	for _, w := range writes {
+		before := source.calls
+		w.write()
+		find()
+		if source.calls != before+1 {
+			t.Errorf("%v did not invalidate the cached rank table", w.name)
+		}
+	}
+
+	ranks.Delete(ctx, &rank)
+	if table, err := r.FindById(ctx, rank.Id); table != nil || err != nil {
+		t.Errorf("FindById(%v, %v) after delete got (%v, %v), want (%v, %v)", ctx, rank.Id, table, err, nil, nil)
+	}
+}
+
+func TestRankTableCacheStaleLoad(t *testing.T) {
+	ctx := context.Background()
+	c := NewRankTableCache(NewLRU[string, *entity.RankTable](10, time.Minute))
+	epoch := c.currentEpoch()
+	c.invalidate(ctx, mock.Rank.Id)
+	c.set(ctx, epoch, &entity.RankTable{Id: mock.Rank.Id})
+	if _, ok := c.store.Get(ctx, mock.Rank.Id); ok {
+		t.Errorf("table loaded before an invalidation was cached")
+	}
+}
+
+// laggingRankTableRepository returns the tables in order, as an index that
+// catches up with a write does.
+type laggingRankTableRepository struct {
+	tables []*entity.RankTable
+	calls  int
+}
+
+func (r *laggingRankTableRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	table := r.tables[min(r.calls, len(r.tables)-1)]
+	r.calls++
+	return table, nil
+}
+
+type timeFence time.Time
+
+func (f timeFence) Reached(ctx context.Context, table *entity.RankTable) (bool, error) {
+	return !table.UpdatedAt.Before(time.Time(f)), nil
+}
+
+func TestRankTableCacheLaggingSource(t *testing.T) {
+	ctx := context.Background()
+	written := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stale := &entity.RankTable{Id: mock.Rank.Id, Name: "Games", UpdatedAt: written.Add(-time.Second)}
+	fresh := &entity.RankTable{Id: mock.Rank.Id, Name: "Consoles", UpdatedAt: written}
+	source := &laggingRankTableRepository{tables: []*entity.RankTable{stale, stale, fresh}}
+	c := NewRankTableCache(NewLRU[string, *entity.RankTable](10, time.Minute))
+	r := NewRankTableCacheRepository(source, c, timeFence(written))
+	for _, want := range []*entity.RankTable{stale, stale, fresh, fresh, fresh} {
+		if got, err := r.FindById(ctx, mock.Rank.Id); got != want || err != nil {
+			t.Errorf("FindById(%v, %v) got (%v, %v), want (%v, %v)", ctx, mock.Rank.Id, got, err, want, nil)
+		}
+	}
+	if source.calls != 3 {
+		t.Errorf("repository was called %v times, want %v", source.calls, 3)
+	}
+}
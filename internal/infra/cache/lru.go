@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Store is a best-effort key/value cache. Implementations that can fail, like
+// a shared cache reached over the network, report failures as misses.
+type Store[K comparable, V any] interface {
+	Get(ctx context.Context, key K) (V, bool)
+	Set(ctx context.Context, key K, value V)
+	Delete(ctx context.Context, key K)
+}
+
+type lruItem[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// LRU is an in-process Store that holds at most size items, evicting the least
+// recently used one when full, and forgets items older than ttl.
+type LRU[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[K]*list.Element
+	order *list.List
+	now   func() time.Time
+}
+
+func NewLRU[K comparable, V any](size int, ttl time.Duration) *LRU[K, V] {
+	return &LRU[K, V]{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[K]*list.Element),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+func (c *LRU[K, V]) Get(ctx context.Context, key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	item := elem.Value.(*lruItem[K, V])
+	if !c.now().Before(item.expiresAt) {
+		c.remove(elem)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return item.value, true
+}
+
+func (c *LRU[K, V]) Set(ctx context.Context, key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.size <= 0 {
+		return
+	}
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		item := elem.Value.(*lruItem[K, V])
+		item.value = value
+		item.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	for c.order.Len() >= c.size {
+		c.remove(c.order.Back())
+	}
+	c.items[key] = c.order.PushFront(&lruItem[K, V]{key, value, expiresAt})
+}
+
+func (c *LRU[K, V]) Delete(ctx context.Context, key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruItem[K, V]).key)
+}
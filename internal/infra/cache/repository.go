@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+)
+
+// RankTableCache holds rank tables by rank id. The same cache must be given to
+// the rank table repository that fills it and to the rank, attribute and entry
+// repositories whose writes invalidate it.
+type RankTableCache struct {
+	store Store[string, *entity.RankTable]
+	// mu and epoch keep a table loaded before a write from being cached after
+	// the write invalidated it.
+	mu    sync.Mutex
+	epoch uint64
+}
+
+func NewRankTableCache(store Store[string, *entity.RankTable]) *RankTableCache {
+	return &RankTableCache{store: store}
+}
+
+func (c *RankTableCache) currentEpoch() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.epoch
+}
+
+func (c *RankTableCache) set(ctx context.Context, epoch uint64, table *entity.RankTable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.epoch == epoch {
+		c.store.Set(ctx, table.Id, table)
+	}
+}
+
+func (c *RankTableCache) invalidate(ctx context.Context, rankId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.epoch++
+	c.store.Delete(ctx, rankId)
+}
+
+// Fence tells whether a rank table read from an eventually consistent source
+// shows the last write to its rank.
+type Fence interface {
+	Reached(ctx context.Context, table *entity.RankTable) (bool, error)
+}
+
+type RankTableCacheRepository struct {
+	repo  repository.RankTableRepository
+	cache *RankTableCache
+	fence Fence
+}
+
+// NewRankTableCacheRepository caches the rank tables read from repo. When
+// repo is eventually consistent, fence keeps a table that does not show the
+// last write yet from being cached, so it is read again instead of being
+// served stale until it expires. A nil fence caches every table.
+func NewRankTableCacheRepository(repo repository.RankTableRepository, cache *RankTableCache, fence Fence) *RankTableCacheRepository {
+	return &RankTableCacheRepository{repo, cache, fence}
+}
+
+func (r *RankTableCacheRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	if table, ok := r.cache.store.Get(ctx, id); ok {
+		return table, nil
+	}
+	epoch := r.cache.currentEpoch()
+	table, err := r.repo.FindById(ctx, id)
+	if err != nil || table == nil {
+		return table, err
+	}
+	if r.fence != nil {
+		if ok, err := r.fence.Reached(ctx, table); err != nil || !ok {
+			return table, nil
+		}
+	}
+	r.cache.set(ctx, epoch, table)
+	return table, nil
+}
+
+type RankCacheRepository struct {
+	repo  repository.RankRepository
+	cache *RankTableCache
+}
+
+func NewRankCacheRepository(repo repository.RankRepository, cache *RankTableCache) *RankCacheRepository {
+	return &RankCacheRepository{repo, cache}
+}
+
+func (r *RankCacheRepository) Create(ctx context.Context, rank *entity.Rank) error {
+	defer r.cache.invalidate(ctx, rank.Id)
+	return r.repo.Create(ctx, rank)
+}
+
+func (r *RankCacheRepository) FindById(ctx context.Context, id string) (*entity.Rank, error) {
+	return r.repo.FindById(ctx, id)
+}
+
+func (r *RankCacheRepository) Update(ctx context.Context, rank *entity.Rank) error {
+	defer r.cache.invalidate(ctx, rank.Id)
+	return r.repo.Update(ctx, rank)
+}
+
+func (r *RankCacheRepository) Delete(ctx context.Context, rank *entity.Rank) error {
+	defer r.cache.invalidate(ctx, rank.Id)
+	return r.repo.Delete(ctx, rank)
+}
+
+type AttributeCacheRepository struct {
+	repo  repository.AttributeRepository
+	cache *RankTableCache
+}
+
+func NewAttributeCacheRepository(repo repository.AttributeRepository, cache *RankTableCache) *AttributeCacheRepository {
+	return &AttributeCacheRepository{repo, cache}
+}
+
+func (r *AttributeCacheRepository) Create(ctx context.Context, attr *entity.Attribute) error {
+	defer r.cache.invalidate(ctx, attr.RankId)
+	return r.repo.Create(ctx, attr)
+}
+
+func (r *AttributeCacheRepository) FindById(ctx context.Context, rankId, id string) (*entity.Attribute, error) {
+	return r.repo.FindById(ctx, rankId, id)
+}
+
+func (r *AttributeCacheRepository) Update(ctx context.Context, attr *entity.Attribute) error {
+	defer r.cache.invalidate(ctx, attr.RankId)
+	return r.repo.Update(ctx, attr)
+}
+
+func (r *AttributeCacheRepository) Delete(ctx context.Context, attr *entity.Attribute) error {
+	defer r.cache.invalidate(ctx, attr.RankId)
+	return r.repo.Delete(ctx, attr)
+}
+
+type EntryCacheRepository struct {
+	repo  repository.EntryRepository
+	cache *RankTableCache
+}
+
+func NewEntryCacheRepository(repo repository.EntryRepository, cache *RankTableCache) *EntryCacheRepository {
+	return &EntryCacheRepository{repo, cache}
+}
+
+func (r *EntryCacheRepository) Create(ctx context.Context, entry *entity.Entry) error {
+	defer r.cache.invalidate(ctx, entry.RankId)
+	return r.repo.Create(ctx, entry)
+}
+
+func (r *EntryCacheRepository) FindById(ctx context.Context, rankId, id string) (*entity.Entry, error) {
+	return r.repo.FindById(ctx, rankId, id)
+}
+
+func (r *EntryCacheRepository) Update(ctx context.Context, entry *entity.Entry) error {
+	defer r.cache.invalidate(ctx, entry.RankId)
+	return r.repo.Update(ctx, entry)
+}
+
+func (r *EntryCacheRepository) Delete(ctx context.Context, entry *entity.Entry) error {
+	defer r.cache.invalidate(ctx, entry.RankId)
+	return r.repo.Delete(ctx, entry)
+}
+
+func (r *EntryCacheRepository) UpdateImageURL(ctx context.Context, entry *entity.Entry, from string) error {
+	defer r.cache.invalidate(ctx, entry.RankId)
+	return r.repo.UpdateImageURL(ctx, entry, from)
+}
+
+func (r *EntryCacheRepository) UpdateMedia(ctx context.Context, entry *entity.Entry, from []entity.Media) error {
+	defer r.cache.invalidate(ctx, entry.RankId)
+	return r.repo.UpdateMedia(ctx, entry, from)
+}
+
+func (r *EntryCacheRepository) UpdateScores(ctx context.Context, entries []*entity.Entry) error {
+	defer func() {
+		seen := make(map[string]bool)
+		for _, entry := range entries {
+			if !seen[entry.RankId] {
+				seen[entry.RankId] = true
+				r.cache.invalidate(ctx, entry.RankId)
+			}
+		}
+	}()
+	return r.repo.UpdateScores(ctx, entries)
+}
@@ -3,6 +3,7 @@ package ddb
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -83,13 +84,14 @@ func (r *AttributeDynamodbRepository) Delete(ctx context.Context, attr *entity.A
 	if _, err := r.client.DeleteItem(ctx, input); err != nil {
 		return err
 	}
-	return nil
+	return touchRank(ctx, r.client, attr.RankId)
 }
 
 func (r *AttributeDynamodbRepository) putItem(ctx context.Context, attr *entity.Attribute) error {
 	rec := &attributeRecord{
 		record: record{
 			RecordType: "attribute",
+			UpdatedAt:  time.Now().UTC(),
 		},
 		Id:     fmt.Sprintf("%s/%s", attr.RankId, attr.Id),
 		Name:   attr.Name,
@@ -31,6 +31,10 @@ func TestAttributeDynamodbRepository(t *testing.T) {
 			Order:  attr.Order,
 			RankId: attr.RankId,
 		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("saved item has no updatedat")
+		}
+		want.UpdatedAt = got.UpdatedAt
 		if *got != *want {
 			t.Errorf("saved item does not match the expected one: got %v, want %v", got, want)
 		}
@@ -66,6 +70,10 @@ func TestAttributeDynamodbRepository(t *testing.T) {
 			Order:  attr.Order,
 			RankId: attr.RankId,
 		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("saved item has no updatedat")
+		}
+		want.UpdatedAt = got.UpdatedAt
 		if *got != *want {
 			t.Errorf("saved item does not match the expected one: got %v, want %v", got, want)
 		}
@@ -1,9 +1,15 @@
 package ddb
 
 import (
+	"context"
+	"errors"
 	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 var (
@@ -11,7 +17,8 @@ var (
 )
 
 type record struct {
-	RecordType string `dynamodbav:"typ"`
+	RecordType string    `dynamodbav:"typ"`
+	UpdatedAt  time.Time `dynamodbav:"updatedat"`
 }
 
 func init() {
@@ -19,3 +26,32 @@ func init() {
 		tableName = aws.String(value)
 	}
 }
+
+// touchRank bumps the updatedat of a rank so a rank table that lost one of its
+// items still reports a newer modification time, and consistent rank table
+// reads can tell when the index shows a write. A missing rank is ignored.
+func touchRank(ctx context.Context, client *dynamodb.Client, rankId string) error {
+	update := expression.Set(expression.Name("updatedat"), expression.Value(time.Now().UTC()))
+	cond := expression.AttributeExists(expression.Name("id"))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName: tableName,
+		Key: map[string]types.AttributeValue{
+			"id":  &types.AttributeValueMemberS{Value: rankId},
+			"typ": &types.AttributeValueMemberS{Value: "rank"},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	_, err = client.UpdateItem(ctx, input)
+	var condErr *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &condErr) {
+		return err
+	}
+	return nil
+}
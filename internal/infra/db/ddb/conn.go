@@ -12,21 +12,28 @@ import (
 
 func NewDynamodbClient(ctx context.Context) (*dynamodb.Client, error) {
 	if infra.IsRunningOnLambda() {
-		cfg, err := config.LoadDefaultConfig(ctx)
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithHTTPClient(infra.NewHTTPClient()))
 		if err != nil {
 			return nil, err
 		}
+		infra.InstrumentConfig(&cfg)
 		return dynamodb.NewFromConfig(cfg), nil
 	}
 	cfg, err := config.LoadDefaultConfig(ctx, func(lo *config.LoadOptions) error {
 		lo.Region = "us-east-1"
 		lo.Credentials = credentials.NewStaticCredentialsProvider("xyz", "123", "")
+		lo.HTTPClient = infra.NewHTTPClient()
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	infra.InstrumentConfig(&cfg)
 	return dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
 		o.BaseEndpoint = aws.String(infra.EndpointURL())
 	}), nil
 }
+
+func CloseDynamodbClient(client *dynamodb.Client) {
+	infra.CloseHTTPClient(client.Options().HTTPClient)
+}
@@ -2,12 +2,21 @@ package ddb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
 )
 
 type entryRecord struct {
@@ -17,6 +26,16 @@ type entryRecord struct {
 	ImageURL string        `dynamodbav:"imageurl"`
 	Scores   entity.Scores `dynamodbav:"scores"`
 	RankId   string        `dynamodbav:"rankid"`
+	Media    []mediaRecord `dynamodbav:"media,omitempty"`
+}
+
+type mediaRecord struct {
+	Id      string `dynamodbav:"id"`
+	URL     string `dynamodbav:"url"`
+	Alt     string `dynamodbav:"alt,omitempty"`
+	Caption string `dynamodbav:"caption,omitempty"`
+	Kind    string `dynamodbav:"kind"`
+	Cover   bool   `dynamodbav:"cover,omitempty"`
 }
 
 type EntryDynamodbRepository struct {
@@ -28,7 +47,10 @@ func NewEntryDynamodbRepository(client *dynamodb.Client) *EntryDynamodbRepositor
 }
 
 func (r *EntryDynamodbRepository) Create(ctx context.Context, entry *entity.Entry) error {
-	return r.putItem(ctx, entry)
+	if err := r.putItem(ctx, entry); err != nil {
+		return err
+	}
+	return touchRank(ctx, r.client, entry.RankId)
 }
 
 func (r *EntryDynamodbRepository) FindById(ctx context.Context, rankId, id string) (*entity.Entry, error) {
@@ -60,11 +82,19 @@ func (r *EntryDynamodbRepository) FindById(ctx context.Context, rankId, id strin
 		ImageURL: rec.ImageURL,
 		Scores:   rec.Scores,
 		RankId:   rankId,
+		Media:    mediaOf(rec.Media),
 	}, nil
 }
 
+// Update leaves the media attribute alone, so media added meanwhile through
+// UpdateMedia are kept.
 func (r *EntryDynamodbRepository) Update(ctx context.Context, entry *entity.Entry) error {
-	return r.putItem(ctx, entry)
+	update := expression.Set(expression.Name("name"), expression.Value(entry.Name)).
+		Set(expression.Name("imageurl"), expression.Value(entry.ImageURL)).
+		Set(expression.Name("scores"), expression.Value(scoresOf(entry))).
+		Set(expression.Name("updatedat"), expression.Value(time.Now().UTC()))
+	cond := expression.AttributeExists(expression.Name("id"))
+	return r.updateItem(ctx, entry, update, cond)
 }
 
 func (r *EntryDynamodbRepository) Delete(ctx context.Context, entry *entity.Entry) error {
@@ -83,19 +113,186 @@ func (r *EntryDynamodbRepository) Delete(ctx context.Context, entry *entity.Entr
 	if _, err := r.client.DeleteItem(ctx, input); err != nil {
 		return err
 	}
+	return touchRank(ctx, r.client, entry.RankId)
+}
+
+// UpdateScores sets the given scores of all given entries in a single
+// transaction, leaving their other scores as they are. DynamoDB limits it to
+// 100 entries per call.
+//
+// Scores can only be set inside an existing score map, which entries stored
+// before scoresOf may lack. Those entries get an empty map and the
+// transaction is tried once more.
+func (r *EntryDynamodbRepository) UpdateScores(ctx context.Context, entries []*entity.Entry) error {
+	err := r.transactScores(ctx, entries)
+	var canceledErr *types.TransactionCanceledException
+	if !errors.As(err, &canceledErr) {
+		return err
+	}
+	retry := false
+	for i, reason := range canceledErr.CancellationReasons {
+		if i >= len(entries) || aws.ToString(reason.Code) != "ConditionalCheckFailed" {
+			continue
+		}
+		if err := r.ensureScores(ctx, entries[i]); err != nil {
+			return err
+		}
+		retry = true
+	}
+	if !retry {
+		return err
+	}
+	return r.transactScores(ctx, entries)
+}
+
+func (r *EntryDynamodbRepository) transactScores(ctx context.Context, entries []*entity.Entry) error {
+	items := make([]types.TransactWriteItem, 0, len(entries))
+	now, err := attributevalue.Marshal(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		key, err := attributevalue.MarshalMap(map[string]string{
+			"id":  fmt.Sprintf("%s/%s", entry.RankId, entry.Id),
+			"typ": "entry",
+		})
+		if err != nil {
+			return err
+		}
+		// Attribute names may contain dots and brackets, which the
+		// expression builder reads as document paths, so the expression
+		// is written with placeholders for every name.
+		names := map[string]string{"#scores": "scores", "#updatedat": "updatedat"}
+		values := map[string]types.AttributeValue{
+			":map":       &types.AttributeValueMemberS{Value: "M"},
+			":updatedat": now,
+		}
+		sets := []string{"#updatedat = :updatedat"}
+		for _, attr := range slices.Sorted(maps.Keys(entry.Scores)) {
+			name, value := fmt.Sprintf("#s%d", len(sets)), fmt.Sprintf(":s%d", len(sets))
+			names[name] = attr
+			values[value] = &types.AttributeValueMemberN{Value: strconv.Itoa(entry.Scores[attr])}
+			sets = append(sets, fmt.Sprintf("#scores.%s = %s", name, value))
+		}
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName:                 tableName,
+				Key:                       key,
+				UpdateExpression:          aws.String("SET " + strings.Join(sets, ", ")),
+				ConditionExpression:       aws.String("attribute_type(#scores, :map)"),
+				ExpressionAttributeNames:  names,
+				ExpressionAttributeValues: values,
+			},
+		})
+	}
+	input := &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	}
+	if _, err := r.client.TransactWriteItems(ctx, input); err != nil {
+		return err
+	}
 	return nil
 }
 
+// ensureScores gives the entry an empty score map when it has none, or a
+// null one. A missing entry is left for the transaction to report.
+func (r *EntryDynamodbRepository) ensureScores(ctx context.Context, entry *entity.Entry) error {
+	update := expression.Set(expression.Name("scores"), expression.Value(entity.Scores{}))
+	cond := expression.AttributeExists(expression.Name("id")).And(expression.Or(
+		expression.AttributeNotExists(expression.Name("scores")),
+		expression.Not(expression.Name("scores").AttributeType(expression.Map)),
+	))
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName: tableName,
+		Key: map[string]types.AttributeValue{
+			"id":  &types.AttributeValueMemberS{Value: fmt.Sprintf("%s/%s", entry.RankId, entry.Id)},
+			"typ": &types.AttributeValueMemberS{Value: "entry"},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	_, err = r.client.UpdateItem(ctx, input)
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return nil
+	}
+	return err
+}
+
+func (r *EntryDynamodbRepository) UpdateImageURL(ctx context.Context, entry *entity.Entry, from string) error {
+	update := expression.Set(expression.Name("imageurl"), expression.Value(entry.ImageURL)).
+		Set(expression.Name("updatedat"), expression.Value(time.Now().UTC()))
+	cond := expression.AttributeExists(expression.Name("id")).
+		And(expression.Name("imageurl").Equal(expression.Value(from)))
+	return r.updateItem(ctx, entry, update, cond)
+}
+
+// UpdateMedia compares the media with the given ones as a whole, so any
+// change made meanwhile fails the update. Entries without media have no media
+// attribute, as with putItem.
+func (r *EntryDynamodbRepository) UpdateMedia(ctx context.Context, entry *entity.Entry, from []entity.Media) error {
+	update := expression.Set(expression.Name("updatedat"), expression.Value(time.Now().UTC()))
+	if len(entry.Media) == 0 {
+		update = update.Remove(expression.Name("media"))
+	} else {
+		update = update.Set(expression.Name("media"), expression.Value(mediaRecords(entry.Media)))
+	}
+	cond := expression.AttributeExists(expression.Name("id"))
+	if len(from) == 0 {
+		cond = cond.And(expression.AttributeNotExists(expression.Name("media")))
+	} else {
+		cond = cond.And(expression.Name("media").Equal(expression.Value(mediaRecords(from))))
+	}
+	return r.updateItem(ctx, entry, update, cond)
+}
+
+// updateItem applies update to the entry when cond holds, returning
+// repository.ErrStale when it does not.
+func (r *EntryDynamodbRepository) updateItem(ctx context.Context, entry *entity.Entry, update expression.UpdateBuilder, cond expression.ConditionBuilder) error {
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(cond).Build()
+	if err != nil {
+		return err
+	}
+	input := &dynamodb.UpdateItemInput{
+		TableName: tableName,
+		Key: map[string]types.AttributeValue{
+			"id":  &types.AttributeValueMemberS{Value: fmt.Sprintf("%s/%s", entry.RankId, entry.Id)},
+			"typ": &types.AttributeValueMemberS{Value: "entry"},
+		},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	_, err = r.client.UpdateItem(ctx, input)
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return repository.ErrStale
+	}
+	if err != nil {
+		return err
+	}
+	return touchRank(ctx, r.client, entry.RankId)
+}
+
 func (r *EntryDynamodbRepository) putItem(ctx context.Context, entry *entity.Entry) error {
 	rec := &entryRecord{
 		record: record{
 			RecordType: "entry",
+			UpdatedAt:  time.Now().UTC(),
 		},
 		Id:       fmt.Sprintf("%s/%s", entry.RankId, entry.Id),
 		Name:     entry.Name,
 		ImageURL: entry.ImageURL,
-		Scores:   entry.Scores,
+		Scores:   scoresOf(entry),
 		RankId:   entry.RankId,
+		Media:    mediaRecords(entry.Media),
 	}
 	item, err := attributevalue.MarshalMap(rec)
 	if err != nil {
@@ -111,3 +308,48 @@ func (r *EntryDynamodbRepository) putItem(ctx context.Context, entry *entity.Ent
 	}
 	return nil
 }
+
+// scoresOf always returns a map, as UpdateScores can only set scores inside
+// an existing one.
+func scoresOf(entry *entity.Entry) entity.Scores {
+	if entry.Scores == nil {
+		return entity.Scores{}
+	}
+	return entry.Scores
+}
+
+func mediaRecords(media []entity.Media) []mediaRecord {
+	if len(media) == 0 {
+		return nil
+	}
+	recs := make([]mediaRecord, len(media))
+	for i, m := range media {
+		recs[i] = mediaRecord{
+			Id:      m.Id,
+			URL:     m.URL,
+			Alt:     m.Alt,
+			Caption: m.Caption,
+			Kind:    m.Kind,
+			Cover:   m.Cover,
+		}
+	}
+	return recs
+}
+
+func mediaOf(recs []mediaRecord) []entity.Media {
+	if len(recs) == 0 {
+		return nil
+	}
+	media := make([]entity.Media, len(recs))
+	for i, rec := range recs {
+		media[i] = entity.Media{
+			Id:      rec.Id,
+			URL:     rec.URL,
+			Alt:     rec.Alt,
+			Caption: rec.Caption,
+			Kind:    rec.Kind,
+			Cover:   rec.Cover,
+		}
+	}
+	return media
+}
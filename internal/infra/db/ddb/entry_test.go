@@ -2,11 +2,14 @@ package ddb
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"testing"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
@@ -33,6 +36,10 @@ func TestEntryDynamodbRepository(t *testing.T) {
 			Scores:   entry.Scores,
 			RankId:   entry.RankId,
 		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("saved item has no updatedat")
+		}
+		want.UpdatedAt = got.UpdatedAt
 		if !reflect.DeepEqual(*got, *want) {
 			t.Errorf("saved item does not match the expected one: got %v, want %v", got, want)
 		}
@@ -72,10 +79,146 @@ func TestEntryDynamodbRepository(t *testing.T) {
 			Scores:   entry.Scores,
 			RankId:   entry.RankId,
 		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("saved item has no updatedat")
+		}
+		want.UpdatedAt = got.UpdatedAt
 		if !reflect.DeepEqual(*got, *want) {
 			t.Errorf("saved item does not match the expected one: got %v, want %v", got, want)
 		}
 	})
+	t.Run("Media", func(t *testing.T) {
+		entry.Media = []entity.Media{
+			*entity.NewMedia("https://videogame.com/dreamcast-box.png", "Box art", "", entity.MediaImage, true),
+			*entity.NewMedia("https://videogame.com/dreamcast-trailer", "", "Trailer", entity.MediaVideo, false),
+		}
+		// An update from a snapshot read before the media were added must
+		// not remove them.
+		snapshot := entry
+		snapshot.Media = nil
+		snapshot.Name = "Dreamcast"
+		if err := r.UpdateMedia(ctx, &entry, nil); err != nil {
+			t.Errorf("UpdateMedia(%v, %v, %v) got %v, want %v", ctx, entry, nil, err, nil)
+		}
+		if err := r.Update(ctx, &snapshot); err != nil {
+			t.Errorf("Update(%v, %v) got %v, want %v", ctx, snapshot, err, nil)
+		}
+		entry.Name = snapshot.Name
+		if got, err := r.FindById(ctx, entry.RankId, entry.Id); err != nil || !reflect.DeepEqual(*got, entry) {
+			t.Errorf("FindById(%v, %v, %v) got (%v, %v), want (%v, %v)", ctx, entry.RankId, entry.Id, got, err, entry, nil)
+		}
+		missing := entity.Entry{Id: "3f87c939-eea6-4a8f-946f-aff81983a306", RankId: entry.RankId, Name: "Missing"}
+		if err := r.Update(ctx, &missing); !errors.Is(err, repository.ErrStale) {
+			t.Errorf("Update(%v, %v) of a missing entry got %v, want %v", ctx, missing, err, repository.ErrStale)
+		}
+	})
+	t.Run("UpdateMedia", func(t *testing.T) {
+		changes := entity.Entry{Id: entry.Id, RankId: entry.RankId, Media: []entity.Media{entry.Media[1]}}
+		if err := r.UpdateMedia(ctx, &changes, entry.Media); err != nil {
+			t.Errorf("UpdateMedia(%v, %v, %v) got %v, want %v", ctx, changes, entry.Media, err, nil)
+		}
+		if err := r.UpdateMedia(ctx, &changes, entry.Media); !errors.Is(err, repository.ErrStale) {
+			t.Errorf("UpdateMedia(%v, %v, %v) of changed media got %v, want %v", ctx, changes, entry.Media, err, repository.ErrStale)
+		}
+		entry.Media = changes.Media
+		if got, err := r.FindById(ctx, entry.RankId, entry.Id); err != nil || !reflect.DeepEqual(*got, entry) {
+			t.Errorf("FindById(%v, %v, %v) got (%v, %v), want (%v, %v)", ctx, entry.RankId, entry.Id, got, err, entry, nil)
+		}
+		empty := entity.Entry{Id: entry.Id, RankId: entry.RankId}
+		if err := r.UpdateMedia(ctx, &empty, entry.Media); err != nil {
+			t.Errorf("UpdateMedia(%v, %v, %v) got %v, want %v", ctx, empty, entry.Media, err, nil)
+		}
+		if err := r.UpdateMedia(ctx, &changes, nil); err != nil {
+			t.Errorf("UpdateMedia(%v, %v, %v) got %v, want %v", ctx, changes, nil, err, nil)
+		}
+	})
+	t.Run("UpdateScores", func(t *testing.T) {
+		changes := entity.Entry{Id: entry.Id, RankId: entry.RankId, Scores: entity.Scores{
+			"Controls":  91,
+			"Graphics":  94,
+			"Sound.5.1": 80,
+		}}
+		if err := r.UpdateScores(ctx, []*entity.Entry{&changes}); err != nil {
+			t.Errorf("UpdateScores(%v, %v) got %v, want %v", ctx, changes, err, nil)
+		}
+		entry.Scores = maps.Clone(entry.Scores)
+		maps.Copy(entry.Scores, changes.Scores)
+		got, err := getItem[entryRecord](ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got.Scores, entry.Scores) || got.Name != entry.Name {
+			t.Errorf("saved item does not match the expected one: got %v, want scores %v", got, entry.Scores)
+		}
+		missing := entity.Entry{Id: "3f87c939-eea6-4a8f-946f-aff81983a306", RankId: entry.RankId}
+		if err := r.UpdateScores(ctx, []*entity.Entry{&entry, &missing}); err == nil {
+			t.Errorf("UpdateScores(%v, %v) with a missing entry got %v, want an error", ctx, missing, err)
+		}
+	})
+	t.Run("UpdateScores without a score map", func(t *testing.T) {
+		legacy := []*entity.Entry{
+			{Id: "8a0d8f0e-5d3b-4d8e-9a54-0c6c1f1f8b11", RankId: entry.RankId, Name: "No scores"},
+			{Id: "f2b9e7a4-3c61-4f0a-b8e2-6d7d2c9e4a35", RankId: entry.RankId, Name: "Null scores"},
+		}
+		// Entries stored before every entry got a score map have either no
+		// scores attribute or a null one.
+		if err := putItem(ctx, &struct {
+			record
+			Id     string `dynamodbav:"id"`
+			Name   string `dynamodbav:"name"`
+			RankId string `dynamodbav:"rankid"`
+		}{record{RecordType: "entry"}, fmt.Sprintf("%s/%s", entry.RankId, legacy[0].Id), legacy[0].Name, entry.RankId}); err != nil {
+			t.Fatal(err)
+		}
+		if err := putItem(ctx, &entryRecord{
+			record: record{RecordType: "entry"},
+			Id:     fmt.Sprintf("%s/%s", entry.RankId, legacy[1].Id),
+			Name:   legacy[1].Name,
+			RankId: entry.RankId,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		changes := []*entity.Entry{
+			{Id: entry.Id, RankId: entry.RankId, Scores: entity.Scores{"Controls": 92}},
+			{Id: legacy[0].Id, RankId: entry.RankId, Scores: entity.Scores{"Controls": 70}},
+			{Id: legacy[1].Id, RankId: entry.RankId, Scores: entity.Scores{"Graphics": 60}},
+		}
+		if err := r.UpdateScores(ctx, changes); err != nil {
+			t.Errorf("UpdateScores(%v, %v) got %v, want %v", ctx, changes, err, nil)
+		}
+		entry.Scores["Controls"] = 92
+		for i, want := range []entity.Scores{entry.Scores, {"Controls": 70}, {"Graphics": 60}} {
+			got, err := getItem[entryRecord](ctx, fmt.Sprintf("%s/%s", entry.RankId, changes[i].Id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Scores, want) {
+				t.Errorf("saved item does not match the expected one: got %v, want scores %v", got, want)
+			}
+		}
+		for _, e := range legacy {
+			if err := r.Delete(ctx, e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	t.Run("UpdateImageURL", func(t *testing.T) {
+		changes := entity.Entry{Id: entry.Id, RankId: entry.RankId, ImageURL: "https://videogame.com/imported.png"}
+		if err := r.UpdateImageURL(ctx, &changes, entry.ImageURL); err != nil {
+			t.Errorf("UpdateImageURL(%v, %v, %v) got %v, want %v", ctx, changes, entry.ImageURL, err, nil)
+		}
+		got, err := getItem[entryRecord](ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ImageURL != changes.ImageURL || got.Name != entry.Name {
+			t.Errorf("saved item does not match the expected one: got %v, want image URL %v", got, changes.ImageURL)
+		}
+		if err := r.UpdateImageURL(ctx, &changes, entry.ImageURL); !errors.Is(err, repository.ErrStale) {
+			t.Errorf("UpdateImageURL(%v, %v, %v) of a changed entry got %v, want %v", ctx, changes, entry.ImageURL, err, repository.ErrStale)
+		}
+		entry.ImageURL = changes.ImageURL
+	})
 	t.Run("Delete", func(t *testing.T) {
 		if err := r.Delete(ctx, &entry); err != nil {
 			t.Errorf("Delete(%v, %v) got %v, want %v", ctx, entry, err, nil)
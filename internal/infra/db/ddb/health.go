@@ -0,0 +1,43 @@
+package ddb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const indexName = "gsi"
+
+type TableHealthChecker struct {
+	client *dynamodb.Client
+}
+
+func NewTableHealthChecker(client *dynamodb.Client) *TableHealthChecker {
+	return &TableHealthChecker{client}
+}
+
+func (c *TableHealthChecker) Check(ctx context.Context) error {
+	input := &dynamodb.DescribeTableInput{
+		TableName: tableName,
+	}
+	output, err := c.client.DescribeTable(ctx, input)
+	if err != nil {
+		return err
+	}
+	if status := output.Table.TableStatus; status != types.TableStatusActive {
+		return fmt.Errorf("table %s is %s", *tableName, status)
+	}
+	for _, index := range output.Table.GlobalSecondaryIndexes {
+		if aws.ToString(index.IndexName) != indexName {
+			continue
+		}
+		if status := index.IndexStatus; status != "" && status != types.IndexStatusActive {
+			return fmt.Errorf("index %s is %s", indexName, status)
+		}
+		return nil
+	}
+	return fmt.Errorf("index %s not found on table %s", indexName, *tableName)
+}
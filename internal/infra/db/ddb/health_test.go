@@ -0,0 +1,16 @@
+package ddb
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTableHealthChecker(t *testing.T) {
+	ctx := context.Background()
+	c := NewTableHealthChecker(client)
+	t.Run("Check", func(t *testing.T) {
+		if err := c.Check(ctx); err != nil {
+			t.Errorf("Check(%v) got %v, want %v", ctx, err, nil)
+		}
+	})
+}
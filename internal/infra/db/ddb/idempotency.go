@@ -0,0 +1,145 @@
+package ddb
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/josimarz/ranking-backend/internal/infra/idempotency"
+)
+
+// idempotencyRecord lives in the rank table without a rankid, so it stays out
+// of the gsi. The expires attribute is the table's TTL attribute.
+type idempotencyRecord struct {
+	record
+	Id          string      `dynamodbav:"id"`
+	Fingerprint string      `dynamodbav:"fingerprint"`
+	Status      int         `dynamodbav:"status"`
+	Header      http.Header `dynamodbav:"header,omitempty"`
+	Body        []byte      `dynamodbav:"body,omitempty"`
+	Expires     int64       `dynamodbav:"expires"`
+}
+
+type IdempotencyDynamodbStore struct {
+	client *dynamodb.Client
+	now    func() time.Time
+}
+
+func NewIdempotencyDynamodbStore(client *dynamodb.Client) *IdempotencyDynamodbStore {
+	return &IdempotencyDynamodbStore{client, time.Now}
+}
+
+func (s *IdempotencyDynamodbStore) Reserve(ctx context.Context, key string, rec *idempotency.Record) (*idempotency.Record, error) {
+	item, err := attributevalue.MarshalMap(newIdempotencyRecord(key, rec))
+	if err != nil {
+		return nil, err
+	}
+	// DynamoDB deletes expired items lazily, so an expired record still
+	// present in the table must not block a new reservation.
+	cond := expression.Or(
+		expression.AttributeNotExists(expression.Name("id")),
+		expression.Name("expires").LessThanEqual(expression.Value(s.now().Unix())),
+	)
+	expr, err := expression.NewBuilder().WithCondition(cond).Build()
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.PutItemInput{
+		TableName:                 tableName,
+		Item:                      item,
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+	}
+	_, err = s.client.PutItem(ctx, input)
+	var condErr *types.ConditionalCheckFailedException
+	switch {
+	case errors.As(err, &condErr):
+		return s.find(ctx, key)
+	case err != nil:
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (s *IdempotencyDynamodbStore) Complete(ctx context.Context, key string, rec *idempotency.Record) error {
+	item, err := attributevalue.MarshalMap(newIdempotencyRecord(key, rec))
+	if err != nil {
+		return err
+	}
+	input := &dynamodb.PutItemInput{
+		TableName:    tableName,
+		Item:         item,
+		ReturnValues: types.ReturnValueNone,
+	}
+	if _, err := s.client.PutItem(ctx, input); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *IdempotencyDynamodbStore) Release(ctx context.Context, key string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:    tableName,
+		Key:          idempotencyKey(key),
+		ReturnValues: types.ReturnValueNone,
+	}
+	if _, err := s.client.DeleteItem(ctx, input); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (s *IdempotencyDynamodbStore) find(ctx context.Context, key string) (*idempotency.Record, error) {
+	input := &dynamodb.GetItemInput{
+		TableName:      tableName,
+		Key:            idempotencyKey(key),
+		ConsistentRead: aws.Bool(true),
+	}
+	res, err := s.client.GetItem(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if res.Item == nil {
+		return nil, errors.New("idempotency record disappeared while being reserved")
+	}
+	var rec idempotencyRecord
+	if err := attributevalue.UnmarshalMap(res.Item, &rec); err != nil {
+		return nil, err
+	}
+	return &idempotency.Record{
+		Fingerprint: rec.Fingerprint,
+		Status:      rec.Status,
+		Header:      rec.Header,
+		Body:        rec.Body,
+		ExpiresAt:   time.Unix(rec.Expires, 0),
+	}, nil
+}
+
+func newIdempotencyRecord(key string, rec *idempotency.Record) *idempotencyRecord {
+	return &idempotencyRecord{
+		record: record{
+			RecordType: "idempotency",
+			UpdatedAt:  time.Now().UTC(),
+		},
+		Id:          key,
+		Fingerprint: rec.Fingerprint,
+		Status:      rec.Status,
+		Header:      rec.Header,
+		Body:        rec.Body,
+		Expires:     rec.ExpiresAt.Unix(),
+	}
+}
+
+func idempotencyKey(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id":  &types.AttributeValueMemberS{Value: key},
+		"typ": &types.AttributeValueMemberS{Value: "idempotency"},
+	}
+}
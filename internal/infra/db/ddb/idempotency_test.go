@@ -0,0 +1,61 @@
+package ddb
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/infra/idempotency"
+)
+
+func TestIdempotencyDynamodbStore(t *testing.T) {
+	ctx := context.Background()
+	s := NewIdempotencyDynamodbStore(client)
+	key := "POST /rank 5f0c3e1a"
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	t.Run("Reserve", func(t *testing.T) {
+		rec := &idempotency.Record{Fingerprint: "abc", ExpiresAt: expires}
+		if got, err := s.Reserve(ctx, key, rec); got != nil || err != nil {
+			t.Fatalf("Reserve(%v, %v, %v) got (%v, %v), want (%v, %v)", ctx, key, rec, got, err, nil, nil)
+		}
+		got, err := s.Reserve(ctx, key, &idempotency.Record{Fingerprint: "def", ExpiresAt: expires})
+		if err != nil || got == nil || got.Fingerprint != "abc" || got.Completed() {
+			t.Errorf("Reserve(%v, %v) got (%v, %v), want the pending record", ctx, key, got, err)
+		}
+	})
+	t.Run("Complete", func(t *testing.T) {
+		rec := &idempotency.Record{
+			Fingerprint: "abc",
+			Status:      http.StatusCreated,
+			Header:      http.Header{"Content-Type": {"application/json"}},
+			Body:        []byte(`{"id":"1"}`),
+			ExpiresAt:   expires,
+		}
+		if err := s.Complete(ctx, key, rec); err != nil {
+			t.Fatalf("Complete(%v, %v, %v) got %v, want %v", ctx, key, rec, err, nil)
+		}
+		got, err := s.Reserve(ctx, key, &idempotency.Record{Fingerprint: "abc", ExpiresAt: expires})
+		if err != nil || !reflect.DeepEqual(got, rec) {
+			t.Errorf("Reserve(%v, %v) got (%v, %v), want (%v, %v)", ctx, key, got, err, rec, nil)
+		}
+	})
+	t.Run("Release", func(t *testing.T) {
+		if err := s.Release(ctx, key); err != nil {
+			t.Fatalf("Release(%v, %v) got %v, want %v", ctx, key, err, nil)
+		}
+		if got, err := s.Reserve(ctx, key, &idempotency.Record{Fingerprint: "abc", ExpiresAt: expires}); got != nil || err != nil {
+			t.Errorf("Reserve(%v, %v) after Release got (%v, %v), want (%v, %v)", ctx, key, got, err, nil, nil)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		key := "POST /rank 9b2d7a44"
+		if _, err := s.Reserve(ctx, key, &idempotency.Record{Fingerprint: "abc", ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := s.Reserve(ctx, key, &idempotency.Record{Fingerprint: "def", ExpiresAt: expires}); got != nil || err != nil {
+			t.Errorf("Reserve(%v, %v) over an expired record got (%v, %v), want (%v, %v)", ctx, key, got, err, nil, nil)
+		}
+	})
+}
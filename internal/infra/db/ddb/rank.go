@@ -2,6 +2,7 @@ package ddb
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -80,6 +81,7 @@ func (r *RankDynamodbRepository) putItem(ctx context.Context, rank *entity.Rank)
 	rec := &rankRecord{
 		record: record{
 			RecordType: "rank",
+			UpdatedAt:  time.Now().UTC(),
 		},
 		Id:     rank.Id,
 		RankId: rank.Id,
@@ -28,6 +28,10 @@ func TestRankDynamodbRepository(t *testing.T) {
 			Name:   rank.Name,
 			Public: rank.Public,
 		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("saved item has no updatedat")
+		}
+		want.UpdatedAt = got.UpdatedAt
 		if *got != *want {
 			t.Errorf("saved item does not match the expected one: got %v, want %v", got, want)
 		}
@@ -60,6 +64,10 @@ func TestRankDynamodbRepository(t *testing.T) {
 			Name:   rank.Name,
 			Public: rank.Public,
 		}
+		if got.UpdatedAt.IsZero() {
+			t.Errorf("saved item has no updatedat")
+		}
+		want.UpdatedAt = got.UpdatedAt
 		if *got != *want {
 			t.Errorf("saved item does not match the expected one: got %v, wnat %v", got, want)
 		}
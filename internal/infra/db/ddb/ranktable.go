@@ -2,64 +2,171 @@ package ddb
 
 import (
 	"context"
+	"errors"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 )
 
+// ErrIndexBehind is returned by consistent rank table repositories when the
+// index has not caught up with the last write to the rank in time.
+var ErrIndexBehind = errors.New("ddb: index has not caught up with the rank")
+
+const (
+	consistentReadAttempts = 5
+	consistentReadBackoff  = 100 * time.Millisecond
+)
+
 type RankTableDynamodbRepository struct {
-	client *dynamodb.Client
+	client     *dynamodb.Client
+	consistent bool
 }
 
 func NewRankTableDynamodbRepository(client *dynamodb.Client) *RankTableDynamodbRepository {
-	return &RankTableDynamodbRepository{client}
+	return &RankTableDynamodbRepository{client: client}
+}
+
+// NewConsistentRankTableDynamodbRepository returns a repository for callers
+// that delete what rank tables no longer reference. Rank tables are read from
+// an index, which DynamoDB updates asynchronously, so before returning one it
+// waits for the index to show the last write to the rank, read consistently
+// from the table. Entry writes that can change the images of the entry touch
+// the rank once the entry is written, so the rank table includes every one
+// that finished before the call. If the index does not catch up in time,
+// ErrIndexBehind is returned.
+func NewConsistentRankTableDynamodbRepository(client *dynamodb.Client) *RankTableDynamodbRepository {
+	return &RankTableDynamodbRepository{client: client, consistent: true}
 }
 
 func (r *RankTableDynamodbRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	if !r.consistent {
+		table, _, err := r.find(ctx, id)
+		return table, err
+	}
+	fence, err := r.rankUpdatedAt(ctx, id)
+	if err != nil || fence == nil {
+		return nil, err
+	}
+	for attempt := 1; ; attempt++ {
+		table, updatedAt, err := r.find(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if table != nil && !updatedAt.Before(*fence) {
+			return table, nil
+		}
+		if attempt == consistentReadAttempts {
+			return nil, ErrIndexBehind
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt) * consistentReadBackoff):
+		}
+	}
+}
+
+// RankTableFence tells whether rank tables read from the index show the last
+// write to their rank, read consistently from the table.
+type RankTableFence struct {
+	repo *RankTableDynamodbRepository
+}
+
+func NewRankTableFence(client *dynamodb.Client) *RankTableFence {
+	return &RankTableFence{&RankTableDynamodbRepository{client: client}}
+}
+
+// Reached reports false if the rank no longer exists.
+func (f *RankTableFence) Reached(ctx context.Context, table *entity.RankTable) (bool, error) {
+	fence, err := f.repo.rankUpdatedAt(ctx, table.Id)
+	if err != nil || fence == nil {
+		return false, err
+	}
+	return !table.UpdatedAt.Before(*fence), nil
+}
+
+// rankUpdatedAt reads the updatedat of the rank from the table, which is
+// strongly consistent unlike the index. It returns nil if the rank does not
+// exist.
+func (r *RankTableDynamodbRepository) rankUpdatedAt(ctx context.Context, id string) (*time.Time, error) {
+	key, err := attributevalue.MarshalMap(map[string]string{"id": id, "typ": "rank"})
+	if err != nil {
+		return nil, err
+	}
+	input := &dynamodb.GetItemInput{
+		TableName:      tableName,
+		Key:            key,
+		ConsistentRead: aws.Bool(true),
+	}
+	res, err := r.client.GetItem(ctx, input)
+	if err != nil || res.Item == nil {
+		return nil, err
+	}
+	var rec record
+	if err := attributevalue.UnmarshalMap(res.Item, &rec); err != nil {
+		return nil, err
+	}
+	return &rec.UpdatedAt, nil
+}
+
+// find reads the rank table from the index, following every page of the
+// query, and also returns the updatedat of the rank item itself.
+func (r *RankTableDynamodbRepository) find(ctx context.Context, id string) (*entity.RankTable, time.Time, error) {
 	keyEx := expression.Key("rankid").Equal(expression.Value(id))
 	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
 	}
 	input := &dynamodb.QueryInput{
 		TableName:                 tableName,
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		KeyConditionExpression:    expr.KeyCondition(),
-		IndexName:                 aws.String("gsi"),
+		IndexName:                 aws.String(indexName),
 	}
-	output, err := r.client.Query(ctx, input)
-	if err != nil {
-		return nil, err
+	var items []map[string]types.AttributeValue
+	paginator := dynamodb.NewQueryPaginator(r.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		items = append(items, page.Items...)
 	}
-	if output.Count == 0 {
-		return nil, nil
+	if len(items) == 0 {
+		return nil, time.Time{}, nil
 	}
+	var rankUpdatedAt time.Time
 	var rankTable entity.RankTable
-	for _, item := range output.Items {
-		var typ string
-		err := attributevalue.Unmarshal(item["typ"], &typ)
-		if err != nil {
-			return nil, err
+	for _, item := range items {
+		var base record
+		if err := attributevalue.UnmarshalMap(item, &base); err != nil {
+			return nil, time.Time{}, err
+		}
+		if base.UpdatedAt.After(rankTable.UpdatedAt) {
+			rankTable.UpdatedAt = base.UpdatedAt
 		}
-		switch typ {
+		switch base.RecordType {
 		case "rank":
 			var rec rankRecord
 			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
-				return nil, err
+				return nil, time.Time{}, err
 			}
+			rankUpdatedAt = rec.UpdatedAt
 			rankTable.Id = rec.Id
 			rankTable.Name = rec.Name
 			rankTable.Public = rec.Public
 		case "attribute":
 			var rec attributeRecord
 			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
-				return nil, err
+				return nil, time.Time{}, err
 			}
 			attr := entity.Attribute{
 				Id:     strings.Split(rec.Id, "/")[1],
@@ -72,7 +179,7 @@ func (r *RankTableDynamodbRepository) FindById(ctx context.Context, id string) (
 		case "entry":
 			var rec entryRecord
 			if err := attributevalue.UnmarshalMap(item, &rec); err != nil {
-				return nil, err
+				return nil, time.Time{}, err
 			}
 			entry := entity.Entry{
 				Id:       strings.Split(rec.Id, "/")[1],
@@ -80,6 +187,7 @@ func (r *RankTableDynamodbRepository) FindById(ctx context.Context, id string) (
 				ImageURL: rec.ImageURL,
 				Scores:   rec.Scores,
 				RankId:   rec.RankId,
+				Media:    mediaOf(rec.Media),
 			}
 			rankTable.Entries = append(rankTable.Entries, entry)
 		}
@@ -90,5 +198,5 @@ func (r *RankTableDynamodbRepository) FindById(ctx context.Context, id string) (
 	sort.Slice(rankTable.Entries, func(i, j int) bool {
 		return rankTable.Entries[i].Name < rankTable.Entries[j].Name
 	})
-	return &rankTable, nil
+	return &rankTable, rankUpdatedAt, nil
 }
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/mock"
@@ -19,11 +20,12 @@ func TestRankTableDynamodbRepository(t *testing.T) {
 	}
 	t.Run("FindById", func(t *testing.T) {
 		want := &entity.RankTable{
-			Id:      mock.Rank.Id,
-			Name:    mock.Rank.Name,
-			Public:  mock.Rank.Public,
-			Attrs:   mock.Attrs,
-			Entries: mock.Entries,
+			Id:        mock.Rank.Id,
+			Name:      mock.Rank.Name,
+			Public:    mock.Rank.Public,
+			Attrs:     mock.Attrs,
+			Entries:   mock.Entries,
+			UpdatedAt: mockUpdatedAt,
 		}
 		sort.Slice(want.Attrs, func(i, j int) bool {
 			return want.Attrs[i].Order < want.Attrs[j].Order
@@ -38,6 +40,29 @@ func TestRankTableDynamodbRepository(t *testing.T) {
 		if got, err := r.FindById(ctx, id); err != nil || got != nil {
 			t.Errorf("FindById(%v, %v) got (%v, %v), want (%v, %v)", ctx, id, got, err, nil, nil)
 		}
+		consistent := NewConsistentRankTableDynamodbRepository(client)
+		if got, err := consistent.FindById(ctx, mock.Rank.Id); err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("FindById(%v, %v) of the consistent repository got (%v, %v), want (%v, %v)", ctx, mock.Rank.Id, got, err, want, nil)
+		}
+		if got, err := consistent.FindById(ctx, id); err != nil || got != nil {
+			t.Errorf("FindById(%v, %v) of the consistent repository got (%v, %v), want (%v, %v)", ctx, id, got, err, nil, nil)
+		}
+	})
+	t.Run("RankTableFence", func(t *testing.T) {
+		f := NewRankTableFence(client)
+		tests := []struct {
+			table *entity.RankTable
+			want  bool
+		}{
+			{&entity.RankTable{Id: mock.Rank.Id, UpdatedAt: mockUpdatedAt}, true},
+			{&entity.RankTable{Id: mock.Rank.Id, UpdatedAt: mockUpdatedAt.Add(-time.Second)}, false},
+			{&entity.RankTable{Id: "1c87a2f3-15ee-473c-9ad6-adb889dbef36", UpdatedAt: mockUpdatedAt}, false},
+		}
+		for _, tt := range tests {
+			if got, err := f.Reached(ctx, tt.table); got != tt.want || err != nil {
+				t.Errorf("Reached(%v, %v) got (%v, %v), want (%v, %v)", ctx, tt.table, got, err, tt.want, nil)
+			}
+		}
 	})
 }
 
@@ -51,10 +76,13 @@ func mockRankTable(ctx context.Context) error {
 	return mockEntries(ctx)
 }
 
+var mockUpdatedAt = time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
 func mockRank(ctx context.Context) error {
 	rec := &rankRecord{
 		record: record{
 			RecordType: "rank",
+			UpdatedAt:  mockUpdatedAt,
 		},
 		Id:     mock.Rank.Id,
 		RankId: mock.Rank.Id,
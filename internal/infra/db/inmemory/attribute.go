@@ -16,6 +16,7 @@ type AttributeInMemoryRepository struct{}
 func (r *AttributeInMemoryRepository) Create(ctx context.Context, attr *entity.Attribute) error {
 	key := fmt.Sprintf("%s/%s", attr.RankId, attr.Id)
 	attrs[key] = attr
+	touch(attr.RankId)
 	return nil
 }
 
@@ -30,11 +31,13 @@ func (r *AttributeInMemoryRepository) FindById(ctx context.Context, rankId, id s
 func (r *AttributeInMemoryRepository) Update(ctx context.Context, attr *entity.Attribute) error {
 	key := fmt.Sprintf("%s/%s", attr.RankId, attr.Id)
 	attrs[key] = attr
+	touch(attr.RankId)
 	return nil
 }
 
 func (r *AttributeInMemoryRepository) Delete(ctx context.Context, attr *entity.Attribute) error {
 	key := fmt.Sprintf("%s/%s", attr.RankId, attr.Id)
 	delete(attrs, key)
+	touch(attr.RankId)
 	return nil
 }
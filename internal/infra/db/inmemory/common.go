@@ -1,9 +1,23 @@
 package inmemory
 
-import "github.com/josimarz/ranking-backend/internal/domain/entity"
+import (
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+)
+
+var (
+	// updated holds the last modification time of each rank table.
+	updated map[string]time.Time = make(map[string]time.Time)
+)
 
 func ClearDatabase() {
 	ranks = make(map[string]*entity.Rank)
 	attrs = make(map[string]*entity.Attribute)
 	entries = make(map[string]*entity.Entry)
+	updated = make(map[string]time.Time)
+}
+
+func touch(rankId string) {
+	updated[rankId] = time.Now().UTC()
 }
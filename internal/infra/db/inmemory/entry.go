@@ -3,8 +3,11 @@ package inmemory
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
 )
 
 var (
@@ -16,6 +19,7 @@ type EntryInMemoryRepository struct{}
 func (r *EntryInMemoryRepository) Create(ctx context.Context, entry *entity.Entry) error {
 	key := fmt.Sprintf("%s/%s", entry.RankId, entry.Id)
 	entries[key] = entry
+	touch(entry.RankId)
 	return nil
 }
 
@@ -27,14 +31,69 @@ func (r *EntryInMemoryRepository) FindById(ctx context.Context, rankId, id strin
 	return nil, nil
 }
 
-func (r *EntryInMemoryRepository) Update(ctx context.Context, entry *entity.Entry) error {
-	key := fmt.Sprintf("%s/%s", entry.RankId, entry.Id)
-	entries[key] = entry
+func (r *EntryInMemoryRepository) Update(ctx context.Context, item *entity.Entry) error {
+	key := fmt.Sprintf("%s/%s", item.RankId, item.Id)
+	current, ok := entries[key]
+	if !ok {
+		return repository.ErrStale
+	}
+	entry := *item
+	entry.Media = current.Media
+	entries[key] = &entry
+	touch(item.RankId)
 	return nil
 }
 
 func (r *EntryInMemoryRepository) Delete(ctx context.Context, entry *entity.Entry) error {
 	key := fmt.Sprintf("%s/%s", entry.RankId, entry.Id)
 	delete(entries, key)
+	touch(entry.RankId)
+	return nil
+}
+
+func (r *EntryInMemoryRepository) UpdateScores(ctx context.Context, items []*entity.Entry) error {
+	for _, item := range items {
+		key := fmt.Sprintf("%s/%s", item.RankId, item.Id)
+		if _, ok := entries[key]; !ok {
+			return fmt.Errorf("entry %s does not exist", key)
+		}
+	}
+	for _, item := range items {
+		key := fmt.Sprintf("%s/%s", item.RankId, item.Id)
+		entry := *entries[key]
+		entry.Scores = maps.Clone(entry.Scores)
+		if entry.Scores == nil {
+			entry.Scores = make(entity.Scores)
+		}
+		maps.Copy(entry.Scores, item.Scores)
+		entries[key] = &entry
+		touch(item.RankId)
+	}
+	return nil
+}
+
+func (r *EntryInMemoryRepository) UpdateImageURL(ctx context.Context, item *entity.Entry, from string) error {
+	key := fmt.Sprintf("%s/%s", item.RankId, item.Id)
+	current, ok := entries[key]
+	if !ok || current.ImageURL != from {
+		return repository.ErrStale
+	}
+	entry := *current
+	entry.ImageURL = item.ImageURL
+	entries[key] = &entry
+	touch(item.RankId)
+	return nil
+}
+
+func (r *EntryInMemoryRepository) UpdateMedia(ctx context.Context, item *entity.Entry, from []entity.Media) error {
+	key := fmt.Sprintf("%s/%s", item.RankId, item.Id)
+	current, ok := entries[key]
+	if !ok || !slices.Equal(current.Media, from) {
+		return repository.ErrStale
+	}
+	entry := *current
+	entry.Media = slices.Clone(item.Media)
+	entries[key] = &entry
+	touch(item.RankId)
 	return nil
 }
@@ -2,11 +2,13 @@ package inmemory
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"testing"
 
 	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
@@ -55,6 +57,23 @@ func TestEntryInMemoryRepository(t *testing.T) {
 		if !reflect.DeepEqual(*item, entry) {
 			t.Errorf("saved item does not match the expected one: got %v, want %v", item, entry)
 		}
+		snapshot := entry
+		entry.Media = []entity.Media{*entity.NewMedia("https://videogame.com/dreamcast-box.png", "Box art", "", entity.MediaImage, true)}
+		if err := r.UpdateMedia(ctx, &entry, nil); err != nil {
+			t.Errorf("UpdateMedia(%v, %v, %v) got %v, want %v", ctx, entry, nil, err, nil)
+		}
+		snapshot.Name = "Dreamcast"
+		if err := r.Update(ctx, &snapshot); err != nil {
+			t.Errorf("Update(%v, %v) got %v, want %v", ctx, snapshot, err, nil)
+		}
+		entry.Name = snapshot.Name
+		if item := entries[key]; !reflect.DeepEqual(*item, entry) {
+			t.Errorf("saved item does not match the expected one: got %v, want %v", item, entry)
+		}
+		missing := entity.Entry{Id: "3f87c939-eea6-4a8f-946f-aff81983a306", RankId: entry.RankId}
+		if err := r.Update(ctx, &missing); !errors.Is(err, repository.ErrStale) {
+			t.Errorf("Update(%v, %v) of a missing entry got %v, want %v", ctx, missing, err, repository.ErrStale)
+		}
 	})
 	t.Run("Delete", func(t *testing.T) {
 		if err := r.Delete(ctx, &entry); err != nil {
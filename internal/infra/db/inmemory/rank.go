@@ -14,6 +14,7 @@ type RankInMemoryRepository struct{}
 
 func (r *RankInMemoryRepository) Create(ctx context.Context, rank *entity.Rank) error {
 	ranks[rank.Id] = rank
+	touch(rank.Id)
 	return nil
 }
 
@@ -26,10 +27,12 @@ func (r *RankInMemoryRepository) FindById(ctx context.Context, id string) (*enti
 
 func (r *RankInMemoryRepository) Update(ctx context.Context, rank *entity.Rank) error {
 	ranks[rank.Id] = rank
+	touch(rank.Id)
 	return nil
 }
 
 func (r *RankInMemoryRepository) Delete(ctx context.Context, rank *entity.Rank) error {
 	delete(ranks, rank.Id)
+	delete(updated, rank.Id)
 	return nil
 }
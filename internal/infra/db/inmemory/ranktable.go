@@ -15,11 +15,12 @@ func (r *RankTableInMemoryRepository) FindById(ctx context.Context, id string) (
 		return nil, nil
 	}
 	rt := &entity.RankTable{
-		Id:      rank.Id,
-		Name:    rank.Name,
-		Public:  rank.Public,
-		Attrs:   r.filterAttributes(rank.Id),
-		Entries: r.filterEntries(rank.Id),
+		Id:        rank.Id,
+		Name:      rank.Name,
+		Public:    rank.Public,
+		Attrs:     r.filterAttributes(rank.Id),
+		Entries:   r.filterEntries(rank.Id),
+		UpdatedAt: updated[rank.Id],
 	}
 	sort.Slice(rt.Attrs, func(i, j int) bool {
 		return rt.Attrs[i].Order < rt.Attrs[j].Order
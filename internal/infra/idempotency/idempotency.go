@@ -0,0 +1,272 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+)
+
+const (
+	ReplayedHeader = "Idempotent-Replayed"
+	maxKeyLength   = 255
+)
+
+// Record is what a Store keeps for an idempotency key. A record with a zero
+// Status has been reserved by a request that is still being processed.
+type Record struct {
+	Fingerprint string
+	Status      int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+func (r *Record) Completed() bool {
+	return r.Status != 0
+}
+
+type Store interface {
+	// Reserve saves rec under key unless a live record already exists, in
+	// which case the existing record is returned and nothing is written.
+	Reserve(ctx context.Context, key string, rec *Record) (*Record, error)
+	// Complete replaces the reservation of key with the final response.
+	Complete(ctx context.Context, key string, rec *Record) error
+	// Release removes the reservation of key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+type Config struct {
+	Header  string
+	Methods []string
+	// TTL is how long responses are replayed.
+	TTL time.Duration
+	// LockTTL is how long a reservation holds a key, so a request that never
+	// completes, such as one whose server crashed, does not hold it for TTL.
+	LockTTL time.Duration
+	// ShortTTL caps the TTL of the responses of the routes whose paths end
+	// with one of ShortTTLSuffixes, which hold signed image URLs that expire.
+	ShortTTL         time.Duration
+	ShortTTLSuffixes []string
+	MaxBodyBytes     int64
+	// ReplayHeaders lists the response headers saved along with the body.
+	ReplayHeaders []string
+	// Client identifies the client of a request, so that clients using the
+	// same key do not get each other's responses.
+	Client func(*http.Request) string
+	Now    func() time.Time
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Header:           "Idempotency-Key",
+		Methods:          []string{http.MethodPost},
+		TTL:              24 * time.Hour,
+		LockTTL:          2 * time.Minute,
+		ShortTTL:         15 * time.Minute,
+		ShortTTLSuffixes: []string{"/entry", "/media", "/media:reorder", "/images:import", "/file", "/file:presign", "/file:confirm"},
+		MaxBodyBytes:     16 << 20,
+		ReplayHeaders:    []string{"Content-Type", "Location"},
+		Now:              time.Now,
+	}
+}
+
+// Middleware replays the stored response of requests that repeat an
+// Idempotency-Key, so retried POSTs do not create duplicates. Keys are scoped
+// to the client, method and path, and reusing one with a different body is
+// rejected.
+func Middleware(logger *slog.Logger, cfg Config, store Store) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(cfg.Header)
+			if key == "" || !slices.Contains(cfg.Methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				msg := fmt.Sprintf("%v must not be longer than %d characters", cfg.Header, maxKeyLength)
+				problem.New(http.StatusBadRequest, problem.CodeBadRequest, msg).Write(w, r, nil)
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, cfg.MaxBodyBytes+1))
+			if err != nil {
+				problem.New(http.StatusBadRequest, problem.CodeBadRequest, "body could not be read").Write(w, r, nil)
+				return
+			}
+			if int64(len(body)) > cfg.MaxBodyBytes {
+				msg := fmt.Sprintf("requests with an %v must not have a body larger than %d bytes", cfg.Header, cfg.MaxBodyBytes)
+				problem.New(http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge, msg).Write(w, r, nil)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scoped := cfg.scope(r, key)
+			fingerprint := fingerprint(r.Header.Get("Content-Type"), body)
+			existing, err := store.Reserve(r.Context(), scoped, &Record{
+				Fingerprint: fingerprint,
+				ExpiresAt:   cfg.Now().Add(cfg.LockTTL),
+			})
+			if err != nil {
+				logger.WarnContext(r.Context(), "idempotency key could not be reserved, processing the request without it", "method", r.Method, "uri", r.URL.RequestURI(), "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if existing != nil {
+				replay(w, r, cfg, existing, fingerprint)
+				return
+			}
+
+			rw := &recorder{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				if rec := recover(); rec != nil {
+					store.Release(context.WithoutCancel(r.Context()), scoped)
+					panic(rec)
+				}
+			}()
+			next.ServeHTTP(rw, r)
+			if rw.status >= http.StatusInternalServerError {
+				store.Release(context.WithoutCancel(r.Context()), scoped)
+				return
+			}
+			rec := &Record{
+				Fingerprint: fingerprint,
+				Status:      rw.status,
+				Header:      make(http.Header),
+				Body:        rw.body.Bytes(),
+				ExpiresAt:   cfg.Now().Add(cfg.ttl(r)),
+			}
+			for _, name := range cfg.ReplayHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					rec.Header[name] = slices.Clone(values)
+				}
+			}
+			ctx := context.WithoutCancel(r.Context())
+			if err := store.Complete(ctx, scoped, rec); err != nil {
+				logger.ErrorContext(ctx, "idempotent response could not be saved", "method", r.Method, "uri", r.URL.RequestURI(), "error", err)
+				store.Release(ctx, scoped)
+			}
+		})
+	}
+}
+
+// scope returns the key the record of a request is stored under. The client
+// is hashed, as it may be an API key.
+func (cfg Config) scope(r *http.Request, key string) string {
+	var client string
+	if cfg.Client != nil {
+		client = cfg.Client(r)
+	}
+	sum := sha256.Sum256([]byte(client))
+	return r.Method + " " + r.URL.Path + " " + hex.EncodeToString(sum[:]) + " " + key
+}
+
+func (cfg Config) ttl(r *http.Request) time.Duration {
+	if slices.ContainsFunc(cfg.ShortTTLSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(r.URL.Path, suffix)
+	}) {
+		return min(cfg.TTL, cfg.ShortTTL)
+	}
+	return cfg.TTL
+}
+
+func replay(w http.ResponseWriter, r *http.Request, cfg Config, rec *Record, fingerprint string) {
+	switch {
+	case rec.Fingerprint != fingerprint:
+		msg := fmt.Sprintf("%v was already used with a different request body", cfg.Header)
+		problem.New(http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused, msg).Write(w, r, nil)
+	case !rec.Completed():
+		msg := fmt.Sprintf("a request with the same %v is still being processed", cfg.Header)
+		problem.New(http.StatusConflict, problem.CodeConflict, msg).Write(w, r, nil)
+	default:
+		for name, values := range rec.Header {
+			w.Header()[name] = slices.Clone(values)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(rec.Status)
+		w.Write(rec.Body)
+	}
+}
+
+// fingerprint hashes the media type and the body of a request. Multipart
+// bodies are hashed part by part, as clients pick a new boundary every time
+// they encode the same form.
+func fingerprint(contentType string, body []byte) string {
+	h := sha256.New()
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	fmt.Fprintf(h, "%s\n", mediaType)
+	if !strings.HasPrefix(mediaType, "multipart/") || !hashParts(h, params["boundary"], body) {
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashParts writes the headers and contents of the parts of a multipart body
+// to h. It reports false, having written nothing, if body is not a valid
+// multipart body.
+func hashParts(h hash.Hash, boundary string, body []byte) bool {
+	if boundary == "" {
+		return false
+	}
+	parts := sha256.New()
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		part, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false
+		}
+		for _, name := range slices.Sorted(maps.Keys(part.Header)) {
+			fmt.Fprintf(parts, "%s: %s\n", name, strings.Join(part.Header[name], ", "))
+		}
+		content := sha256.New()
+		if _, err := io.Copy(content, part); err != nil {
+			return false
+		}
+		fmt.Fprintf(parts, "\n%x\n", content.Sum(nil))
+	}
+	h.Write(parts.Sum(nil))
+	return true
+}
+
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
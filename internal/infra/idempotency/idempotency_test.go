@@ -0,0 +1,279 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	var calls atomic.Int32
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if string(body) == "fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", fmt.Sprint(n))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, n)
+	})
+	h := Middleware(slog.New(slog.DiscardHandler), DefaultConfig(), NewInMemoryStore())(next)
+	send := func(method, path, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	t.Run("replay", func(t *testing.T) {
+		first := send("POST", "/rank", "key-1", `{"name":"a"}`)
+		second := send("POST", "/rank", "key-1", `{"name":"a"}`)
+		if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+			t.Fatalf("requests returned wrong status codes: got %v and %v, want %v", first.Code, second.Code, http.StatusCreated)
+		}
+		if first.Body.String() != second.Body.String() {
+			t.Errorf("replayed body got %v, want %v", second.Body.String(), first.Body.String())
+		}
+		if got := second.Header().Get(ReplayedHeader); got != "true" {
+			t.Errorf("replayed response %v header got %q, want %q", ReplayedHeader, got, "true")
+		}
+		if got := second.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("replayed response Content-Type got %q, want %q", got, "application/json")
+		}
+		if got := second.Header().Get("X-Request-Id"); got != "" {
+			t.Errorf("replayed response X-Request-Id got %q, want it not to be replayed", got)
+		}
+	})
+	t.Run("different payload", func(t *testing.T) {
+		rr := send("POST", "/rank", "key-1", `{"name":"b"}`)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("request returned wrong status code: got %v, want %v", rr.Code, http.StatusUnprocessableEntity)
+		}
+		if !strings.Contains(rr.Body.String(), `"code":"idempotency_key_reused"`) {
+			t.Errorf("request returned wrong body: got %v", rr.Body.String())
+		}
+	})
+	t.Run("scoped by path", func(t *testing.T) {
+		before := calls.Load()
+		if rr := send("POST", "/rank/1/entry", "key-1", `{"name":"a"}`); rr.Code != http.StatusCreated || calls.Load() != before+1 {
+			t.Errorf("request to another path was not processed: got %v", rr.Code)
+		}
+	})
+	t.Run("server error releases key", func(t *testing.T) {
+		before := calls.Load()
+		send("POST", "/rank", "key-2", "fail")
+		send("POST", "/rank", "key-2", "fail")
+		if got := calls.Load() - before; got != 2 {
+			t.Errorf("handler was called %d times, want %d", got, 2)
+		}
+	})
+	t.Run("without key", func(t *testing.T) {
+		before := calls.Load()
+		send("POST", "/rank", "", `{"name":"a"}`)
+		send("POST", "/rank", "", `{"name":"a"}`)
+		send("PUT", "/rank/1", "key-3", `{"name":"a"}`)
+		send("PUT", "/rank/1", "key-3", `{"name":"a"}`)
+		if got := calls.Load() - before; got != 4 {
+			t.Errorf("handler was called %d times, want %d", got, 4)
+		}
+	})
+	t.Run("key too long", func(t *testing.T) {
+		if rr := send("POST", "/rank", strings.Repeat("k", 256), ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("request returned wrong status code: got %v, want %v", rr.Code, http.StatusBadRequest)
+		}
+	})
+}
+
+func TestMiddlewareBodyTooLarge(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodyBytes = 4
+	h := Middleware(slog.New(slog.DiscardHandler), cfg, NewInMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest("POST", "/rank", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("Idempotency-Key", "key-1")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("request returned wrong status code: got %v, want %v", rr.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	form := func(content string) (string, []byte) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		part, _ := mw.CreateFormFile("image", "snes.png")
+		part.Write([]byte(content))
+		mw.Close()
+		return mw.FormDataContentType(), buf.Bytes()
+	}
+	typ1, body1 := form("image")
+	typ2, body2 := form("image")
+	typ3, body3 := form("other")
+	if fingerprint(typ1, body1) != fingerprint(typ2, body2) {
+		t.Errorf("fingerprint() of the same form with other boundaries differs")
+	}
+	if fingerprint(typ1, body1) == fingerprint(typ3, body3) {
+		t.Errorf("fingerprint() of forms with other files is the same")
+	}
+	if fingerprint("application/json", []byte("{}")) == fingerprint("text/plain", []byte("{}")) {
+		t.Errorf("fingerprint() of bodies with other media types is the same")
+	}
+	if fingerprint("application/json", []byte("{}")) != fingerprint("application/json; charset=utf-8", []byte("{}")) {
+		t.Errorf("fingerprint() depends on media type parameters")
+	}
+}
+
+func TestMiddlewareInProgress(t *testing.T) {
+	store := NewInMemoryStore()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := Middleware(slog.New(slog.DiscardHandler), DefaultConfig(), store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "/rank", strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "key")
+		return req
+	}
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), newRequest())
+		close(done)
+	}()
+	<-started
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, newRequest())
+	close(release)
+	<-done
+	if rr.Code != http.StatusConflict {
+		t.Errorf("concurrent request returned wrong status code: got %v, want %v", rr.Code, http.StatusConflict)
+	}
+}
+
+type recordingStore struct {
+	*InMemoryStore
+	reserved  *Record
+	completed *Record
+	failing   bool
+}
+
+func (s *recordingStore) Reserve(ctx context.Context, key string, rec *Record) (*Record, error) {
+	s.reserved = rec
+	return s.InMemoryStore.Reserve(ctx, key, rec)
+}
+
+func (s *recordingStore) Complete(ctx context.Context, key string, rec *Record) error {
+	if s.failing {
+		return errors.New("item size has exceeded the maximum allowed size")
+	}
+	s.completed = rec
+	return s.InMemoryStore.Complete(ctx, key, rec)
+}
+
+func TestMiddlewareExpiration(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := DefaultConfig()
+	cfg.Now = func() time.Time { return now }
+	var calls int
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	send := func(h http.Handler, path string) {
+		req := httptest.NewRequest("POST", path, strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "key")
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	t.Run("lock and replay", func(t *testing.T) {
+		store := &recordingStore{InMemoryStore: NewInMemoryStore()}
+		h := Middleware(slog.New(slog.DiscardHandler), cfg, store)(next)
+		tests := []struct {
+			path string
+			ttl  time.Duration
+		}{
+			{"/rank", cfg.TTL},
+			{"/rank/1/entry", cfg.ShortTTL},
+			{"/rank/1/entry/2/media", cfg.ShortTTL},
+		}
+		for _, tt := range tests {
+			send(h, tt.path)
+			if want := now.Add(cfg.LockTTL); !store.reserved.ExpiresAt.Equal(want) {
+				t.Errorf("reservation of %v expires at %v, want %v", tt.path, store.reserved.ExpiresAt, want)
+			}
+			if want := now.Add(tt.ttl); !store.completed.ExpiresAt.Equal(want) {
+				t.Errorf("response of %v expires at %v, want %v", tt.path, store.completed.ExpiresAt, want)
+			}
+		}
+	})
+	t.Run("failed completion releases key", func(t *testing.T) {
+		store := &recordingStore{InMemoryStore: NewInMemoryStore(), failing: true}
+		h := Middleware(slog.New(slog.DiscardHandler), cfg, store)(next)
+		before := calls
+		send(h, "/rank")
+		send(h, "/rank")
+		if got := calls - before; got != 2 {
+			t.Errorf("handler was called %d times, want %d", got, 2)
+		}
+	})
+}
+
+func TestMiddlewareClients(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Client = func(r *http.Request) string { return r.Header.Get("X-Api-Key") }
+	var calls int
+	h := Middleware(slog.New(slog.DiscardHandler), cfg, NewInMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"id":%d}`, calls)
+	}))
+	send := func(apiKey string) string {
+		req := httptest.NewRequest("POST", "/rank", strings.NewReader("{}"))
+		req.Header.Set("Idempotency-Key", "key")
+		req.Header.Set("X-Api-Key", apiKey)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr.Body.String()
+	}
+	first, second, replayed := send("client-1"), send("client-2"), send("client-1")
+	if first == second || calls != 2 {
+		t.Errorf("request of another client got %v, want a response of its own", second)
+	}
+	if replayed != first {
+		t.Errorf("replayed body got %v, want %v", replayed, first)
+	}
+}
+
+func TestInMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewInMemoryStore()
+	s.now = func() time.Time { return now }
+	rec := &Record{Fingerprint: "abc", ExpiresAt: now.Add(time.Hour)}
+	if got, err := s.Reserve(ctx, "key", rec); got != nil || err != nil {
+		t.Fatalf("Reserve() got (%v, %v), want (%v, %v)", got, err, nil, nil)
+	}
+	if got, err := s.Reserve(ctx, "key", &Record{Fingerprint: "def"}); err != nil || got != rec {
+		t.Errorf("Reserve() got (%v, %v), want (%v, %v)", got, err, rec, nil)
+	}
+	now = now.Add(2 * time.Hour)
+	if got, err := s.Reserve(ctx, "key", &Record{Fingerprint: "def", ExpiresAt: now.Add(time.Hour)}); got != nil || err != nil {
+		t.Errorf("Reserve() after expiration got (%v, %v), want (%v, %v)", got, err, nil, nil)
+	}
+}
@@ -0,0 +1,61 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type InMemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*Record
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{
+		records: make(map[string]*Record),
+		now:     time.Now,
+	}
+}
+
+func (s *InMemoryStore) Reserve(ctx context.Context, key string, rec *Record) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	if existing, ok := s.records[key]; ok && now.Before(existing.ExpiresAt) {
+		return existing, nil
+	}
+	s.records[key] = rec
+	return nil, nil
+}
+
+func (s *InMemoryStore) Complete(ctx context.Context, key string, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = rec
+	return nil
+}
+
+func (s *InMemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *InMemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, rec := range s.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ranking"
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	calls           *prometheus.CounterVec
+	callDuration    *prometheus.HistogramVec
+	callErrors      *prometheus.CounterVec
+	uploadBytes     prometheus.Counter
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route and status class.",
+		}, []string{"route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route"}),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dependency_calls_total",
+			Help:      "Number of calls to DynamoDB and S3 by operation.",
+		}, []string{"dependency", "operation"}),
+		callDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "dependency_call_duration_seconds",
+			Help:      "Latency of calls to DynamoDB and S3 by operation.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"dependency", "operation"}),
+		callErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "dependency_errors_total",
+			Help:      "Number of failed calls to DynamoDB and S3 by operation.",
+		}, []string{"dependency", "operation"}),
+		uploadBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "upload_bytes_total",
+			Help:      "Number of bytes uploaded to the file storage.",
+		}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.requestDuration,
+		m.calls,
+		m.callDuration,
+		m.callErrors,
+		m.uploadBytes,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) InstrumentHandler(route string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		m.requestDuration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(route, statusClass(rw.status)).Inc()
+	})
+}
+
+func (m *Metrics) observe(dependency, operation string, start time.Time, err error) {
+	m.calls.WithLabelValues(dependency, operation).Inc()
+	m.callDuration.WithLabelValues(dependency, operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.callErrors.WithLabelValues(dependency, operation).Inc()
+	}
+}
+
+func statusClass(status int) string {
+	return strconv.Itoa(status/100) + "xx"
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/mock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	t.Run("InstrumentHandler", func(t *testing.T) {
+		route := "GET /rank/{id}"
+		h := m.InstrumentHandler(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/rank/1", nil))
+		if got := testutil.ToFloat64(m.requests.WithLabelValues(route, "4xx")); got != 1 {
+			t.Errorf("requests counter got %v, want %v", got, 1)
+		}
+		if got := testutil.CollectAndCount(m.requestDuration); got != 1 {
+			t.Errorf("request duration series got %v, want %v", got, 1)
+		}
+	})
+	t.Run("Handler", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		m.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+		}
+		want := `ranking_http_requests_total{route="GET /rank/{id}",status="4xx"} 1`
+		if body := rr.Body.String(); !strings.Contains(body, want) {
+			t.Errorf("handler body does not contain %v", want)
+		}
+	})
+}
+
+func TestRankMetricsRepository(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	r := NewRankMetricsRepository(&inmemory.RankInMemoryRepository{}, m)
+	rank := mock.Rank
+	if err := r.Create(ctx, &rank); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.FindById(ctx, rank.Id); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(m.calls.WithLabelValues(dynamodb, "rank.Create")); got != 1 {
+		t.Errorf("calls counter got %v, want %v", got, 1)
+	}
+	if got := testutil.ToFloat64(m.calls.WithLabelValues(dynamodb, "rank.FindById")); got != 1 {
+		t.Errorf("calls counter got %v, want %v", got, 1)
+	}
+	if got := testutil.ToFloat64(m.callErrors.WithLabelValues(dynamodb, "rank.Create")); got != 0 {
+		t.Errorf("errors counter got %v, want %v", got, 0)
+	}
+	inmemory.ClearDatabase()
+}
+
+func TestFileMetricsStorage(t *testing.T) {
+	ctx := context.Background()
+	m := New()
+	s := NewFileMetricsStorage(storage.NewInMemoryStorage(), "s3", m)
+	t.Run("Upload", func(t *testing.T) {
+		if _, err := s.Upload(ctx, "file/path.png", strings.NewReader("file content"), storage.UploadOptions{}); err != nil {
+			t.Fatal(err)
+		}
+		if got := testutil.ToFloat64(m.calls.WithLabelValues("s3", "Upload")); got != 1 {
+			t.Errorf("calls counter got %v, want %v", got, 1)
+		}
+		if got := testutil.ToFloat64(m.uploadBytes); got != 12 {
+			t.Errorf("upload bytes counter got %v, want %v", got, 12)
+		}
+	})
+}
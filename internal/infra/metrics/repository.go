@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/repository"
+)
+
+const dynamodb = "dynamodb"
+
+type RankMetricsRepository struct {
+	repo repository.RankRepository
+	m    *Metrics
+}
+
+func NewRankMetricsRepository(repo repository.RankRepository, m *Metrics) *RankMetricsRepository {
+	return &RankMetricsRepository{repo, m}
+}
+
+func (r *RankMetricsRepository) Create(ctx context.Context, rank *entity.Rank) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, rank)
+	r.m.observe(dynamodb, "rank.Create", start, err)
+	return err
+}
+
+func (r *RankMetricsRepository) FindById(ctx context.Context, id string) (*entity.Rank, error) {
+	start := time.Now()
+	rank, err := r.repo.FindById(ctx, id)
+	r.m.observe(dynamodb, "rank.FindById", start, err)
+	return rank, err
+}
+
+func (r *RankMetricsRepository) Update(ctx context.Context, rank *entity.Rank) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, rank)
+	r.m.observe(dynamodb, "rank.Update", start, err)
+	return err
+}
+
+func (r *RankMetricsRepository) Delete(ctx context.Context, rank *entity.Rank) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, rank)
+	r.m.observe(dynamodb, "rank.Delete", start, err)
+	return err
+}
+
+type AttributeMetricsRepository struct {
+	repo repository.AttributeRepository
+	m    *Metrics
+}
+
+func NewAttributeMetricsRepository(repo repository.AttributeRepository, m *Metrics) *AttributeMetricsRepository {
+	return &AttributeMetricsRepository{repo, m}
+}
+
+func (r *AttributeMetricsRepository) Create(ctx context.Context, attr *entity.Attribute) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, attr)
+	r.m.observe(dynamodb, "attribute.Create", start, err)
+	return err
+}
+
+func (r *AttributeMetricsRepository) FindById(ctx context.Context, rankId, id string) (*entity.Attribute, error) {
+	start := time.Now()
+	attr, err := r.repo.FindById(ctx, rankId, id)
+	r.m.observe(dynamodb, "attribute.FindById", start, err)
+	return attr, err
+}
+
+func (r *AttributeMetricsRepository) Update(ctx context.Context, attr *entity.Attribute) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, attr)
+	r.m.observe(dynamodb, "attribute.Update", start, err)
+	return err
+}
+
+func (r *AttributeMetricsRepository) Delete(ctx context.Context, attr *entity.Attribute) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, attr)
+	r.m.observe(dynamodb, "attribute.Delete", start, err)
+	return err
+}
+
+type EntryMetricsRepository struct {
+	repo repository.EntryRepository
+	m    *Metrics
+}
+
+func NewEntryMetricsRepository(repo repository.EntryRepository, m *Metrics) *EntryMetricsRepository {
+	return &EntryMetricsRepository{repo, m}
+}
+
+func (r *EntryMetricsRepository) Create(ctx context.Context, entry *entity.Entry) error {
+	start := time.Now()
+	err := r.repo.Create(ctx, entry)
+	r.m.observe(dynamodb, "entry.Create", start, err)
+	return err
+}
+
+func (r *EntryMetricsRepository) FindById(ctx context.Context, rankId, id string) (*entity.Entry, error) {
+	start := time.Now()
+	entry, err := r.repo.FindById(ctx, rankId, id)
+	r.m.observe(dynamodb, "entry.FindById", start, err)
+	return entry, err
+}
+
+func (r *EntryMetricsRepository) Update(ctx context.Context, entry *entity.Entry) error {
+	start := time.Now()
+	err := r.repo.Update(ctx, entry)
+	r.m.observe(dynamodb, "entry.Update", start, err)
+	return err
+}
+
+func (r *EntryMetricsRepository) Delete(ctx context.Context, entry *entity.Entry) error {
+	start := time.Now()
+	err := r.repo.Delete(ctx, entry)
+	r.m.observe(dynamodb, "entry.Delete", start, err)
+	return err
+}
+
+func (r *EntryMetricsRepository) UpdateScores(ctx context.Context, entries []*entity.Entry) error {
+	start := time.Now()
+	err := r.repo.UpdateScores(ctx, entries)
+	r.m.observe(dynamodb, "entry.UpdateScores", start, err)
+	return err
+}
+
+func (r *EntryMetricsRepository) UpdateImageURL(ctx context.Context, entry *entity.Entry, from string) error {
+	start := time.Now()
+	err := r.repo.UpdateImageURL(ctx, entry, from)
+	r.m.observe(dynamodb, "entry.UpdateImageURL", start, err)
+	return err
+}
+
+func (r *EntryMetricsRepository) UpdateMedia(ctx context.Context, entry *entity.Entry, from []entity.Media) error {
+	start := time.Now()
+	err := r.repo.UpdateMedia(ctx, entry, from)
+	r.m.observe(dynamodb, "entry.UpdateMedia", start, err)
+	return err
+}
+
+type RankTableMetricsRepository struct {
+	repo repository.RankTableRepository
+	m    *Metrics
+}
+
+func NewRankTableMetricsRepository(repo repository.RankTableRepository, m *Metrics) *RankTableMetricsRepository {
+	return &RankTableMetricsRepository{repo, m}
+}
+
+func (r *RankTableMetricsRepository) FindById(ctx context.Context, id string) (*entity.RankTable, error) {
+	start := time.Now()
+	table, err := r.repo.FindById(ctx, id)
+	r.m.observe(dynamodb, "ranktable.FindById", start, err)
+	return table, err
+}
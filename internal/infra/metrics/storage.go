@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+)
+
+type FileMetricsStorage struct {
+	storage storage.FileStorage
+	// dependency labels the calls with the backend behind storage, such as
+	// "s3" or "filesystem".
+	dependency string
+	m          *Metrics
+}
+
+func NewFileMetricsStorage(storage storage.FileStorage, dependency string, m *Metrics) *FileMetricsStorage {
+	return &FileMetricsStorage{storage, dependency, m}
+}
+
+func (s *FileMetricsStorage) Upload(ctx context.Context, path string, file io.Reader, opts storage.UploadOptions) (string, error) {
+	start := time.Now()
+	size, file := s.measure(file)
+	url, err := s.storage.Upload(ctx, path, file, opts)
+	s.m.observe(s.dependency, "Upload", start, err)
+	if err == nil {
+		s.m.uploadBytes.Add(float64(size()))
+	}
+	return url, err
+}
+
+func (s *FileMetricsStorage) Delete(ctx context.Context, path string) error {
+	start := time.Now()
+	err := s.storage.Delete(ctx, path)
+	s.m.observe(s.dependency, "Delete", start, err)
+	return err
+}
+
+func (s *FileMetricsStorage) List(ctx context.Context, prefix string) ([]storage.FileInfo, error) {
+	start := time.Now()
+	infos, err := s.storage.List(ctx, prefix)
+	s.m.observe(s.dependency, "List", start, err)
+	return infos, err
+}
+
+func (s *FileMetricsStorage) Stat(ctx context.Context, path string) (*storage.FileInfo, error) {
+	start := time.Now()
+	info, err := s.storage.Stat(ctx, path)
+	s.m.observe(s.dependency, "Stat", start, err)
+	return info, err
+}
+
+func (s *FileMetricsStorage) Open(ctx context.Context, path string) (io.ReadCloser, *storage.FileInfo, error) {
+	start := time.Now()
+	body, info, err := s.storage.Open(ctx, path)
+	s.m.observe(s.dependency, "Open", start, err)
+	return body, info, err
+}
+
+func (s *FileMetricsStorage) Touch(ctx context.Context, path string, private bool) error {
+	start := time.Now()
+	err := s.storage.Touch(ctx, path, private)
+	s.m.observe(s.dependency, "Touch", start, err)
+	return err
+}
+
+func (s *FileMetricsStorage) PathOf(url string) (string, bool) {
+	return s.storage.PathOf(url)
+}
+
+func (s *FileMetricsStorage) URLOf(path string) string {
+	return s.storage.URLOf(path)
+}
+
+func (*FileMetricsStorage) measure(file io.Reader) (func() int64, io.Reader) {
+	if seeker, ok := file.(io.Seeker); ok {
+		cur, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			end, err := seeker.Seek(0, io.SeekEnd)
+			if _, serr := seeker.Seek(cur, io.SeekStart); err == nil && serr == nil {
+				return func() int64 { return end - cur }, file
+			}
+		}
+	}
+	cr := &countingReader{r: file}
+	return func() int64 { return cr.n }, cr
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
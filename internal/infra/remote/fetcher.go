@@ -0,0 +1,173 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL       = errors.New("remote: invalid URL")
+	ErrForbiddenAddress = errors.New("remote: address not allowed")
+	ErrTooLarge         = errors.New("remote: response too large")
+	ErrUnavailable      = errors.New("remote: resource unavailable")
+	errTooManyRedirects = errors.New("too many redirects")
+)
+
+var (
+	// reservedPrefixes are not routable on the internet but, unlike the
+	// private ranges, not reported by netip.Addr.IsPrivate.
+	reservedPrefixes = []netip.Prefix{
+		netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+		netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+		netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+		netip.MustParsePrefix("240.0.0.0/4"),    // reserved
+		netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+		netip.MustParsePrefix("2001::/32"),      // Teredo
+	}
+	// nat64Prefix and sixToFourPrefix embed IPv4 addresses, which gateways
+	// reach on behalf of the client.
+	nat64Prefix     = netip.MustParsePrefix("64:ff9b::/96")
+	sixToFourPrefix = netip.MustParsePrefix("2002::/16")
+)
+
+type Config struct {
+	// Timeout bounds the whole fetch, from connecting to reading the body.
+	Timeout  time.Duration
+	MaxBytes int64
+	// MaxRedirects is the number of redirects followed.
+	MaxRedirects int
+	// AllowPrivate allows fetching from loopback and private addresses. It
+	// is meant for tests and local development only.
+	AllowPrivate bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Timeout:      10 * time.Second,
+		MaxBytes:     10 << 20,
+		MaxRedirects: 5,
+	}
+}
+
+// Fetcher downloads resources from URLs given by clients. Unless allowed by
+// its Config, it refuses to connect to addresses that are not publicly
+// routable, which is checked on every connection so that neither redirects
+// nor DNS answers can lead it to the private network.
+type Fetcher struct {
+	client *http.Client
+	cfg    Config
+}
+
+func NewFetcher(cfg Config) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if cfg.AllowPrivate {
+				return nil
+			}
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !isPublic(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// A proxy would make the dialer check the proxy instead of the
+		// resource.
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   cfg.Timeout,
+		ResponseHeaderTimeout: cfg.Timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > cfg.MaxRedirects {
+				return errTooManyRedirects
+			}
+			return checkURL(req.URL)
+		},
+	}
+	return &Fetcher{client, cfg}
+}
+
+// Fetch returns the body of a successful GET request to rawURL.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if err := checkURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	res, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrForbiddenAddress) || errors.Is(err, ErrInvalidURL) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: status %d", ErrUnavailable, res.StatusCode)
+	}
+	if res.ContentLength > f.cfg.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, f.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if int64(len(data)) > f.cfg.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+func checkURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// isPublic tells whether addr is routable on the internet. IPv6 addresses
+// that embed an IPv4 one are judged by the IPv4 address.
+func isPublic(addr netip.Addr) bool {
+	addr = embeddedIPv4(addr.Unmap())
+	return addr.IsGlobalUnicast() &&
+		!addr.IsPrivate() &&
+		!slices.ContainsFunc(reservedPrefixes, func(prefix netip.Prefix) bool {
+			return prefix.Contains(addr)
+		})
+}
+
+// embeddedIPv4 returns the IPv4 address embedded in NAT64 and 6to4
+// addresses, and addr itself otherwise.
+func embeddedIPv4(addr netip.Addr) netip.Addr {
+	b := addr.As16()
+	switch {
+	case nat64Prefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[12:16]))
+	case sixToFourPrefix.Contains(addr):
+		return netip.AddrFrom4([4]byte(b[2:6]))
+	}
+	return addr
+}
@@ -0,0 +1,96 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestFetcher(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png":
+			w.Write([]byte("image"))
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 64)))
+		case "/redirect":
+			http.Redirect(w, r, "/image.png", http.StatusFound)
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	cfg := DefaultConfig()
+	cfg.MaxBytes = 32
+	cfg.AllowPrivate = true
+	f := NewFetcher(cfg)
+	t.Run("Fetch", func(t *testing.T) {
+		for _, path := range []string{"/image.png", "/redirect"} {
+			url := srv.URL + path
+			if got, err := f.Fetch(ctx, url); err != nil || string(got) != "image" {
+				t.Errorf("Fetch(%v, %v) got (%q, %v), want (%q, %v)", ctx, url, got, err, "image", nil)
+			}
+		}
+	})
+	t.Run("errors", func(t *testing.T) {
+		for url, want := range map[string]error{
+			srv.URL + "/large":   ErrTooLarge,
+			srv.URL + "/missing": ErrUnavailable,
+			srv.URL + "/loop":    ErrUnavailable,
+			"ftp://example.com/": ErrInvalidURL,
+			"http://user:pass@" + strings.TrimPrefix(srv.URL, "http://") + "/image.png": ErrInvalidURL,
+			"/image.png": ErrInvalidURL,
+		} {
+			if got, err := f.Fetch(ctx, url); got != nil || !errors.Is(err, want) {
+				t.Errorf("Fetch(%v, %v) got (%q, %v), want (%v, %v)", ctx, url, got, err, nil, want)
+			}
+		}
+	})
+	t.Run("private", func(t *testing.T) {
+		f := NewFetcher(DefaultConfig())
+		url := srv.URL + "/image.png"
+		if got, err := f.Fetch(ctx, url); got != nil || !errors.Is(err, ErrForbiddenAddress) {
+			t.Errorf("Fetch(%v, %v) got (%q, %v), want (%v, %v)", ctx, url, got, err, nil, ErrForbiddenAddress)
+		}
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":          true,
+		"2606:2800:220:1::":      true,
+		"127.0.0.1":              false,
+		"10.0.0.1":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"::1":                    false,
+		"fd00::1":                false,
+		"fe80::1":                false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+		"198.18.0.1":             false,
+		"240.0.0.1":              false,
+		"64:ff9b::a9fe:a9fe":     false,
+		"64:ff9b::7f00:1":        false,
+		"64:ff9b::5db8:d822":     true,
+		"64:ff9b:1::5db8:d822":   false,
+		"2002:a9fe:a9fe::1":      false,
+		"2002:c0a8:101::":        false,
+		"2002:5db8:d822::1":      true,
+		"2001:0:4136:e378::1":    false,
+	} {
+		if got := isPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isPublic(%v) got %v, want %v", addr, got, want)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// FileSystemStorage keeps files under a root directory. The URLs it returns
+// are built from baseURL, so something must serve the root there, such as the
+// API's GET /files/ route, which must check the URLs of private files with
+// VerifyURL.
+type FileSystemStorage struct {
+	root    string
+	baseURL string
+	// key signs the URLs of private files.
+	key []byte
+}
+
+func NewFileSystemStorage(root, baseURL string, key []byte) *FileSystemStorage {
+	return &FileSystemStorage{root, strings.TrimSuffix(baseURL, "/") + "/", key}
+}
+
+func (s *FileSystemStorage) Upload(ctx context.Context, path string, file io.Reader, opts UploadOptions) (string, error) {
+	name, err := s.resolve(path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return "", err
+	}
+	// A private file is marked before it is written, so that it is never
+	// served publicly, and a public one unmarked after.
+	if opts.Private {
+		if err := setPrivate(name, true); err != nil {
+			return "", err
+		}
+	}
+	if err := writeFile(name, file); err != nil {
+		return "", err
+	}
+	if !opts.Private {
+		if err := setPrivate(name, false); err != nil {
+			return "", err
+		}
+	}
+	if len(opts.Metadata) == 0 {
+		if err := os.Remove(metadataName(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		return s.URLOf(path), nil
+	}
+	data, err := json.Marshal(opts.Metadata)
+	if err != nil {
+		return "", err
+	}
+	if err := writeFile(metadataName(name), bytes.NewReader(data)); err != nil {
+		return "", err
+	}
+	return s.URLOf(path), nil
+}
+
+func (s *FileSystemStorage) Delete(ctx context.Context, path string) error {
+	name, err := s.resolve(path)
+	if err != nil {
+		return nil
+	}
+	for _, name := range []string{name, metadataName(name), privateName(name)} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	// Directories only exist to hold files, so the ones left empty go too.
+	for dir := filepath.Dir(name); dir != filepath.Clean(s.root); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			break
+		}
+	}
+	return nil
+}
+
+func (s *FileSystemStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	var infos []FileInfo
+	err := filepath.WalkDir(s.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		path := filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Skip directories that cannot hold a match.
+			if path != "." && !strings.HasPrefix(path+"/", prefix) && !strings.HasPrefix(prefix, path+"/") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		// Hidden files hold metadata and uploads in progress.
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") || !strings.HasPrefix(path, prefix) {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		infos = append(infos, fileInfo(path, fi))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(infos, func(a, b FileInfo) int { return strings.Compare(a.Path, b.Path) })
+	return infos, nil
+}
+
+func (s *FileSystemStorage) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	name, err := s.resolve(path)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return nil, notExist(err)
+	}
+	if !fi.Mode().IsRegular() {
+		return nil, ErrNotFound
+	}
+	info := fileInfo(path, fi)
+	if info.Metadata, err = readMetadata(name); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Open returns an *os.File, so callers may seek the reader.
+func (s *FileSystemStorage) Open(ctx context.Context, path string) (io.ReadCloser, *FileInfo, error) {
+	name, err := s.resolve(path)
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, notExist(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if !fi.Mode().IsRegular() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+	info := fileInfo(path, fi)
+	if info.Metadata, err = readMetadata(name); err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, &info, nil
+}
+
+func (s *FileSystemStorage) Touch(ctx context.Context, path string, private bool) error {
+	if err := s.SetPrivate(ctx, path, private); err != nil {
+		return err
+	}
+	name, _ := s.resolve(path)
+	now := time.Now()
+	return notExist(os.Chtimes(name, now, now))
+}
+
+func (s *FileSystemStorage) SetPrivate(ctx context.Context, path string, private bool) error {
+	name, err := s.resolve(path)
+	if err != nil {
+		return ErrNotFound
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		return notExist(err)
+	}
+	if !fi.Mode().IsRegular() {
+		return ErrNotFound
+	}
+	return setPrivate(name, private)
+}
+
+// SignURL returns the URL of the file with its expiry and a signature of
+// both, which VerifyURL checks.
+func (s *FileSystemStorage) SignURL(ctx context.Context, path string, expires time.Duration) (string, error) {
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+	query := url.Values{
+		"expires":   {expiresAt},
+		"signature": {s.signature(path, expiresAt)},
+	}
+	return s.URLOf(path) + "?" + query.Encode(), nil
+}
+
+func (s *FileSystemStorage) VerifyURL(ctx context.Context, path string, query url.Values) (bool, error) {
+	name, err := s.resolve(path)
+	if err != nil {
+		return false, nil
+	}
+	_, err = os.Stat(privateName(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	expiresAt := query.Get("expires")
+	unix, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return true, ErrAccessDenied
+	}
+	signature, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return true, ErrAccessDenied
+	}
+	want, _ := hex.DecodeString(s.signature(path, expiresAt))
+	if !hmac.Equal(signature, want) {
+		return true, ErrAccessDenied
+	}
+	return true, nil
+}
+
+func (s *FileSystemStorage) signature(path, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(path + "\n" + expiresAt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *FileSystemStorage) PathOf(url string) (string, bool) {
+	url, _, _ = strings.Cut(url, "?")
+	path, ok := strings.CutPrefix(url, s.baseURL)
+	return path, ok && path != ""
+}
+
+func (s *FileSystemStorage) URLOf(path string) string {
+	return s.baseURL + path
+}
+
+// resolve returns the file name of path, rejecting paths that would escape
+// the root or reach hidden files, which hold metadata and uploads in progress.
+func (s *FileSystemStorage) resolve(path string) (string, error) {
+	if path == "" || strings.Contains(path, `\`) || !filepath.IsLocal(filepath.FromSlash(path)) {
+		return "", ErrInvalidPath
+	}
+	for segment := range strings.SplitSeq(path, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", ErrInvalidPath
+		}
+	}
+	return filepath.Join(s.root, filepath.FromSlash(path)), nil
+}
+
+// writeFile writes name so that readers never see it partially written: the
+// content goes to a temporary file renamed once complete.
+func writeFile(name string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// metadataName returns the name of the hidden file holding the metadata of
+// the file name.
+func metadataName(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".metadata.json")
+}
+
+// privateName returns the name of the hidden, empty, file marking the file
+// name as private.
+func privateName(name string) string {
+	return filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+".private")
+}
+
+func setPrivate(name string, private bool) error {
+	if !private {
+		if err := os.Remove(privateName(name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	f, err := os.OpenFile(privateName(name), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func readMetadata(name string) (map[string]string, error) {
+	data, err := os.ReadFile(metadataName(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var metadata map[string]string
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+func fileInfo(path string, fi fs.FileInfo) FileInfo {
+	return FileInfo{
+		Path:         path,
+		Size:         fi.Size(),
+		ContentType:  mime.TypeByExtension(filepath.Ext(path)),
+		LastModified: fi.ModTime().UTC(),
+	}
+}
+
+func notExist(err error) error {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return ErrNotFound
+	}
+	return err
+}
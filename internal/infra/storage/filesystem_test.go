@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileSystemStorage(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	storage := NewFileSystemStorage(root, "http://localhost:8080/files/", []byte("key"))
+	t.Run("Upload", func(t *testing.T) {
+		path := "file/path.png"
+		file := strings.NewReader("file content")
+		want := "http://localhost:8080/files/file/path.png"
+		if got, err := storage.Upload(ctx, path, file, UploadOptions{}); got != want || err != nil {
+			t.Errorf("Upload(%v, %v, %v) got (%v, %v), want (%v, %v)", ctx, path, file, got, err, want, nil)
+		}
+		if data, err := os.ReadFile(filepath.Join(root, "file", "path.png")); string(data) != "file content" || err != nil {
+			t.Errorf("ReadFile() got (%q, %v), want (%q, %v)", data, err, "file content", nil)
+		}
+		for _, path := range []string{"", "../escape.png", "/abs.png", "file/../../escape.png", `file\path.png`} {
+			if got, err := storage.Upload(ctx, path, strings.NewReader(""), UploadOptions{}); !errors.Is(err, ErrInvalidPath) {
+				t.Errorf("Upload(%v, %q) got (%v, %v), want (%v, %v)", ctx, path, got, err, "", ErrInvalidPath)
+			}
+		}
+		if err := storage.Delete(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(root, "file")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Stat() of emptied directory got %v, want %v", err, os.ErrNotExist)
+		}
+	})
+	t.Run("hidden", func(t *testing.T) {
+		opts := UploadOptions{Metadata: map[string]string{"sha256": "abc"}}
+		if _, err := storage.Upload(ctx, "rank/img/original.jpg", strings.NewReader("image"), opts); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{"rank/img/.original.jpg.metadata.json", "rank/.img/original.jpg", ".rank/img/original.jpg"} {
+			if f, _, err := storage.Open(ctx, path); !errors.Is(err, ErrNotFound) {
+				if f != nil {
+					f.Close()
+				}
+				t.Errorf("Open(%v, %v) got %v, want %v", ctx, path, err, ErrNotFound)
+			}
+			if _, err := storage.Stat(ctx, path); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Stat(%v, %v) got %v, want %v", ctx, path, err, ErrNotFound)
+			}
+		}
+		if err := storage.Delete(ctx, "rank/img/original.jpg"); err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("private", func(t *testing.T) {
+		path := "rank/img/private.jpg"
+		if _, err := storage.Upload(ctx, path, strings.NewReader("image"), UploadOptions{Private: true}); err != nil {
+			t.Fatal(err)
+		}
+		signed, err := storage.SignURL(ctx, path, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u, err := url.Parse(signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, ok := storage.PathOf(signed); !ok || got != path {
+			t.Errorf("PathOf(%v) got (%v, %v), want (%v, %v)", signed, got, ok, path, true)
+		}
+		tampered := u.Query()
+		tampered.Set("expires", "9999999999")
+		expired := u.Query()
+		expired.Set("expires", "1")
+		for _, tt := range []struct {
+			name  string
+			query url.Values
+			want  error
+		}{
+			{"signed", u.Query(), nil},
+			{"unsigned", url.Values{}, ErrAccessDenied},
+			{"tampered", tampered, ErrAccessDenied},
+			{"expired", expired, ErrAccessDenied},
+		} {
+			if private, err := storage.VerifyURL(ctx, path, tt.query); !private || !errors.Is(err, tt.want) {
+				t.Errorf("VerifyURL(%v, %v, %v) of a %v URL got (%v, %v), want (%v, %v)", ctx, path, tt.query, tt.name, private, err, true, tt.want)
+			}
+		}
+		if err := storage.SetPrivate(ctx, path, false); err != nil {
+			t.Fatal(err)
+		}
+		if private, err := storage.VerifyURL(ctx, path, url.Values{}); private || err != nil {
+			t.Errorf("VerifyURL(%v, %v) of a public file got (%v, %v), want (%v, %v)", ctx, path, private, err, false, nil)
+		}
+		if err := storage.SetPrivate(ctx, "rank/img/missing.jpg", true); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetPrivate(%v, %v) got %v, want %v", ctx, "rank/img/missing.jpg", err, ErrNotFound)
+		}
+		storage.SetPrivate(ctx, path, true)
+		if err := storage.Delete(ctx, path); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(root, "rank")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("Stat() of the directory of a deleted private file got %v, want %v", err, os.ErrNotExist)
+		}
+	})
+	t.Run("FileStorage", func(t *testing.T) {
+		testFileStorage(t, storage)
+	})
+}
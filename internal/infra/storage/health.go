@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type BucketHealthChecker struct {
+	client *s3.Client
+}
+
+func NewBucketHealthChecker(client *s3.Client) *BucketHealthChecker {
+	return &BucketHealthChecker{client}
+}
+
+func (c *BucketHealthChecker) Check(ctx context.Context) error {
+	input := &s3.HeadBucketInput{
+		Bucket: bucketName,
+	}
+	_, err := c.client.HeadBucket(ctx, input)
+	return err
+}
@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBucketHealthChecker(t *testing.T) {
+	ctx := context.Background()
+	c := NewBucketHealthChecker(client)
+	t.Run("Check", func(t *testing.T) {
+		if err := c.Check(ctx); err != nil {
+			t.Errorf("Check(%v) got %v, want %v", ctx, err, nil)
+		}
+	})
+}
@@ -1,19 +1,171 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+const inMemoryBaseURL = "http://fake-url/"
+
+type inMemoryFile struct {
+	data    []byte
+	info    FileInfo
+	private bool
+}
+
 type InMemoryStorage struct {
-	file io.Reader
+	mu    sync.Mutex
+	files map[string]*inMemoryFile
 }
 
 func NewInMemoryStorage() *InMemoryStorage {
-	return &InMemoryStorage{}
+	return &InMemoryStorage{files: make(map[string]*inMemoryFile)}
+}
+
+func (s *InMemoryStorage) Upload(ctx context.Context, path string, file io.Reader, opts UploadOptions) (string, error) {
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = &inMemoryFile{
+		data: data,
+		info: FileInfo{
+			Path:         path,
+			Size:         int64(len(data)),
+			ContentType:  mime.TypeByExtension(filepath.Ext(path)),
+			LastModified: time.Now().UTC(),
+			Metadata:     maps.Clone(opts.Metadata),
+		},
+		private: opts.Private,
+	}
+	return s.URLOf(path), nil
+}
+
+func (s *InMemoryStorage) Delete(ctx context.Context, path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, path)
+	return nil
+}
+
+func (s *InMemoryStorage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var infos []FileInfo
+	for _, path := range slices.Sorted(maps.Keys(s.files)) {
+		if strings.HasPrefix(path, prefix) {
+			info := s.files[path].info
+			info.Metadata = nil
+			infos = append(infos, info)
+		}
+	}
+	return infos, nil
+}
+
+func (s *InMemoryStorage) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[path]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	info := file.info
+	info.Metadata = maps.Clone(info.Metadata)
+	return &info, nil
+}
+
+func (s *InMemoryStorage) Open(ctx context.Context, path string) (io.ReadCloser, *FileInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[path]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	info := file.info
+	info.Metadata = maps.Clone(info.Metadata)
+	return io.NopCloser(bytes.NewReader(file.data)), &info, nil
+}
+
+func (s *InMemoryStorage) Touch(ctx context.Context, path string, private bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[path]
+	if !ok {
+		return ErrNotFound
+	}
+	file.info.LastModified = time.Now().UTC()
+	file.private = private
+	return nil
+}
+
+func (s *InMemoryStorage) PathOf(url string) (string, bool) {
+	url, _, _ = strings.Cut(url, "?")
+	path, ok := strings.CutPrefix(url, inMemoryBaseURL)
+	return path, ok && path != ""
+}
+
+func (s *InMemoryStorage) URLOf(path string) string {
+	return inMemoryBaseURL + path
+}
+
+// PresignUpload returns a fake request. Uploading through it is simulated by
+// calling Upload with the same path.
+func (s *InMemoryStorage) PresignUpload(ctx context.Context, path, contentType string, size int64, private bool, expires time.Duration) (*PresignedUpload, error) {
+	return &PresignedUpload{
+		Method:    http.MethodPut,
+		URL:       s.URLOf(path) + "?signature=fake",
+		Header:    http.Header{"Content-Type": {contentType}},
+		ExpiresAt: time.Now().Add(expires),
+	}, nil
+}
+
+func (s *InMemoryStorage) SetPrivate(ctx context.Context, path string, private bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[path]
+	if !ok {
+		return ErrNotFound
+	}
+	file.private = private
+	return nil
+}
+
+// IsPrivate tells whether the file at path was uploaded or set private.
+func (s *InMemoryStorage) IsPrivate(path string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[path]
+	return ok && file.private
+}
+
+// VerifyURL accepts the URLs returned by SignURL until they expire, without
+// checking any signature.
+func (s *InMemoryStorage) VerifyURL(ctx context.Context, path string, query url.Values) (bool, error) {
+	if !s.IsPrivate(path) {
+		return false, nil
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return true, ErrAccessDenied
+	}
+	return true, nil
 }
 
-func (s *InMemoryStorage) Upload(ctx context.Context, path string, file io.Reader) (string, error) {
-	s.file = file
-	return "http://fake-url/file.png", nil
+// SignURL returns a fake URL, which PathOf accepts.
+func (s *InMemoryStorage) SignURL(ctx context.Context, path string, expires time.Duration) (string, error) {
+	return fmt.Sprintf("%s?expires=%d", s.URLOf(path), time.Now().Add(expires).Unix()), nil
 }
@@ -12,9 +12,12 @@ func TestInMemoryStorage(t *testing.T) {
 	t.Run("Upload", func(t *testing.T) {
 		path := "file/path.png"
 		file := strings.NewReader("file content")
-		want := "http://fake-url/file.png"
-		if got, err := storage.Upload(ctx, path, file); got != want || err != nil {
+		want := "http://fake-url/file/path.png"
+		if got, err := storage.Upload(ctx, path, file, UploadOptions{}); got != want || err != nil {
 			t.Errorf("Upload(%v, %v, %v) got (%v, %v), want (%v, %v)", ctx, path, file, got, err, want, nil)
 		}
 	})
+	t.Run("FileStorage", func(t *testing.T) {
+		testFileStorage(t, storage)
+	})
 }
@@ -2,15 +2,22 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/josimarz/ranking-backend/internal/infra"
 )
 
@@ -26,26 +33,33 @@ func init() {
 
 func NewS3Client(ctx context.Context) (*s3.Client, error) {
 	if infra.IsRunningOnLambda() {
-		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"))
+		cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("us-east-1"), config.WithHTTPClient(infra.NewHTTPClient()))
 		if err != nil {
 			return nil, err
 		}
+		infra.InstrumentConfig(&cfg)
 		return s3.NewFromConfig(cfg), nil
 	}
 	cfg, err := config.LoadDefaultConfig(ctx, func(lo *config.LoadOptions) error {
 		lo.Region = "us-east-1"
 		lo.Credentials = credentials.NewStaticCredentialsProvider("xyz", "123", "")
+		lo.HTTPClient = infra.NewHTTPClient()
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
+	infra.InstrumentConfig(&cfg)
 	return s3.NewFromConfig(cfg, func(o *s3.Options) {
 		o.BaseEndpoint = aws.String(infra.EndpointURL())
 		o.UsePathStyle = true
 	}), nil
 }
 
+func CloseS3Client(client *s3.Client) {
+	infra.CloseHTTPClient(client.Options().HTTPClient)
+}
+
 type FileS3Storage struct {
 	client *s3.Client
 }
@@ -54,23 +68,199 @@ func NewFileS3Storage(client *s3.Client) *FileS3Storage {
 	return &FileS3Storage{client}
 }
 
-func (s *FileS3Storage) Upload(ctx context.Context, path string, file io.Reader) (string, error) {
+func (s *FileS3Storage) Upload(ctx context.Context, path string, file io.Reader, opts UploadOptions) (string, error) {
 	input := &s3.PutObjectInput{
+		Bucket:   bucketName,
+		Key:      aws.String(path),
+		Body:     file,
+		ACL:      objectACL(opts.Private),
+		Metadata: opts.Metadata,
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return "", err
+	}
+	return s.URLOf(path), nil
+}
+
+func (s *FileS3Storage) Delete(ctx context.Context, path string) error {
+	input := &s3.DeleteObjectInput{
 		Bucket: bucketName,
 		Key:    aws.String(path),
-		Body:   file,
-		ACL:    types.ObjectCannedACLPublicRead,
 	}
-	if _, err := s.client.PutObject(ctx, input); err != nil {
+	_, err := s.client.DeleteObject(ctx, input)
+	return err
+}
+
+func (s *FileS3Storage) List(ctx context.Context, prefix string) ([]FileInfo, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket: bucketName,
+		Prefix: aws.String(prefix),
+	}
+	var infos []FileInfo
+	for p := s3.NewListObjectsV2Paginator(s.client, input); p.HasMorePages(); {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			infos = append(infos, FileInfo{
+				Path:         aws.ToString(obj.Key),
+				Size:         aws.ToInt64(obj.Size),
+				LastModified: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return infos, nil
+}
+
+func (s *FileS3Storage) Stat(ctx context.Context, path string) (*FileInfo, error) {
+	input := &s3.HeadObjectInput{
+		Bucket: bucketName,
+		Key:    aws.String(path),
+	}
+	output, err := s.client.HeadObject(ctx, input)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &FileInfo{
+		Path:         path,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		LastModified: aws.ToTime(output.LastModified),
+		Metadata:     output.Metadata,
+	}, nil
+}
+
+func (s *FileS3Storage) Open(ctx context.Context, path string) (io.ReadCloser, *FileInfo, error) {
+	input := &s3.GetObjectInput{
+		Bucket: bucketName,
+		Key:    aws.String(path),
+	}
+	output, err := s.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, nil, notFound(err)
+	}
+	return output.Body, &FileInfo{
+		Path:         path,
+		Size:         aws.ToInt64(output.ContentLength),
+		ContentType:  aws.ToString(output.ContentType),
+		LastModified: aws.ToTime(output.LastModified),
+		Metadata:     output.Metadata,
+	}, nil
+}
+
+// Touch copies the object onto itself, which S3 only allows when replacing
+// its metadata, so the metadata and content type are read first.
+func (s *FileS3Storage) Touch(ctx context.Context, path string, private bool) error {
+	info, err := s.Stat(ctx, path)
+	if err != nil {
+		return err
+	}
+	input := &s3.CopyObjectInput{
+		Bucket:            bucketName,
+		Key:               aws.String(path),
+		CopySource:        aws.String(*bucketName + "/" + url.PathEscape(path)),
+		MetadataDirective: types.MetadataDirectiveReplace,
+		Metadata:          info.Metadata,
+		ACL:               objectACL(private),
+	}
+	if info.ContentType != "" {
+		input.ContentType = aws.String(info.ContentType)
+	}
+	_, err = s.client.CopyObject(ctx, input)
+	return notFound(err)
+}
+
+func (s *FileS3Storage) PathOf(url string) (string, bool) {
+	url, _, _ = strings.Cut(url, "?")
+	for _, base := range []string{s.URLOf(""), signedURLBase()} {
+		if path, ok := strings.CutPrefix(url, base); ok && path != "" {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func (s *FileS3Storage) SetPrivate(ctx context.Context, path string, private bool) error {
+	input := &s3.PutObjectAclInput{
+		Bucket: bucketName,
+		Key:    aws.String(path),
+		ACL:    objectACL(private),
+	}
+	_, err := s.client.PutObjectAcl(ctx, input)
+	return notFound(err)
+}
+
+func (s *FileS3Storage) SignURL(ctx context.Context, path string, expires time.Duration) (string, error) {
+	input := &s3.GetObjectInput{
+		Bucket: bucketName,
+		Key:    aws.String(path),
+	}
+	req, err := s3.NewPresignClient(s.client).PresignGetObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
 		return "", err
 	}
-	return s.buildURL(path), nil
+	return req.URL, nil
 }
 
-func (*FileS3Storage) buildURL(path string) string {
+// PresignUpload presigns a PutObject request. The size and content type are
+// signed, so S3 rejects uploads that do not match them.
+func (s *FileS3Storage) PresignUpload(ctx context.Context, path, contentType string, size int64, private bool, expires time.Duration) (*PresignedUpload, error) {
+	input := &s3.PutObjectInput{
+		Bucket:        bucketName,
+		Key:           aws.String(path),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(size),
+		ACL:           objectACL(private),
+	}
+	req, err := s3.NewPresignClient(s.client).PresignPutObject(ctx, input, s3.WithPresignExpires(expires))
+	if err != nil {
+		return nil, err
+	}
+	header := req.SignedHeader.Clone()
+	// The client sets Host from the URL.
+	header.Del("Host")
+	return &PresignedUpload{
+		Method:    req.Method,
+		URL:       req.URL,
+		Header:    header,
+		ExpiresAt: time.Now().Add(expires),
+	}, nil
+}
+
+func objectACL(private bool) types.ObjectCannedACL {
+	if private {
+		return types.ObjectCannedACLPrivate
+	}
+	return types.ObjectCannedACLPublicRead
+}
+
+// notFound turns the errors S3 returns for missing keys into ErrNotFound.
+func notFound(err error) error {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && (apiErr.ErrorCode() == "NotFound" || apiErr.ErrorCode() == "NoSuchKey") {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (*FileS3Storage) URLOf(path string) string {
 	if infra.IsRunningOnLambda() {
 		region := os.Getenv("AWS_REGION")
 		return fmt.Sprintf("https://%s.s3-%s.amazonaws.com/%s", *bucketName, region, path)
 	}
 	return fmt.Sprintf("%s/%s/%s", infra.EndpointURL(), *bucketName, path)
 }
+
+// signedURLBase is how URLs signed by the SDK start. On AWS they use a
+// different host than URLOf.
+func signedURLBase() string {
+	if infra.IsRunningOnLambda() {
+		region := os.Getenv("AWS_REGION")
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", *bucketName, region)
+	}
+	return fmt.Sprintf("%s/%s/", infra.EndpointURL(), *bucketName)
+}
@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestFileS3Storage(t *testing.T) {
@@ -13,8 +16,64 @@ func TestFileS3Storage(t *testing.T) {
 		file := strings.NewReader("file content")
 		path := "file/path.png"
 		want := "http://localhost:4566/ranking/file/path.png"
-		if got, err := storage.Upload(ctx, path, file); err != nil || got != want {
+		if got, err := storage.Upload(ctx, path, file, UploadOptions{}); err != nil || got != want {
 			t.Errorf("Upload(%v, %v, %v) got (%v, %v), want (%v, %v)", ctx, path, file, got, err, want, nil)
 		}
 	})
+	t.Run("FileStorage", func(t *testing.T) {
+		testFileStorage(t, storage)
+	})
+	t.Run("PresignUpload", func(t *testing.T) {
+		path := "presigned/original.png"
+		content := "file content"
+		upload, err := storage.PresignUpload(ctx, path, "image/png", int64(len(content)), false, time.Minute)
+		if err != nil {
+			t.Fatalf("PresignUpload(%v, %v) got %v, want %v", ctx, path, err, nil)
+		}
+		req, err := http.NewRequestWithContext(ctx, upload.Method, upload.URL, strings.NewReader(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = upload.Header.Clone()
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("presigned upload got status %v, want %v", res.StatusCode, http.StatusOK)
+		}
+		if info, err := storage.Stat(ctx, path); err != nil || info.Size != int64(len(content)) || info.ContentType != "image/png" {
+			t.Errorf("Stat(%v, %v) got (%+v, %v)", ctx, path, info, err)
+		}
+		storage.Delete(ctx, path)
+	})
+	t.Run("SignURL", func(t *testing.T) {
+		path := "private/original.png"
+		if _, err := storage.Upload(ctx, path, strings.NewReader("file content"), UploadOptions{Private: true}); err != nil {
+			t.Fatal(err)
+		}
+		defer storage.Delete(ctx, path)
+		url, err := storage.SignURL(ctx, path, time.Minute)
+		if err != nil {
+			t.Fatalf("SignURL(%v, %v) got %v, want %v", ctx, path, err, nil)
+		}
+		if got, ok := storage.PathOf(url); !ok || got != path {
+			t.Errorf("PathOf(%v) got (%v, %v), want (%v, %v)", url, got, ok, path, true)
+		}
+		res, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("GET %v got status %v, want %v", url, res.StatusCode, http.StatusOK)
+		}
+		if err := storage.SetPrivate(ctx, path, false); err != nil {
+			t.Errorf("SetPrivate(%v, %v, %v) got %v, want %v", ctx, path, false, err, nil)
+		}
+		if err := storage.SetPrivate(ctx, "private/missing.png", false); !errors.Is(err, ErrNotFound) {
+			t.Errorf("SetPrivate(%v, %v, %v) got %v, want %v", ctx, "private/missing.png", false, err, ErrNotFound)
+		}
+	})
 }
@@ -2,9 +2,101 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+	"net/http"
+	"net/url"
+	"time"
 )
 
+var (
+	ErrNotFound = errors.New("file not found")
+	// ErrInvalidPath is returned for paths that are empty or point outside
+	// the storage.
+	ErrInvalidPath = errors.New("invalid file path")
+	// ErrAccessDenied is returned for private files read without a valid
+	// signed URL.
+	ErrAccessDenied = errors.New("access denied")
+)
+
+type FileInfo struct {
+	Path         string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	// Metadata holds what was given to Upload. List leaves it empty.
+	Metadata map[string]string
+}
+
+type UploadOptions struct {
+	// Metadata is stored along with the file. Its keys are lowercase.
+	Metadata map[string]string
+	// Private keeps the file from being read through its URL. Storages that
+	// do not implement Signer ignore it.
+	Private bool
+}
+
 type FileStorage interface {
-	Upload(context.Context, string, io.Reader) (string, error)
+	// Upload stores file at path and returns its URL.
+	Upload(ctx context.Context, path string, file io.Reader, opts UploadOptions) (string, error)
+	// Delete removes the file at path. Deleting a missing file is not an
+	// error.
+	Delete(ctx context.Context, path string) error
+	// List returns the files whose path starts with prefix, sorted by path.
+	List(ctx context.Context, prefix string) ([]FileInfo, error)
+	// Stat returns ErrNotFound when there is no file at path.
+	Stat(ctx context.Context, path string) (*FileInfo, error)
+	// Open returns ErrNotFound when there is no file at path. The caller
+	// must close the returned reader.
+	Open(ctx context.Context, path string) (io.ReadCloser, *FileInfo, error)
+	// PathOf returns the path of the file a URL returned by Upload or
+	// Signer.SignURL points to, and false for URLs that do not belong to this
+	// storage.
+	PathOf(url string) (string, bool)
+	// URLOf returns the URL of the file at path, the one Upload returns.
+	URLOf(path string) string
+	// Touch sets the last modification time of the file at path to now,
+	// keeping its content and metadata, and makes it private as with
+	// UploadOptions.Private. It returns ErrNotFound when there is no file at
+	// path.
+	Touch(ctx context.Context, path string, private bool) error
+}
+
+// PresignedUpload is a request a client can send to upload a file without
+// going through the API.
+type PresignedUpload struct {
+	Method string
+	URL    string
+	// Header holds the headers the client must send with the values given,
+	// as they are part of the signature.
+	Header    http.Header
+	ExpiresAt time.Time
+}
+
+// Presigner is implemented by storages that accept uploads straight from
+// clients.
+type Presigner interface {
+	// PresignUpload allows uploading a file of exactly size bytes and of the
+	// given content type to path until expires has passed.
+	PresignUpload(ctx context.Context, path, contentType string, size int64, private bool, expires time.Duration) (*PresignedUpload, error)
+}
+
+// Signer is implemented by storages that can keep files private and grant
+// temporary access to them.
+type Signer interface {
+	// SetPrivate changes whether the file at path can be read through the
+	// URL returned by Upload.
+	SetPrivate(ctx context.Context, path string, private bool) error
+	// SignURL returns a URL that reads the file at path, private or not,
+	// until expires has passed.
+	SignURL(ctx context.Context, path string, expires time.Duration) (string, error)
+}
+
+// URLVerifier is implemented by storages whose files the API serves, which
+// must then check the URLs signed for private files.
+type URLVerifier interface {
+	// VerifyURL tells whether the file at path is private, and returns
+	// ErrAccessDenied when it is and query does not hold the signature of a
+	// URL returned by SignURL that has not expired.
+	VerifyURL(ctx context.Context, path string, query url.Values) (bool, error)
 }
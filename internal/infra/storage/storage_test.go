@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFileStorage checks the FileStorage methods beyond Upload, which every
+// implementation must behave the same way for.
+func testFileStorage(t *testing.T, s FileStorage) {
+	ctx := context.Background()
+	urls := make(map[string]string)
+	for _, path := range []string{"rank-1/img/thumbnail.jpg", "rank-1/img/original.jpg", "rank-2/img/original.jpg"} {
+		url, err := s.Upload(ctx, path, strings.NewReader("content of "+path), UploadOptions{Metadata: map[string]string{"name": path}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		urls[path] = url
+	}
+	t.Run("PathOf", func(t *testing.T) {
+		for path, url := range urls {
+			if got, ok := s.PathOf(url); !ok || got != path {
+				t.Errorf("PathOf(%v) got (%v, %v), want (%v, %v)", url, got, ok, path, true)
+			}
+			if got := s.URLOf(path); got != url {
+				t.Errorf("URLOf(%v) got %v, want %v", path, got, url)
+			}
+		}
+		if got, ok := s.PathOf("https://example.com/rank-1/img/original.jpg"); ok {
+			t.Errorf("PathOf() of a foreign URL got (%v, %v), want (%v, %v)", got, ok, "", false)
+		}
+	})
+	t.Run("Stat", func(t *testing.T) {
+		path := "rank-1/img/original.jpg"
+		info, err := s.Stat(ctx, path)
+		if err != nil || info.Path != path || info.Size != int64(len("content of "+path)) || info.ContentType != "image/jpeg" || info.Metadata["name"] != path {
+			t.Errorf("Stat(%v, %v) got (%+v, %v)", ctx, path, info, err)
+		}
+		if _, err := s.Stat(ctx, "rank-1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Stat(%v, %v) got %v, want %v", ctx, "rank-1/missing.jpg", err, ErrNotFound)
+		}
+	})
+	t.Run("Open", func(t *testing.T) {
+		path := "rank-1/img/thumbnail.jpg"
+		body, info, err := s.Open(ctx, path)
+		if err != nil {
+			t.Fatalf("Open(%v, %v) got %v, want %v", ctx, path, err, nil)
+		}
+		defer body.Close()
+		if data, _ := io.ReadAll(body); string(data) != "content of "+path || info.Path != path || info.Metadata["name"] != path {
+			t.Errorf("Open(%v, %v) got %q, %+v", ctx, path, data, info)
+		}
+		if _, _, err := s.Open(ctx, "rank-1/missing.jpg"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%v, %v) got %v, want %v", ctx, "rank-1/missing.jpg", err, ErrNotFound)
+		}
+	})
+	t.Run("Touch", func(t *testing.T) {
+		path := "rank-2/img/original.jpg"
+		before, err := s.Stat(ctx, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Second)
+		if err := s.Touch(ctx, path, false); err != nil {
+			t.Fatalf("Touch(%v, %v, %v) got %v, want %v", ctx, path, false, err, nil)
+		}
+		info, err := s.Stat(ctx, path)
+		if err != nil || !info.LastModified.After(before.LastModified) || info.ContentType != "image/jpeg" || info.Metadata["name"] != path {
+			t.Errorf("Stat(%v, %v) after Touch got (%+v, %v), want modified after %v", ctx, path, info, err, before.LastModified)
+		}
+		if err := s.Touch(ctx, "rank-1/missing.jpg", false); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Touch(%v, %v, %v) got %v, want %v", ctx, "rank-1/missing.jpg", false, err, ErrNotFound)
+		}
+	})
+	t.Run("List", func(t *testing.T) {
+		infos, err := s.List(ctx, "rank-1/")
+		if err != nil || len(infos) != 2 || infos[0].Path != "rank-1/img/original.jpg" || infos[1].Path != "rank-1/img/thumbnail.jpg" {
+			t.Errorf("List(%v, %v) got (%+v, %v)", ctx, "rank-1/", infos, err)
+		}
+	})
+	t.Run("Delete", func(t *testing.T) {
+		for path := range urls {
+			if err := s.Delete(ctx, path); err != nil {
+				t.Errorf("Delete(%v, %v) got %v, want %v", ctx, path, err, nil)
+			}
+		}
+		if err := s.Delete(ctx, "rank-1/missing.jpg"); err != nil {
+			t.Errorf("Delete(%v, %v) got %v, want %v", ctx, "rank-1/missing.jpg", err, nil)
+		}
+		if infos, err := s.List(ctx, "rank-"); err != nil || len(infos) != 0 {
+			t.Errorf("List(%v, %v) after Delete got (%+v, %v)", ctx, "rank-", infos, err)
+		}
+	})
+}
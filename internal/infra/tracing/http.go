@@ -0,0 +1,11 @@
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+func InstrumentHandler(route string, next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, route)
+}
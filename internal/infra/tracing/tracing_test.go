@@ -0,0 +1,122 @@
+package tracing
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/web/handler"
+	"github.com/josimarz/ranking-backend/internal/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTest(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := NewTracerProvider(exporter)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	exporter := setupTest(t)
+	ctx := context.Background()
+	inmemory.ClearDatabase()
+	rank := mock.Rank
+	repo := &inmemory.RankInMemoryRepository{}
+	repo.Create(ctx, &rank)
+	uc := NewTracedUsecase(usecase.NewFindRankUsecase(repo))
+	h := InstrumentHandler("GET /rank/{id}", handler.NewGetRankHandler(slog.New(slog.DiscardHandler), uc))
+	req := httptest.NewRequest("GET", "/rank/"+rank.Id, nil)
+	req.SetPathValue("id", rank.Id)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %v spans, want %v", len(spans), 2)
+	}
+	ucSpan, httpSpan := spans[0], spans[1]
+	if want := "usecase.FindRankUsecase.Execute"; ucSpan.Name != want {
+		t.Errorf("usecase span name got %v, want %v", ucSpan.Name, want)
+	}
+	if want := "GET /rank/{id}"; httpSpan.Name != want {
+		t.Errorf("http span name got %v, want %v", httpSpan.Name, want)
+	}
+	if ucSpan.Parent.SpanID() != httpSpan.SpanContext.SpanID() {
+		t.Error("usecase span is not a child of the http span")
+	}
+	if got, want := httpSpan.SpanContext.TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736"; got != want {
+		t.Errorf("trace id was not propagated: got %v, want %v", got, want)
+	}
+	inmemory.ClearDatabase()
+}
+
+func TestTracedUsecase(t *testing.T) {
+	exporter := setupTest(t)
+	ctx := context.Background()
+	uc := NewTracedUsecase(usecase.NewFindRankUsecase(&inmemory.RankInMemoryRepository{}))
+	input := usecase.FindRankInput{Id: "7c8c2c5d-4f59-43c5-a7b4-1fd1f8f7e2a5"}
+	if _, err := uc.Execute(ctx, input); err == nil {
+		t.Fatal("Execute() expected a not found error")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || len(spans[0].Events) != 1 || spans[0].Status.Description == "" {
+		t.Errorf("error was not recorded on span: %v", spans)
+	}
+}
+
+func TestInstrumentConfig(t *testing.T) {
+	exporter := setupTest(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("xyz", "123", ""),
+	}
+	infra.InstrumentConfig(&cfg)
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		o.BaseEndpoint = aws.String(srv.URL)
+	})
+	ctx, span := otel.Tracer(tracerName).Start(context.Background(), "parent")
+	_, err := client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String("rank"),
+		Key: map[string]types.AttributeValue{
+			"id": &types.AttributeValueMemberS{Value: "1"},
+		},
+	})
+	span.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("got %v spans, want %v", len(spans), 2)
+	}
+	if want := "DynamoDB.GetItem"; spans[0].Name != want {
+		t.Errorf("aws span name got %v, want %v", spans[0].Name, want)
+	}
+	if spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() {
+		t.Error("aws span is not a child of the parent span")
+	}
+}
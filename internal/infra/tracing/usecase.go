@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+type TracedUsecase[I any, O any] struct {
+	name string
+	uc   usecase.Usecase[I, O]
+}
+
+func NewTracedUsecase[I any, O any](uc usecase.Usecase[I, O]) *TracedUsecase[I, O] {
+	name := strings.TrimPrefix(fmt.Sprintf("%T", uc), "*")
+	return &TracedUsecase[I, O]{name, uc}
+}
+
+func (t *TracedUsecase[I, O]) Execute(ctx context.Context, input I) (*O, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, t.name+".Execute")
+	defer span.End()
+	output, err := t.uc.Execute(ctx, input)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return output, err
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -12,10 +11,10 @@ import (
 
 type PostAttributeHandler struct {
 	baseHandler
-	uc *usecase.CreateAttributeUsecase
+	uc usecase.Usecase[usecase.CreateAttributeInput, usecase.CreateAttributeOutput]
 }
 
-func NewPostAttributeHandler(logger *slog.Logger, uc *usecase.CreateAttributeUsecase) *PostAttributeHandler {
+func NewPostAttributeHandler(logger *slog.Logger, uc usecase.Usecase[usecase.CreateAttributeInput, usecase.CreateAttributeOutput]) *PostAttributeHandler {
 	return &PostAttributeHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -41,7 +40,7 @@ func (h *PostAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 	output, err := h.uc.Execute(r.Context(), attr)
 	if err != nil {
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusCreated, output, nil); err != nil {
@@ -51,10 +50,10 @@ func (h *PostAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 type GetAttributeHandler struct {
 	baseHandler
-	uc *usecase.FindAttributeUsecase
+	uc usecase.Usecase[usecase.FindAttributeInput, usecase.FindAttributeOutput]
 }
 
-func NewGetAttributeHandler(logger *slog.Logger, uc *usecase.FindAttributeUsecase) *GetAttributeHandler {
+func NewGetAttributeHandler(logger *slog.Logger, uc usecase.Usecase[usecase.FindAttributeInput, usecase.FindAttributeOutput]) *GetAttributeHandler {
 	return &GetAttributeHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -68,12 +67,7 @@ func (h *GetAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -83,10 +77,10 @@ func (h *GetAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 type PutAttributeHandler struct {
 	baseHandler
-	uc *usecase.UpdateAttributeUsecase
+	uc usecase.Usecase[usecase.UpdateAttributeInput, usecase.UpdateAttributeOutput]
 }
 
-func NewPutAttributeHandler(logger *slog.Logger, uc *usecase.UpdateAttributeUsecase) *PutAttributeHandler {
+func NewPutAttributeHandler(logger *slog.Logger, uc usecase.Usecase[usecase.UpdateAttributeInput, usecase.UpdateAttributeOutput]) *PutAttributeHandler {
 	return &PutAttributeHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -119,12 +113,40 @@ func (h *PutAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 	output, err := h.uc.Execute(r.Context(), attr)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
 		h.serverErrorResponse(w, r, err)
+	}
+}
+
+type PatchAttributeHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.PatchAttributeInput, usecase.PatchAttributeOutput]
+}
+
+func NewPatchAttributeHandler(logger *slog.Logger, uc usecase.Usecase[usecase.PatchAttributeInput, usecase.PatchAttributeOutput]) *PatchAttributeHandler {
+	return &PatchAttributeHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PatchAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	patch, err := h.readMergePatch(w, r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	input := usecase.PatchAttributeInput{
+		RankId: r.PathValue("rankId"),
+		Id:     r.PathValue("id"),
+		Patch:  patch,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -134,10 +156,10 @@ func (h *PutAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 type DeleteAttributeHandler struct {
 	baseHandler
-	uc *usecase.DeleteAttributeUsecase
+	uc usecase.Usecase[usecase.DeleteAttributeInput, usecase.DeleteAttributeOutput]
 }
 
-func NewDeleteAttributeHandler(logger *slog.Logger, uc *usecase.DeleteAttributeUsecase) *DeleteAttributeHandler {
+func NewDeleteAttributeHandler(logger *slog.Logger, uc usecase.Usecase[usecase.DeleteAttributeInput, usecase.DeleteAttributeOutput]) *DeleteAttributeHandler {
 	return &DeleteAttributeHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -150,12 +172,7 @@ func (h *DeleteAttributeHandler) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		Id:     r.PathValue("id"),
 	}
 	if _, err := h.uc.Execute(r.Context(), input); err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	data := map[string]any{
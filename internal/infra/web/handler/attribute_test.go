@@ -110,7 +110,7 @@ func TestGetAttributeHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong satus code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"attribute not found: f68f93e2-1cae-4382-b94a-9dc84ae60db8"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"attribute not found: f68f93e2-1cae-4382-b94a-9dc84ae60db8","instance":"/rank/%7BrankId%7D/attribute/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -181,7 +181,7 @@ func TestPutAttributeHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"attribute not found: 2908fdf0-a9c3-4a9f-a539-2b49ec9ad8cf"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"attribute not found: 2908fdf0-a9c3-4a9f-a539-2b49ec9ad8cf","instance":"/rank/%7BrankId%7D/attribute/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -242,7 +242,7 @@ func TestDeleteAttributeHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"attribute not found: be44503b-1fac-4d5a-aae0-0239159bdc4a"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"attribute not found: be44503b-1fac-4d5a-aae0-0239159bdc4a","instance":"/rank/%7BrankId%7D/attribute/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
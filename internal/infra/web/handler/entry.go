@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -12,10 +11,10 @@ import (
 
 type PostEntryHandler struct {
 	baseHandler
-	uc *usecase.CreateEntryUsecase
+	uc usecase.Usecase[usecase.CreateEntryInput, usecase.CreateEntryOutput]
 }
 
-func NewPostEntryHandler(logger *slog.Logger, uc *usecase.CreateEntryUsecase) *PostEntryHandler {
+func NewPostEntryHandler(logger *slog.Logger, uc usecase.Usecase[usecase.CreateEntryInput, usecase.CreateEntryOutput]) *PostEntryHandler {
 	return &PostEntryHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -24,9 +23,10 @@ func NewPostEntryHandler(logger *slog.Logger, uc *usecase.CreateEntryUsecase) *P
 
 func (h *PostEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Name     string        `json:"name"`
-		ImageURL string        `json:"image_url"`
-		Scores   entity.Scores `json:"scores"`
+		Name        string        `json:"name"`
+		ImageURL    string        `json:"image_url"`
+		Scores      entity.Scores `json:"scores"`
+		ImportImage bool          `json:"import_image"`
 	}
 	if err := h.readJSON(w, r, &body); err != nil {
 		h.badRequestResponse(w, r, err)
@@ -39,9 +39,10 @@ func (h *PostEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.failedValidationResponse(w, r, v.Errors())
 		return
 	}
-	output, err := h.uc.Execute(r.Context(), entry)
+	input := usecase.CreateEntryInput{Entry: entry, ImportImage: body.ImportImage}
+	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusCreated, output, nil); err != nil {
@@ -51,10 +52,10 @@ func (h *PostEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type GetEntryHandler struct {
 	baseHandler
-	uc *usecase.FindEntryUsecase
+	uc usecase.Usecase[usecase.FindEntryInput, usecase.FindEntryOutput]
 }
 
-func NewGetEntryHandler(logger *slog.Logger, uc *usecase.FindEntryUsecase) *GetEntryHandler {
+func NewGetEntryHandler(logger *slog.Logger, uc usecase.Usecase[usecase.FindEntryInput, usecase.FindEntryOutput]) *GetEntryHandler {
 	return &GetEntryHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -68,12 +69,7 @@ func (h *GetEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -83,10 +79,10 @@ func (h *GetEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type PutEntryHandler struct {
 	baseHandler
-	uc *usecase.UpdateEntryUsecase
+	uc usecase.Usecase[usecase.UpdateEntryInput, usecase.UpdateEntryOutput]
 }
 
-func NewPutEntryHandler(logger *slog.Logger, uc *usecase.UpdateEntryUsecase) *PutEntryHandler {
+func NewPutEntryHandler(logger *slog.Logger, uc usecase.Usecase[usecase.UpdateEntryInput, usecase.UpdateEntryOutput]) *PutEntryHandler {
 	return &PutEntryHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -95,9 +91,10 @@ func NewPutEntryHandler(logger *slog.Logger, uc *usecase.UpdateEntryUsecase) *Pu
 
 func (h *PutEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var body struct {
-		Name     string        `json:"name"`
-		ImageURL string        `json:"image_url"`
-		Scores   entity.Scores `json:"scores"`
+		Name        string        `json:"name"`
+		ImageURL    string        `json:"image_url"`
+		Scores      entity.Scores `json:"scores"`
+		ImportImage bool          `json:"import_image"`
 	}
 	if err := h.readJSON(w, r, &body); err != nil {
 		h.badRequestResponse(w, r, err)
@@ -117,14 +114,43 @@ func (h *PutEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		h.failedValidationResponse(w, r, v.Errors())
 		return
 	}
-	output, err := h.uc.Execute(r.Context(), entry)
+	input := usecase.UpdateEntryInput{Entry: entry, ImportImage: body.ImportImage}
+	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
 		h.serverErrorResponse(w, r, err)
+	}
+}
+
+type PatchEntryHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.PatchEntryInput, usecase.PatchEntryOutput]
+}
+
+func NewPatchEntryHandler(logger *slog.Logger, uc usecase.Usecase[usecase.PatchEntryInput, usecase.PatchEntryOutput]) *PatchEntryHandler {
+	return &PatchEntryHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PatchEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	patch, err := h.readMergePatch(w, r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	input := usecase.PatchEntryInput{
+		RankId: r.PathValue("rankId"),
+		Id:     r.PathValue("id"),
+		Patch:  patch,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -134,10 +160,10 @@ func (h *PutEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type DeleteEntryHandler struct {
 	baseHandler
-	uc *usecase.DeleteEntryUsecase
+	uc usecase.Usecase[usecase.DeleteEntryInput, usecase.DeleteEntryOutput]
 }
 
-func NewDeleteEntryHandler(logger *slog.Logger, uc *usecase.DeleteEntryUsecase) *DeleteEntryHandler {
+func NewDeleteEntryHandler(logger *slog.Logger, uc usecase.Usecase[usecase.DeleteEntryInput, usecase.DeleteEntryOutput]) *DeleteEntryHandler {
 	return &DeleteEntryHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -150,12 +176,7 @@ func (h *DeleteEntryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Id:     r.PathValue("id"),
 	}
 	if _, err := h.uc.Execute(r.Context(), input); err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	data := map[string]any{
@@ -4,19 +4,22 @@ import (
 	"bytes"
 	"context"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
 	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
 func TestPostEntryHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := usecase.NewCreateEntryUsecase(repo)
+	uc := usecase.NewCreateEntryUsecase(repo, usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute), nil)
 	h := NewPostEntryHandler(logger, uc)
 	t.Run("ServeHTTP", func(t *testing.T) {
 		t.Run("201", func(t *testing.T) {
@@ -88,7 +91,7 @@ func TestPostEntryHandler(t *testing.T) {
 func TestGetEntryHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := usecase.NewFindEntryUsecase(repo)
+	uc := usecase.NewFindEntryUsecase(repo, usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	h := NewGetEntryHandler(logger, uc)
 	repo.Create(context.Background(), &mock.Entries[0])
 	t.Run("ServeHTTP", func(t *testing.T) {
@@ -104,7 +107,7 @@ func TestGetEntryHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusOK {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
 			}
-			want := `{"id":"d10961ca-e9ed-4d3b-b086-f756a3118894","name":"Neo Geo CD","image_url":"https://videogame.com/neo-geo-cd.png","scores":{"Controls":90,"Graphics":97,"Sound":97},"rank_id":"1ac85e34-cb6f-40c9-97bb-16267877bb13"}`
+			want := `{"id":"d10961ca-e9ed-4d3b-b086-f756a3118894","name":"Neo Geo CD","image_url":"https://videogame.com/neo-geo-cd.png","scores":{"Controls":90,"Graphics":97,"Sound":97},"rank_id":"1ac85e34-cb6f-40c9-97bb-16267877bb13","media":[]}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -121,7 +124,7 @@ func TestGetEntryHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"entry not found: 7ca55092-80f7-40cb-a629-361037baa6be"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"entry not found: 7ca55092-80f7-40cb-a629-361037baa6be","instance":"/rank/%7BrankId%7D/entry/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -132,7 +135,7 @@ func TestGetEntryHandler(t *testing.T) {
 func TestPutEntryHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := usecase.NewUpdateEntryUsecase(repo)
+	uc := usecase.NewUpdateEntryUsecase(repo, usecase.NewImageCleaner(slog.New(slog.DiscardHandler), storage.NewInMemoryStorage(), &inmemory.RankTableInMemoryRepository{}, 0), usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute), nil)
 	h := NewPutEntryHandler(logger, uc)
 	t.Run("ServeHTTP", func(t *testing.T) {
 		t.Run("200", func(t *testing.T) {
@@ -204,7 +207,7 @@ func TestPutEntryHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"entry not found: b59287e3-e544-44a9-a20a-f28520c6beea"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"entry not found: b59287e3-e544-44a9-a20a-f28520c6beea","instance":"/rank/%7BrankId%7D/entry/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -234,10 +237,62 @@ func TestPutEntryHandler(t *testing.T) {
 	})
 }
 
+func TestPatchEntryHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	repo := &inmemory.EntryInMemoryRepository{}
+	uc := usecase.NewPatchEntryUsecase(repo, usecase.NewImageCleaner(slog.New(slog.DiscardHandler), storage.NewInMemoryStorage(), &inmemory.RankTableInMemoryRepository{}, 0), usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
+	h := NewPatchEntryHandler(logger, uc)
+	entry := mock.Entries[2]
+	entry.Scores = maps.Clone(entry.Scores)
+	if err := repo.Create(context.Background(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	send := func(contentType string, buf []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("PATCH", "/rank/{rankId}/entry/{id}", bytes.NewBuffer(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.SetPathValue("rankId", entry.RankId)
+		req.SetPathValue("id", entry.Id)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	t.Run("ServeHTTP", func(t *testing.T) {
+		t.Run("200", func(t *testing.T) {
+			rr := send("application/merge-patch+json", []byte(`{"scores":{"Graphics":80,"Sound":null}}`))
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+			}
+			want := `{"id":"da2b4fc6-f933-4214-b742-4f199aec2481","name":"Sega Master System","image_url":"https://videogame.com/sms.png","scores":{"Controls":73,"Graphics":80},"rank_id":"1ac85e34-cb6f-40c9-97bb-16267877bb13"}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+		t.Run("415", func(t *testing.T) {
+			rr := send("text/plain", []byte(`{"name":"SMS"}`))
+			if status := rr.Code; status != http.StatusUnsupportedMediaType {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnsupportedMediaType)
+			}
+		})
+		t.Run("422", func(t *testing.T) {
+			rr := send("application/merge-patch+json", []byte(`{"image_url":"not a url"}`))
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnprocessableEntity)
+			}
+			want := `{"type":"/problems/validation-failed","title":"Unprocessable Entity","status":422,"detail":"the request contains invalid fields","instance":"/rank/%7BrankId%7D/entry/%7Bid%7D","code":"validation_failed","errors":[{"field":"image_url","message":"must be a valid URL"}]}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+	})
+}
+
 func TestDeleteEntryHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	repo := &inmemory.EntryInMemoryRepository{}
-	uc := usecase.NewDeleteEntryUsecase(repo)
+	uc := usecase.NewDeleteEntryUsecase(repo, usecase.NewImageCleaner(slog.New(slog.DiscardHandler), storage.NewInMemoryStorage(), &inmemory.RankTableInMemoryRepository{}, 0))
 	h := NewDeleteEntryHandler(logger, uc)
 	t.Run("ServeHTTP", func(t *testing.T) {
 		t.Run("200", func(t *testing.T) {
@@ -269,7 +324,7 @@ func TestDeleteEntryHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"entry not found: d10961ca-e9ed-4d3b-b086-f756a3118894"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"entry not found: d10961ca-e9ed-4d3b-b086-f756a3118894","instance":"/rank/%7BrankId%7D/entry/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
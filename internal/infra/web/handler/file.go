@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+)
+
+const (
+	fileCacheControl = "public, max-age=86400"
+	// privateFileCacheControl keeps shared caches from storing private files
+	// and browsers from using them without checking they are still readable.
+	privateFileCacheControl = "private, no-cache"
+)
+
+// GetFileHandler serves the files of a storage that cannot serve them itself,
+// such as storage.FileSystemStorage. Private files are only served through
+// URLs the verifier accepts.
+type GetFileHandler struct {
+	baseHandler
+	storage  storage.FileStorage
+	verifier storage.URLVerifier
+}
+
+func NewGetFileHandler(logger *slog.Logger, storage storage.FileStorage, verifier storage.URLVerifier) *GetFileHandler {
+	return &GetFileHandler{
+		baseHandler: baseHandler{logger},
+		storage:     storage,
+		verifier:    verifier,
+	}
+}
+
+func (h *GetFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.PathValue("path")
+	private, err := h.verifier.VerifyURL(r.Context(), path, r.URL.Query())
+	if err != nil {
+		if errors.Is(err, storage.ErrAccessDenied) {
+			h.problemResponse(w, r, problem.New(http.StatusForbidden, problem.CodeForbidden, "the file is private and the URL is not signed or has expired"))
+			return
+		}
+		h.serverErrorResponse(w, r, err)
+		return
+	}
+	body, info, err := h.storage.Open(r.Context(), path)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			h.problemResponse(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, "file not found"))
+			return
+		}
+		h.serverErrorResponse(w, r, err)
+		return
+	}
+	defer body.Close()
+	header := w.Header()
+	if info.ContentType != "" {
+		header.Set("Content-Type", info.ContentType)
+	} else {
+		header.Set("Content-Type", "application/octet-stream")
+	}
+	if private {
+		header.Set("Cache-Control", privateFileCacheControl)
+	} else {
+		header.Set("Cache-Control", fileCacheControl)
+	}
+	header.Set("ETag", fmt.Sprintf(`"%x-%x"`, info.LastModified.UnixNano(), info.Size))
+	// Uploaded files are not trusted: browsers must neither guess their type
+	// nor run scripts they contain.
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	if rs, ok := body.(io.ReadSeeker); ok {
+		// ServeContent answers conditional and range requests.
+		http.ServeContent(w, r, "", info.LastModified, rs)
+		return
+	}
+	if notModified(r, header.Get("ETag"), info.LastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	header.Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	header.Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		io.Copy(w, body)
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+)
+
+type servedStorage interface {
+	storage.FileStorage
+	storage.Signer
+	storage.URLVerifier
+}
+
+func TestGetFileHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	storages := map[string]servedStorage{
+		"inmemory":   storage.NewInMemoryStorage(),
+		"filesystem": storage.NewFileSystemStorage(t.TempDir(), "http://localhost/files", []byte("key")),
+	}
+	for name, s := range storages {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Upload(context.Background(), "rank/img/original.png", strings.NewReader("0123456789"), storage.UploadOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			mux := http.NewServeMux()
+			mux.Handle("GET /files/{path...}", NewGetFileHandler(logger, s, s))
+			serve := func(path string, header http.Header) *httptest.ResponseRecorder {
+				req, err := http.NewRequest("GET", path, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.Header = header
+				rr := httptest.NewRecorder()
+				mux.ServeHTTP(rr, req)
+				return rr
+			}
+			t.Run("200", func(t *testing.T) {
+				rr := serve("/files/rank/img/original.png", http.Header{})
+				if status := rr.Code; status != http.StatusOK {
+					t.Fatalf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+				}
+				if body := rr.Body.String(); body != "0123456789" {
+					t.Errorf("handler returned wrong body: got %v, want %v", body, "0123456789")
+				}
+				for header, want := range map[string]string{
+					"Content-Type":           "image/png",
+					"Cache-Control":          fileCacheControl,
+					"X-Content-Type-Options": "nosniff",
+				} {
+					if got := rr.Header().Get(header); got != want {
+						t.Errorf("handler returned wrong %v header: got %v, want %v", header, got, want)
+					}
+				}
+			})
+			t.Run("304", func(t *testing.T) {
+				etag := serve("/files/rank/img/original.png", http.Header{}).Header().Get("ETag")
+				rr := serve("/files/rank/img/original.png", http.Header{"If-None-Match": {etag}})
+				if status := rr.Code; status != http.StatusNotModified {
+					t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotModified)
+				}
+			})
+			t.Run("private", func(t *testing.T) {
+				ctx := context.Background()
+				path := "rank/img/private.png"
+				if _, err := s.Upload(ctx, path, strings.NewReader("0123456789"), storage.UploadOptions{Private: true}); err != nil {
+					t.Fatal(err)
+				}
+				if status := serve("/files/"+path, http.Header{}).Code; status != http.StatusForbidden {
+					t.Errorf("handler returned wrong status code for an unsigned URL: got %v, want %v", status, http.StatusForbidden)
+				}
+				signed, err := s.SignURL(ctx, path, time.Minute)
+				if err != nil {
+					t.Fatal(err)
+				}
+				u, err := url.Parse(signed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rr := serve("/files/"+path+"?"+u.RawQuery, http.Header{})
+				if status := rr.Code; status != http.StatusOK {
+					t.Fatalf("handler returned wrong status code for a signed URL: got %v, want %v", status, http.StatusOK)
+				}
+				if got := rr.Header().Get("Cache-Control"); got != privateFileCacheControl {
+					t.Errorf("handler returned wrong Cache-Control header: got %v, want %v", got, privateFileCacheControl)
+				}
+			})
+			t.Run("404", func(t *testing.T) {
+				for _, path := range []string{"/files/rank/img/missing.png", "/files/rank/%2E%2E/%2E%2E/etc/passwd", "/files/rank/img"} {
+					if status := serve(path, http.Header{}).Code; status != http.StatusNotFound {
+						t.Errorf("handler returned wrong status code for %v: got %v, want %v", path, status, http.StatusNotFound)
+					}
+				}
+			})
+		})
+	}
+	t.Run("206", func(t *testing.T) {
+		s := storages["filesystem"]
+		req, err := http.NewRequest("GET", "/files/rank/img/original.png", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetPathValue("path", "rank/img/original.png")
+		req.Header.Set("Range", "bytes=2-5")
+		rr := httptest.NewRecorder()
+		NewGetFileHandler(logger, s, s).ServeHTTP(rr, req)
+		if status := rr.Code; status != http.StatusPartialContent {
+			t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusPartialContent)
+		}
+		if body := rr.Body.String(); body != "2345" {
+			t.Errorf("handler returned wrong body: got %v, want %v", body, "2345")
+		}
+	})
+}
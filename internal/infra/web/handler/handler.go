@@ -1,41 +1,88 @@
 package handler
 
 import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"maps"
+	"mime"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+	"github.com/josimarz/ranking-backend/internal/mergepatch"
 )
 
 const (
 	maxBytes = 1_048_576
 )
 
+type requestError struct {
+	status int
+	code   string
+	msg    string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
 type baseHandler struct {
 	logger *slog.Logger
 }
 
+func (h *baseHandler) errorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr *usecase.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		h.failedValidationResponse(w, r, validationErr.Errors)
+	case errors.Is(err, usecase.ErrNotFound):
+		h.problemResponse(w, r, problem.New(http.StatusNotFound, problem.CodeNotFound, err.Error()))
+	case errors.Is(err, usecase.ErrConflict):
+		h.problemResponse(w, r, problem.New(http.StatusConflict, problem.CodeConflict, err.Error()))
+	default:
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
 func (h *baseHandler) serverErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
 	h.logError(r, err)
 	msg := "the server has encountered a problem and could not process your request"
-	h.errorResponse(w, r, http.StatusInternalServerError, msg)
+	h.problemResponse(w, r, problem.New(http.StatusInternalServerError, problem.CodeInternalError, msg))
 }
 
-func (h *baseHandler) notFoundResponse(w http.ResponseWriter, r *http.Request, err error) {
-	h.errorResponse(w, r, http.StatusNotFound, err.Error())
+func (h *baseHandler) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		h.problemResponse(w, r, problem.New(reqErr.status, reqErr.code, reqErr.msg))
+		return
+	}
+	h.logger.WarnContext(r.Context(), "bad request", "method", r.Method, "uri", r.URL.RequestURI(), "error", err)
+	h.problemResponse(w, r, problem.New(http.StatusBadRequest, problem.CodeBadRequest, "the request could not be read"))
 }
 
-func (h *baseHandler) badRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	h.errorResponse(w, r, http.StatusBadRequest, err.Error())
+func (h *baseHandler) unsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, msg string) {
+	h.problemResponse(w, r, problem.New(http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, msg))
 }
 
 func (h *baseHandler) failedValidationResponse(w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	h.errorResponse(w, r, http.StatusUnprocessableEntity, errors)
+	p := problem.New(http.StatusUnprocessableEntity, problem.CodeValidationFailed, "the request contains invalid fields")
+	for _, field := range slices.Sorted(maps.Keys(errors)) {
+		p.Errors = append(p.Errors, problem.FieldError{Field: field, Message: errors[field]})
+	}
+	h.problemResponse(w, r, p)
 }
 
-func (h *baseHandler) errorResponse(w http.ResponseWriter, r *http.Request, status int, msg any) {
-	data := map[string]any{"error": msg}
-	if err := h.writeJSON(w, status, data, nil); err != nil {
+func (h *baseHandler) problemResponse(w http.ResponseWriter, r *http.Request, p *problem.Problem) {
+	if err := p.Write(w, r, nil); err != nil {
 		h.logError(r, err)
 		w.WriteHeader(http.StatusInternalServerError)
 	}
@@ -43,16 +90,93 @@ func (h *baseHandler) errorResponse(w http.ResponseWriter, r *http.Request, stat
 
 func (h *baseHandler) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
 	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	body, err := h.decodeBody(w, r)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
 
-	dec := json.NewDecoder(r.Body)
+	dec := json.NewDecoder(body)
 	dec.DisallowUnknownFields()
 
 	if err := dec.Decode(dst); err != nil {
-		return err
+		return h.decodeError(err)
+	}
+	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return &requestError{http.StatusBadRequest, problem.CodeBadRequest, "body must only contain a single JSON value"}
 	}
 	return nil
 }
 
+// decodeBody undoes the Content-Encoding of the request body. The decoded
+// body is limited to maxBytes as well, so a small compressed body cannot
+// expand without bounds.
+func (h *baseHandler) decodeBody(w http.ResponseWriter, r *http.Request) (io.ReadCloser, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		return r.Body, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, io.EOF) || errors.As(err, &maxBytesErr):
+			return nil, h.decodeError(err)
+		case err != nil:
+			return nil, &requestError{http.StatusBadRequest, problem.CodeBadRequest, "body is not valid gzip"}
+		}
+		return http.MaxBytesReader(w, zr, maxBytes), nil
+	default:
+		msg := fmt.Sprintf("Content-Encoding %q is not supported, use gzip", encoding)
+		return nil, &requestError{http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, msg}
+	}
+}
+
+func (h *baseHandler) readMergePatch(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != mergepatch.ContentType && mediaType != "application/json") {
+			msg := fmt.Sprintf("Content-Type must be %v", mergepatch.ContentType)
+			return nil, &requestError{http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, msg}
+		}
+	}
+	var patch json.RawMessage
+	if err := h.readJSON(w, r, &patch); err != nil {
+		return nil, err
+	}
+	return patch, nil
+}
+
+func (*baseHandler) decodeError(err error) error {
+	var (
+		syntaxErr        *json.SyntaxError
+		unmarshalTypeErr *json.UnmarshalTypeError
+		maxBytesErr      *http.MaxBytesError
+	)
+	msg := err.Error()
+	switch {
+	case errors.As(err, &syntaxErr):
+		msg = fmt.Sprintf("body contains badly-formed JSON (at character %d)", syntaxErr.Offset)
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		msg = "body contains badly-formed JSON"
+	case errors.As(err, &unmarshalTypeErr):
+		if unmarshalTypeErr.Field != "" {
+			msg = fmt.Sprintf("body contains incorrect JSON type for field %q", unmarshalTypeErr.Field)
+		} else {
+			msg = fmt.Sprintf("body contains incorrect JSON type (at character %d)", unmarshalTypeErr.Offset)
+		}
+	case errors.Is(err, io.EOF):
+		msg = "body must not be empty"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		msg = fmt.Sprintf("body contains unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	case errors.As(err, &maxBytesErr):
+		msg = fmt.Sprintf("body must not be larger than %d bytes", maxBytesErr.Limit)
+		return &requestError{http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge, msg}
+	default:
+		msg = "body could not be decoded"
+	}
+	return &requestError{http.StatusBadRequest, problem.CodeBadRequest, msg}
+}
+
 func (h *baseHandler) writeJSON(w http.ResponseWriter, status int, data any, headers http.Header) error {
 	output, err := json.Marshal(data)
 	if err != nil {
@@ -65,6 +189,51 @@ func (h *baseHandler) writeJSON(w http.ResponseWriter, status int, data any, hea
 	return nil
 }
 
+// writeConditionalJSON works like writeJSON with a 200 status, but tags the
+// response with a strong ETag and, when modTime is known, a Last-Modified
+// header. A request whose validators still match gets 304 Not Modified.
+func (h *baseHandler) writeConditionalJSON(w http.ResponseWriter, r *http.Request, data any, modTime time.Time, headers http.Header) error {
+	output, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(output)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	maps.Insert(w.Header(), maps.All(headers))
+	w.Header().Set("ETag", etag)
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if notModified(r, etag, modTime) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(output)
+	return nil
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since as described in
+// RFC 9110. If-Modified-Since is ignored when If-None-Match is present.
+func notModified(r *http.Request, etag string, modTime time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modTime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	return err == nil && !modTime.Truncate(time.Second).After(t)
+}
+
 func (h *baseHandler) logError(r *http.Request, err error) {
-	h.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
+	h.logger.ErrorContext(r.Context(), err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
 }
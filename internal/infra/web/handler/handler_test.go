@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+)
+
+func TestErrorResponse(t *testing.T) {
+	h := &baseHandler{slog.New(slog.DiscardHandler)}
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", fmt.Errorf("find rank: %w", usecase.ErrNotFound), http.StatusNotFound, problem.CodeNotFound},
+		{"conflict", usecase.NewConflictError("rank already exists"), http.StatusConflict, problem.CodeConflict},
+		{"validation", usecase.NewValidationError(map[string]string{"name": "must be provided"}), http.StatusUnprocessableEntity, problem.CodeValidationFailed},
+		{"unknown", errors.New("boom"), http.StatusInternalServerError, problem.CodeInternalError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/rank/1", nil)
+			rr := httptest.NewRecorder()
+			h.errorResponse(rr, req, tt.err)
+			if rr.Code != tt.status {
+				t.Errorf("errorResponse() returned wrong status code: got %v, want %v", rr.Code, tt.status)
+			}
+			if got := rr.Header().Get("Content-Type"); got != problem.ContentType {
+				t.Errorf("errorResponse() returned wrong Content-Type: got %v, want %v", got, problem.ContentType)
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if p.Code != tt.code || p.Status != tt.status || p.Instance != "/rank/1" {
+				t.Errorf("errorResponse() returned wrong problem: got %+v", p)
+			}
+		})
+	}
+}
+
+func TestFailedValidationResponse(t *testing.T) {
+	h := &baseHandler{slog.New(slog.DiscardHandler)}
+	rr := httptest.NewRecorder()
+	h.failedValidationResponse(rr, httptest.NewRequest("POST", "/rank", nil), map[string]string{
+		"public": "must be a boolean",
+		"name":   "must be provided",
+	})
+	want := `{"type":"/problems/validation-failed","title":"Unprocessable Entity","status":422,"detail":"the request contains invalid fields","instance":"/rank","code":"validation_failed","errors":[{"field":"name","message":"must be provided"},{"field":"public","message":"must be a boolean"}]}`
+	if body := rr.Body.String(); body != want {
+		t.Errorf("failedValidationResponse() returned wrong body: got %v, want %v", body, want)
+	}
+}
+
+func TestReadJSON(t *testing.T) {
+	h := &baseHandler{slog.New(slog.DiscardHandler)}
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		detail string
+	}{
+		{"syntax", `{"name":}`, http.StatusBadRequest, "body contains badly-formed JSON (at character 9)"},
+		{"unexpected eof", `{"name":"a"`, http.StatusBadRequest, "body contains badly-formed JSON"},
+		{"type", `{"name":1}`, http.StatusBadRequest, `body contains incorrect JSON type for field "name"`},
+		{"unknown field", `{"age":1}`, http.StatusBadRequest, `body contains unknown field "age"`},
+		{"empty", ``, http.StatusBadRequest, "body must not be empty"},
+		{"multiple values", `{"name":"a"}{"name":"b"}`, http.StatusBadRequest, "body must only contain a single JSON value"},
+		{"too large", `{"name":"` + strings.Repeat("a", maxBytes) + `"}`, http.StatusRequestEntityTooLarge, "body must not be larger than 1048576 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/rank", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+			var dst struct {
+				Name string `json:"name"`
+			}
+			err := h.readJSON(rr, req, &dst)
+			if err == nil {
+				t.Fatal("readJSON() got nil error")
+			}
+			h.badRequestResponse(rr, req, err)
+			var p problem.Problem
+			if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+				t.Fatal(err)
+			}
+			if rr.Code != tt.status || p.Detail != tt.detail {
+				t.Errorf("badRequestResponse() got %v %q, want %v %q", rr.Code, p.Detail, tt.status, tt.detail)
+			}
+		})
+	}
+}
+
+func TestBadRequestResponse(t *testing.T) {
+	h := &baseHandler{slog.New(slog.DiscardHandler)}
+	req := httptest.NewRequest("POST", "/rank", nil)
+	rr := httptest.NewRecorder()
+	h.badRequestResponse(rr, req, errors.New("read tcp 10.0.0.1:8080: connection reset by peer"))
+	var p problem.Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if want := "the request could not be read"; rr.Code != http.StatusBadRequest || p.Detail != want {
+		t.Errorf("badRequestResponse() got %v %q, want %v %q", rr.Code, p.Detail, http.StatusBadRequest, want)
+	}
+}
+
+func TestReadJSONContentEncoding(t *testing.T) {
+	h := &baseHandler{slog.New(slog.DiscardHandler)}
+	gzipped := func(s string) string {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write([]byte(s))
+		zw.Close()
+		return buf.String()
+	}
+	tests := []struct {
+		name     string
+		encoding string
+		body     string
+		status   int
+	}{
+		{"gzip", "gzip", gzipped(`{"name":"a"}`), 0},
+		{"expands beyond limit", "gzip", gzipped(`{"name":"` + strings.Repeat("a", maxBytes) + `"}`), http.StatusRequestEntityTooLarge},
+		{"invalid gzip", "gzip", `{"name":"a"}`, http.StatusBadRequest},
+		{"unsupported", "br", `{"name":"a"}`, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/rank", strings.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			rr := httptest.NewRecorder()
+			var dst struct {
+				Name string `json:"name"`
+			}
+			err := h.readJSON(rr, req, &dst)
+			if tt.status == 0 {
+				if err != nil || dst.Name != "a" {
+					t.Errorf("readJSON() got (%v, %v), want (%v, %v)", dst.Name, err, "a", nil)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("readJSON() got nil error")
+			}
+			h.badRequestResponse(rr, req, err)
+			if rr.Code != tt.status {
+				t.Errorf("badRequestResponse() got %v, want %v", rr.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	statusOk          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+type HealthChecker interface {
+	Check(context.Context) error
+}
+
+type HealthzHandler struct {
+	baseHandler
+}
+
+func NewHealthzHandler(logger *slog.Logger) *HealthzHandler {
+	return &HealthzHandler{
+		baseHandler: baseHandler{logger},
+	}
+}
+
+func (h *HealthzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data := map[string]any{
+		"status": statusOk,
+	}
+	if err := h.writeJSON(w, http.StatusOK, data, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+type checkResult struct {
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type readiness struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks"`
+}
+
+type ReadyzHandler struct {
+	baseHandler
+	checks  map[string]HealthChecker
+	timeout time.Duration
+	ttl     time.Duration
+	mu      sync.Mutex
+	cached  *readiness
+	expires time.Time
+}
+
+func NewReadyzHandler(logger *slog.Logger, checks map[string]HealthChecker, timeout, ttl time.Duration) *ReadyzHandler {
+	return &ReadyzHandler{
+		baseHandler: baseHandler{logger},
+		checks:      checks,
+		timeout:     timeout,
+		ttl:         ttl,
+	}
+}
+
+func (h *ReadyzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res := h.check(r.Context())
+	status := http.StatusOK
+	if res.Status != statusOk {
+		status = http.StatusServiceUnavailable
+	}
+	headers := http.Header{"Cache-Control": []string{"no-store"}}
+	if err := h.writeJSON(w, status, res, headers); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+func (h *ReadyzHandler) check(ctx context.Context) *readiness {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cached != nil && time.Now().Before(h.expires) {
+		return h.cached
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), h.timeout)
+	defer cancel()
+	res := &readiness{
+		Status: statusOk,
+		Checks: make(map[string]checkResult, len(h.checks)),
+	}
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	for name, checker := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := checker.Check(ctx)
+			result := checkResult{
+				Status:  statusOk,
+				Latency: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				result.Status = statusUnavailable
+				result.Error = statusUnavailable
+				h.logger.WarnContext(ctx, "readiness check failed", "check", name, "error", err)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			res.Checks[name] = result
+			if err != nil {
+				res.Status = statusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+	h.cached = res
+	h.expires = time.Now().Add(h.ttl)
+	return res
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockChecker struct {
+	err   error
+	calls int
+}
+
+func (c *mockChecker) Check(ctx context.Context) error {
+	c.calls++
+	return c.err
+}
+
+func TestHealthzHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	h := NewHealthzHandler(logger)
+	t.Run("ServeHTTP", func(t *testing.T) {
+		t.Run("200", func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/healthz", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+			}
+			want := `{"status":"ok"}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+	})
+}
+
+func TestReadyzHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	t.Run("ServeHTTP", func(t *testing.T) {
+		t.Run("200", func(t *testing.T) {
+			dynamodb := &mockChecker{}
+			s3 := &mockChecker{}
+			h := NewReadyzHandler(logger, map[string]HealthChecker{"dynamodb": dynamodb, "s3": s3}, time.Second, time.Minute)
+			for range 2 {
+				req, err := http.NewRequest("GET", "/readyz", nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+				if status := rr.Code; status != http.StatusOK {
+					t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+				}
+			}
+			if dynamodb.calls != 1 || s3.calls != 1 {
+				t.Errorf("checks were not cached: got (%v, %v) calls, want (%v, %v)", dynamodb.calls, s3.calls, 1, 1)
+			}
+		})
+		t.Run("503", func(t *testing.T) {
+			checks := map[string]HealthChecker{
+				"dynamodb": &mockChecker{},
+				"s3":       &mockChecker{err: errors.New("bucket not found")},
+			}
+			h := NewReadyzHandler(logger, checks, time.Second, 0)
+			req, err := http.NewRequest("GET", "/readyz", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if status := rr.Code; status != http.StatusServiceUnavailable {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusServiceUnavailable)
+			}
+			var body readiness
+			if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Status != "unavailable" || body.Checks["dynamodb"].Status != "ok" || body.Checks["s3"].Error != "unavailable" {
+				t.Errorf("handler returned wrong body: got %v", rr.Body.String())
+			}
+			if strings.Contains(rr.Body.String(), "bucket not found") {
+				t.Errorf("handler leaked the check error: got %v", rr.Body.String())
+			}
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+)
+
+type PostImagesImportHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.ImportImagesInput, usecase.ImportImagesOutput]
+}
+
+func NewPostImagesImportHandler(logger *slog.Logger, uc usecase.Usecase[usecase.ImportImagesInput, usecase.ImportImagesOutput]) *PostImagesImportHandler {
+	return &PostImagesImportHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PostImagesImportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	input := usecase.ImportImagesInput{
+		RankId: r.PathValue("id"),
+		After:  r.URL.Query().Get("after"),
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
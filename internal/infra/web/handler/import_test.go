@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/remote"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+)
+
+func TestPostImagesImportHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	ranks := &inmemory.RankInMemoryRepository{}
+	rank := &entity.Rank{Id: "7f2b4d6a-8c1e-4a3b-9d5f-6e0a2c4b8d1f", Name: "No images", Public: true}
+	if err := ranks.Create(context.Background(), rank); err != nil {
+		t.Fatal(err)
+	}
+	files := storage.NewInMemoryStorage()
+	urls := usecase.NewImageSigner(files, nil, ranks, time.Minute)
+	upload := usecase.NewUploadUsecase(files, imaging.DefaultVariants(), imaging.DefaultLimits(), urls)
+	importer := usecase.NewImageImporter(remote.NewFetcher(remote.DefaultConfig()), upload)
+	uc := usecase.NewImportImagesUsecase(&inmemory.RankTableInMemoryRepository{}, &inmemory.EntryInMemoryRepository{}, importer, urls)
+	h := NewPostImagesImportHandler(logger, uc)
+	send := func(id string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/rank/{id}/images:import", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	t.Run("ServeHTTP", func(t *testing.T) {
+		t.Run("200", func(t *testing.T) {
+			rr := send(rank.Id)
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+			}
+			want := `{"rank_id":"7f2b4d6a-8c1e-4a3b-9d5f-6e0a2c4b8d1f","imported":0,"failed":0,"results":[]}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+		t.Run("404", func(t *testing.T) {
+			rr := send("0c9e8d7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f")
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
+			}
+		})
+	})
+}
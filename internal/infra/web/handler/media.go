@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/validator"
+)
+
+type PostMediaHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.AddMediaInput, usecase.EntryMediaOutput]
+}
+
+func NewPostMediaHandler(logger *slog.Logger, uc usecase.Usecase[usecase.AddMediaInput, usecase.EntryMediaOutput]) *PostMediaHandler {
+	return &PostMediaHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PostMediaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		URL     string `json:"url"`
+		Alt     string `json:"alt"`
+		Caption string `json:"caption"`
+		Kind    string `json:"kind"`
+		Cover   bool   `json:"cover"`
+	}
+	if err := h.readJSON(w, r, &body); err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	if body.Kind == "" {
+		body.Kind = entity.MediaImage
+	}
+	media := entity.NewMedia(body.URL, body.Alt, body.Caption, body.Kind, body.Cover)
+	v := validator.New()
+	if entity.ValidateMedia(v, media); !v.Valid() {
+		h.failedValidationResponse(w, r, v.Errors())
+		return
+	}
+	input := usecase.AddMediaInput{
+		RankId:  r.PathValue("rankId"),
+		EntryId: r.PathValue("id"),
+		Media:   media,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusCreated, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+type PostMediaReorderHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.ReorderMediaInput, usecase.EntryMediaOutput]
+}
+
+func NewPostMediaReorderHandler(logger *slog.Logger, uc usecase.Usecase[usecase.ReorderMediaInput, usecase.EntryMediaOutput]) *PostMediaReorderHandler {
+	return &PostMediaReorderHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PostMediaReorderHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Ids     []string `json:"ids"`
+		CoverId string   `json:"cover_id"`
+	}
+	if err := h.readJSON(w, r, &body); err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	input := usecase.ReorderMediaInput{
+		RankId:  r.PathValue("rankId"),
+		EntryId: r.PathValue("id"),
+		Ids:     body.Ids,
+		CoverId: body.CoverId,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+type DeleteMediaHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.RemoveMediaInput, usecase.EntryMediaOutput]
+}
+
+func NewDeleteMediaHandler(logger *slog.Logger, uc usecase.Usecase[usecase.RemoveMediaInput, usecase.EntryMediaOutput]) *DeleteMediaHandler {
+	return &DeleteMediaHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *DeleteMediaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	input := usecase.RemoveMediaInput{
+		RankId:  r.PathValue("rankId"),
+		EntryId: r.PathValue("id"),
+		Id:      r.PathValue("mediaId"),
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
+	"github.com/josimarz/ranking-backend/internal/mock"
+)
+
+func TestMediaHandlers(t *testing.T) {
+	ctx := context.Background()
+	mockRankTable(ctx)
+	logger := slog.New(slog.DiscardHandler)
+	files := storage.NewInMemoryStorage()
+	repo := &inmemory.EntryInMemoryRepository{}
+	urls := usecase.NewImageSigner(files, nil, &inmemory.RankInMemoryRepository{}, time.Minute)
+	images := usecase.NewImageCleaner(slog.New(slog.DiscardHandler), files, &inmemory.RankTableInMemoryRepository{}, 0)
+	entry := mock.Entries[0]
+	serve := func(h http.Handler, method, pattern string, body []byte, mediaId string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(method, pattern, bytes.NewBuffer(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetPathValue("rankId", entry.RankId)
+		req.SetPathValue("id", entry.Id)
+		req.SetPathValue("mediaId", mediaId)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	var output usecase.EntryMediaOutput
+	t.Run("PostMediaHandler", func(t *testing.T) {
+		h := NewPostMediaHandler(logger, usecase.NewAddMediaUsecase(repo, urls))
+		t.Run("201", func(t *testing.T) {
+			for _, buf := range [][]byte{
+				[]byte(`{"url":"https://videogame.com/neo-geo-cd.png","alt":"Neo Geo CD","cover":true}`),
+				[]byte(`{"url":"https://videogame.com/neo-geo-cd-trailer","caption":"Trailer","kind":"video"}`),
+			} {
+				rr := serve(h, "POST", "/rank/{rankId}/entry/{id}/media", buf, "")
+				if status := rr.Code; status != http.StatusCreated {
+					t.Fatalf("handler returned wrong status code: got %v, want %v", status, http.StatusCreated)
+				}
+				if err := json.Unmarshal(rr.Body.Bytes(), &output); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(output.Media) != 2 || output.Media[0].Kind != "image" || !output.Media[0].Cover {
+				t.Errorf("handler returned wrong media: got %+v, want the image as cover followed by the video", output.Media)
+			}
+		})
+		t.Run("400", func(t *testing.T) {
+			rr := serve(h, "POST", "/rank/{rankId}/entry/{id}/media", []byte(`{"url"}`), "")
+			if status := rr.Code; status != http.StatusBadRequest {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusBadRequest)
+			}
+		})
+		t.Run("422", func(t *testing.T) {
+			rr := serve(h, "POST", "/rank/{rankId}/entry/{id}/media", []byte(`{"url":"https://videogame.com/trailer","kind":"video","cover":true}`), "")
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnprocessableEntity)
+			}
+		})
+	})
+	t.Run("PostMediaReorderHandler", func(t *testing.T) {
+		h := NewPostMediaReorderHandler(logger, usecase.NewReorderMediaUsecase(repo, urls))
+		image, video := output.Media[0], output.Media[1]
+		t.Run("200", func(t *testing.T) {
+			buf, _ := json.Marshal(map[string]any{"ids": []string{video.Id, image.Id}})
+			rr := serve(h, "POST", "/rank/{rankId}/entry/{id}/media:reorder", buf, "")
+			if status := rr.Code; status != http.StatusOK {
+				t.Fatalf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+			}
+			var got usecase.EntryMediaOutput
+			if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got.Media) != 2 || got.Media[0].Id != video.Id || got.Media[1].Id != image.Id {
+				t.Errorf("handler returned wrong media: got %+v, want the video followed by the image", got.Media)
+			}
+		})
+		t.Run("422", func(t *testing.T) {
+			buf, _ := json.Marshal(map[string]any{"ids": []string{video.Id}})
+			rr := serve(h, "POST", "/rank/{rankId}/entry/{id}/media:reorder", buf, "")
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnprocessableEntity)
+			}
+		})
+	})
+	t.Run("DeleteMediaHandler", func(t *testing.T) {
+		h := NewDeleteMediaHandler(logger, usecase.NewRemoveMediaUsecase(repo, images, urls))
+		video := output.Media[1]
+		t.Run("200", func(t *testing.T) {
+			rr := serve(h, "DELETE", "/rank/{rankId}/entry/{id}/media/{mediaId}", nil, video.Id)
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+			}
+		})
+		t.Run("404", func(t *testing.T) {
+			rr := serve(h, "DELETE", "/rank/{rankId}/entry/{id}/media/{mediaId}", nil, video.Id)
+			if status := rr.Code; status != http.StatusNotFound {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
+			}
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"media not found: ` + video.Id + `","instance":"/rank/%7BrankId%7D/entry/%7Bid%7D/media/%7BmediaId%7D","code":"not_found"}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+	})
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+	"html"
+	"log/slog"
+	"net/http"
+)
+
+type OpenAPIHandler struct {
+	baseHandler
+	doc any
+}
+
+func NewOpenAPIHandler(logger *slog.Logger, doc any) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		baseHandler: baseHandler{logger},
+		doc:         doc,
+	}
+}
+
+func (h *OpenAPIHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h.writeJSON(w, http.StatusOK, h.doc, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "%s", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+type DocsHandler struct {
+	baseHandler
+	title   string
+	specURL string
+}
+
+func NewDocsHandler(logger *slog.Logger, title, specURL string) *DocsHandler {
+	return &DocsHandler{
+		baseHandler: baseHandler{logger},
+		title:       title,
+		specURL:     specURL,
+	}
+}
+
+func (h *DocsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, docsPage, html.EscapeString(h.title), html.EscapeString(h.specURL))
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -12,10 +11,10 @@ import (
 
 type PostRankHandler struct {
 	baseHandler
-	uc *usecase.CreateRankUsecase
+	uc usecase.Usecase[usecase.CreateRankInput, usecase.CreateRankOutput]
 }
 
-func NewPostRankHandler(logger *slog.Logger, uc *usecase.CreateRankUsecase) *PostRankHandler {
+func NewPostRankHandler(logger *slog.Logger, uc usecase.Usecase[usecase.CreateRankInput, usecase.CreateRankOutput]) *PostRankHandler {
 	return &PostRankHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -39,7 +38,7 @@ func (h *PostRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	output, err := h.uc.Execute(r.Context(), rank)
 	if err != nil {
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusCreated, output, nil); err != nil {
@@ -49,10 +48,10 @@ func (h *PostRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type GetRankHandler struct {
 	baseHandler
-	uc *usecase.FindRankUsecase
+	uc usecase.Usecase[usecase.FindRankInput, usecase.FindRankOutput]
 }
 
-func NewGetRankHandler(logger *slog.Logger, uc *usecase.FindRankUsecase) *GetRankHandler {
+func NewGetRankHandler(logger *slog.Logger, uc usecase.Usecase[usecase.FindRankInput, usecase.FindRankOutput]) *GetRankHandler {
 	return &GetRankHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -65,12 +64,7 @@ func (h *GetRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.badRequestResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -80,10 +74,10 @@ func (h *GetRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type PutRankHandler struct {
 	baseHandler
-	uc *usecase.UpdateRankUsecase
+	uc usecase.Usecase[usecase.UpdateRankInput, usecase.UpdateRankOutput]
 }
 
-func NewPutRankHandler(logger *slog.Logger, uc *usecase.UpdateRankUsecase) *PutRankHandler {
+func NewPutRankHandler(logger *slog.Logger, uc usecase.Usecase[usecase.UpdateRankInput, usecase.UpdateRankOutput]) *PutRankHandler {
 	return &PutRankHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -112,12 +106,39 @@ func (h *PutRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 	output, err := h.uc.Execute(r.Context(), rank)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
 		h.serverErrorResponse(w, r, err)
+	}
+}
+
+type PatchRankHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.PatchRankInput, usecase.PatchRankOutput]
+}
+
+func NewPatchRankHandler(logger *slog.Logger, uc usecase.Usecase[usecase.PatchRankInput, usecase.PatchRankOutput]) *PatchRankHandler {
+	return &PatchRankHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PatchRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	patch, err := h.readMergePatch(w, r)
+	if err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	input := usecase.PatchRankInput{
+		Id:    r.PathValue("id"),
+		Patch: patch,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -127,10 +148,10 @@ func (h *PutRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 type DeleteRankHandler struct {
 	baseHandler
-	uc *usecase.DeleteRankUsecase
+	uc usecase.Usecase[usecase.DeleteRankInput, usecase.DeleteRankOutput]
 }
 
-func NewDeleteRankHandler(logger *slog.Logger, uc *usecase.DeleteRankUsecase) *DeleteRankHandler {
+func NewDeleteRankHandler(logger *slog.Logger, uc usecase.Usecase[usecase.DeleteRankInput, usecase.DeleteRankOutput]) *DeleteRankHandler {
 	return &DeleteRankHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -142,12 +163,7 @@ func (h *DeleteRankHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Id: r.PathValue("id"),
 	}
 	if _, err := h.uc.Execute(r.Context(), input); err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	data := map[string]any{
@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
 	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
@@ -109,7 +111,7 @@ func TestGetRankHandler(t *testing.T) {
 func TestPutRankHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	repo := &inmemory.RankInMemoryRepository{}
-	uc := usecase.NewUpdateRankUsecase(repo)
+	uc := usecase.NewUpdateRankUsecase(repo, usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	h := NewPutRankHandler(logger, uc)
 	rank := mock.Rank
 	t.Run("ServeHTTP", func(t *testing.T) {
@@ -164,7 +166,7 @@ func TestPutRankHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"rank not found: 9812bc0e-129b-42b6-896d-48c798168160"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"rank not found: 9812bc0e-129b-42b6-896d-48c798168160","instance":"/rank/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -221,7 +223,7 @@ func TestDeleteRankHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"rank not found: 1ac85e34-cb6f-40c9-97bb-16267877bb13"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"rank not found: 1ac85e34-cb6f-40c9-97bb-16267877bb13","instance":"/rank/%7Bid%7D","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -1,19 +1,23 @@
 package handler
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
 
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
 )
 
+const (
+	publicCacheControl  = "public, max-age=60"
+	privateCacheControl = "private, no-store"
+)
+
 type GetRankTableHandler struct {
 	baseHandler
-	uc *usecase.FindRankTableUsecase
+	uc usecase.Usecase[usecase.FindRankTableInput, usecase.FindRankTableOutput]
 }
 
-func NewGetRankTableHandler(logger *slog.Logger, uc *usecase.FindRankTableUsecase) *GetRankTableHandler {
+func NewGetRankTableHandler(logger *slog.Logger, uc usecase.Usecase[usecase.FindRankTableInput, usecase.FindRankTableOutput]) *GetRankTableHandler {
 	return &GetRankTableHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
@@ -26,15 +30,16 @@ func (h *GetRankTableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	}
 	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		var notFoundErr *usecase.ResourceNotFoundError
-		if errors.As(err, &notFoundErr) {
-			h.notFoundResponse(w, r, err)
-			return
-		}
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
-	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+	headers := http.Header{}
+	if output.Public {
+		headers.Set("Cache-Control", publicCacheControl)
+	} else {
+		headers.Set("Cache-Control", privateCacheControl)
+	}
+	if err := h.writeConditionalJSON(w, r, output, output.UpdatedAt, headers); err != nil {
 		h.serverErrorResponse(w, r, err)
 	}
 }
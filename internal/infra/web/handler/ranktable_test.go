@@ -5,17 +5,21 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/josimarz/ranking-backend/internal/domain/entity"
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
 	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+	"github.com/josimarz/ranking-backend/internal/infra/storage"
 	"github.com/josimarz/ranking-backend/internal/mock"
 )
 
 func TestGetRankTableHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	repo := &inmemory.RankTableInMemoryRepository{}
-	uc := usecase.NewFindRankTableUsecase(repo)
+	uc := usecase.NewFindRankTableUsecase(repo, usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute))
 	h := NewGetRankTableHandler(logger, uc)
 	mockRankTable(context.Background())
 	t.Run("ServeHTTP", func(t *testing.T) {
@@ -30,11 +34,63 @@ func TestGetRankTableHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusOK {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
 			}
-			want := `{"id":"1ac85e34-cb6f-40c9-97bb-16267877bb13","name":"Video Game Consoles","public":true,"attributes":[{"id":"be44503b-1fac-4d5a-aae0-0239159bdc4a","name":"Controls","description":"Evaluate the quality and accessibility of controls","order":1},{"id":"53e1515d-7fed-4d94-8b36-4cd49b2f11be","name":"Graphics","description":"Evaluate the graphics capacity of the console","order":2},{"id":"b2ac5f2c-a65c-4eb8-a0e1-a66a6bea4aac","name":"Sound","description":"Evaluate the sound capacity of the console","order":3}],"entries":[{"id":"d10961ca-e9ed-4d3b-b086-f756a3118894","name":"Neo Geo CD","image_url":"https://videogame.com/neo-geo-cd.png","scores":{"Controls":90,"Graphics":97,"Sound":97}},{"id":"e006f3be-88a4-4891-8c8e-f1de6d6b5324","name":"Nintendo Entertainment System","image_url":"https://videogame.com/nes.png","scores":{"Controls":70,"Graphics":72,"Sound":70}},{"id":"da2b4fc6-f933-4214-b742-4f199aec2481","name":"Sega Master System","image_url":"https://videogame.com/sms.png","scores":{"Controls":73,"Graphics":78,"Sound":76}},{"id":"25658fa3-6721-42ae-8e25-7ba9c8f1cd85","name":"Sega Mega Drive","image_url":"https://videogame.com/smd.png","scores":{"Controls":80,"Graphics":84,"Sound":83}},{"id":"959c559e-db6a-4c4a-9164-f3eab305e076","name":"Super Nintendo Entertainment System","image_url":"https://videogame.com/snes.png","scores":{"Controls":84,"Graphics":89,"Sound":87}}]}`
+			want := `{"id":"1ac85e34-cb6f-40c9-97bb-16267877bb13","name":"Video Game Consoles","public":true,"attributes":[{"id":"be44503b-1fac-4d5a-aae0-0239159bdc4a","name":"Controls","description":"Evaluate the quality and accessibility of controls","order":1},{"id":"53e1515d-7fed-4d94-8b36-4cd49b2f11be","name":"Graphics","description":"Evaluate the graphics capacity of the console","order":2},{"id":"b2ac5f2c-a65c-4eb8-a0e1-a66a6bea4aac","name":"Sound","description":"Evaluate the sound capacity of the console","order":3}],"entries":[{"id":"d10961ca-e9ed-4d3b-b086-f756a3118894","name":"Neo Geo CD","image_url":"https://videogame.com/neo-geo-cd.png","scores":{"Controls":90,"Graphics":97,"Sound":97},"cover_url":"https://videogame.com/neo-geo-cd.png","media_count":0},{"id":"e006f3be-88a4-4891-8c8e-f1de6d6b5324","name":"Nintendo Entertainment System","image_url":"https://videogame.com/nes.png","scores":{"Controls":70,"Graphics":72,"Sound":70},"cover_url":"https://videogame.com/nes.png","media_count":0},{"id":"da2b4fc6-f933-4214-b742-4f199aec2481","name":"Sega Master System","image_url":"https://videogame.com/sms.png","scores":{"Controls":73,"Graphics":78,"Sound":76},"cover_url":"https://videogame.com/sms.png","media_count":0},{"id":"25658fa3-6721-42ae-8e25-7ba9c8f1cd85","name":"Sega Mega Drive","image_url":"https://videogame.com/smd.png","scores":{"Controls":80,"Graphics":84,"Sound":83},"cover_url":"https://videogame.com/smd.png","media_count":0},{"id":"959c559e-db6a-4c4a-9164-f3eab305e076","name":"Super Nintendo Entertainment System","image_url":"https://videogame.com/snes.png","scores":{"Controls":84,"Graphics":89,"Sound":87},"cover_url":"https://videogame.com/snes.png","media_count":0}]}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
 		})
+		t.Run("304", func(t *testing.T) {
+			send := func(header, value string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest("GET", "/rank/{id}/table", nil)
+				req.SetPathValue("id", "1ac85e34-cb6f-40c9-97bb-16267877bb13")
+				if header != "" {
+					req.Header.Set(header, value)
+				}
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+				return rr
+			}
+			first := send("", "")
+			etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+			if !strings.HasPrefix(etag, `"`) || lastModified == "" {
+				t.Fatalf("handler returned wrong validators: got ETag %q and Last-Modified %q", etag, lastModified)
+			}
+			if got := first.Header().Get("Cache-Control"); got != "public, max-age=60" {
+				t.Errorf("handler returned wrong Cache-Control header: got %v, want %v", got, "public, max-age=60")
+			}
+			tests := []struct {
+				header string
+				value  string
+				want   int
+			}{
+				{"If-None-Match", etag, http.StatusNotModified},
+				{"If-None-Match", `"other", W/` + etag, http.StatusNotModified},
+				{"If-None-Match", "*", http.StatusNotModified},
+				{"If-None-Match", `"other"`, http.StatusOK},
+				{"If-Modified-Since", lastModified, http.StatusNotModified},
+				{"If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), http.StatusOK},
+			}
+			for _, test := range tests {
+				rr := send(test.header, test.value)
+				if rr.Code != test.want {
+					t.Errorf("handler returned wrong status code for %v: %v: got %v, want %v", test.header, test.value, rr.Code, test.want)
+				}
+				if rr.Code == http.StatusNotModified && (rr.Body.Len() != 0 || rr.Header().Get("ETag") != etag) {
+					t.Errorf("handler returned wrong 304 response for %v: %v: body %q, ETag %q", test.header, test.value, rr.Body.String(), rr.Header().Get("ETag"))
+				}
+			}
+		})
+		t.Run("private", func(t *testing.T) {
+			rank := entity.Rank{Id: "c0c8a1a4-6fb6-4c34-8d0c-8d1f3e7b9a51", Name: "Private"}
+			(&inmemory.RankInMemoryRepository{}).Create(context.Background(), &rank)
+			req := httptest.NewRequest("GET", "/rank/{id}/table", nil)
+			req.SetPathValue("id", rank.Id)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if got := rr.Header().Get("Cache-Control"); got != "private, no-store" {
+				t.Errorf("handler returned wrong Cache-Control header: got %v, want %v", got, "private, no-store")
+			}
+		})
 		t.Run("404", func(t *testing.T) {
 			req, err := http.NewRequest("GET", "/rank/{id}/table", nil)
 			if err != nil {
@@ -46,7 +102,7 @@ func TestGetRankTableHandler(t *testing.T) {
 			if status := rr.Code; status != http.StatusNotFound {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusNotFound)
 			}
-			want := `{"error":"rank not found: 6a4984c3-8a2e-4aba-86fb-7154b1ac2d15"}`
+			want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"rank not found: 6a4984c3-8a2e-4aba-86fb-7154b1ac2d15","instance":"/rank/%7Bid%7D/table","code":"not_found"}`
 			if body := rr.Body.String(); body != want {
 				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
 			}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+)
+
+type PostScoresBatchHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.BatchScoresInput, usecase.BatchScoresOutput]
+}
+
+func NewPostScoresBatchHandler(logger *slog.Logger, uc usecase.Usecase[usecase.BatchScoresInput, usecase.BatchScoresOutput]) *PostScoresBatchHandler {
+	return &PostScoresBatchHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PostScoresBatchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Changes []usecase.ScoreChange `json:"changes"`
+	}
+	if err := h.readJSON(w, r, &body); err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	input := usecase.BatchScoresInput{
+		RankId:  r.PathValue("id"),
+		Changes: body.Changes,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
+)
+
+func TestPostScoresBatchHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	uc := usecase.NewBatchScoresUsecase(&inmemory.RankTableInMemoryRepository{}, &inmemory.EntryInMemoryRepository{})
+	h := NewPostScoresBatchHandler(logger, uc)
+	mockRankTable(context.Background())
+	send := func(buf []byte) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("POST", "/rank/{id}/scores:batch", bytes.NewBuffer(buf))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.SetPathValue("id", "1ac85e34-cb6f-40c9-97bb-16267877bb13")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	t.Run("ServeHTTP", func(t *testing.T) {
+		t.Run("200", func(t *testing.T) {
+			rr := send([]byte(`{
+				"changes": [
+					{"entry_id": "d10961ca-e9ed-4d3b-b086-f756a3118894", "attribute": "Controls", "score": 92},
+					{"entry_id": "e006f3be-88a4-4891-8c8e-f1de6d6b5324", "attribute": "Sound", "score": 71}
+				]
+			}`))
+			if status := rr.Code; status != http.StatusOK {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusOK)
+			}
+			want := `{"rank_id":"1ac85e34-cb6f-40c9-97bb-16267877bb13","applied":2,"failed":0,"results":[{"entry_id":"d10961ca-e9ed-4d3b-b086-f756a3118894","attribute":"Controls","score":92,"status":"applied"},{"entry_id":"e006f3be-88a4-4891-8c8e-f1de6d6b5324","attribute":"Sound","score":71,"status":"applied"}]}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+		t.Run("422", func(t *testing.T) {
+			rr := send([]byte(`{"changes": [{"entry_id": "d10961ca-e9ed-4d3b-b086-f756a3118894", "attribute": "Speed", "score": 50}]}`))
+			if status := rr.Code; status != http.StatusUnprocessableEntity {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnprocessableEntity)
+			}
+			want := `{"type":"/problems/validation-failed","title":"Unprocessable Entity","status":422,"detail":"the request contains invalid fields","instance":"/rank/%7Bid%7D/scores:batch","code":"validation_failed","errors":[{"field":"changes[0].attribute","message":"must be an attribute of the rank"}]}`
+			if body := rr.Body.String(); body != want {
+				t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+			}
+		})
+	})
+}
@@ -2,51 +2,148 @@ package handler
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
 )
 
+// multipartOverhead is allowed on top of UploadConfig.MaxBytes for the
+// boundaries and headers of the form.
+const multipartOverhead = 64 << 10
+
+type UploadConfig struct {
+	// MaxBytes is the largest file accepted.
+	MaxBytes int64
+	// AllowedTypes are the MIME types accepted, as sniffed from the content
+	// of the file. SVG must not be allowed, as it may carry scripts.
+	AllowedTypes []string
+}
+
+func DefaultUploadConfig() UploadConfig {
+	return UploadConfig{
+		MaxBytes:     10 << 20,
+		AllowedTypes: []string{"image/jpeg", "image/png", "image/gif", "image/webp"},
+	}
+}
+
 type PostFileHandler struct {
 	baseHandler
-	uc *usecase.UploadUsecase
+	uc  usecase.Usecase[usecase.UploadInput, usecase.UploadOutput]
+	cfg UploadConfig
 }
 
-func NewPostFileHandler(logger *slog.Logger, uc *usecase.UploadUsecase) *PostFileHandler {
+func NewPostFileHandler(logger *slog.Logger, uc usecase.Usecase[usecase.UploadInput, usecase.UploadOutput], cfg UploadConfig) *PostFileHandler {
 	return &PostFileHandler{
 		baseHandler: baseHandler{logger},
 		uc:          uc,
+		cfg:         cfg,
 	}
 }
 
 func (h *PostFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	r.ParseMultipartForm(10 << 20)
-	file, handler, err := r.FormFile("image")
+	r.Body = http.MaxBytesReader(w, r.Body, h.cfg.MaxBytes+multipartOverhead)
+	buf, err := h.readImage(r)
 	if err != nil {
 		h.badRequestResponse(w, r, err)
 		return
 	}
-	defer file.Close()
-	buf, err := io.ReadAll(file)
+	if contentType := http.DetectContentType(buf); !slices.Contains(h.cfg.AllowedTypes, contentType) {
+		msg := fmt.Sprintf("image must be of type %v", strings.Join(h.cfg.AllowedTypes, ", "))
+		h.unsupportedMediaTypeResponse(w, r, msg)
+		return
+	}
+	input := usecase.UploadInput{
+		RankId: r.PathValue("id"),
+		File:   bytes.NewReader(buf),
+	}
+	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		h.badRequestResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
-	if !h.isImage(buf) {
-		h.errorResponse(w, r, http.StatusUnsupportedMediaType, "invalid file type")
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
+}
+
+// readImage reads the image field of the multipart form without buffering
+// the other fields.
+func (h *PostFileHandler) readImage(r *http.Request) ([]byte, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, &requestError{http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, "Content-Type must be multipart/form-data"}
+	}
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			return nil, &requestError{http.StatusBadRequest, problem.CodeBadRequest, "body must contain an image field"}
+		}
+		if err != nil {
+			return nil, h.multipartError(err)
+		}
+		if part.FormName() != "image" {
+			continue
+		}
+		buf, err := io.ReadAll(io.LimitReader(part, h.cfg.MaxBytes+1))
+		if err != nil {
+			return nil, h.multipartError(err)
+		}
+		if int64(len(buf)) > h.cfg.MaxBytes {
+			return nil, h.tooLargeError()
+		}
+		return buf, nil
+	}
+}
+
+func (h *PostFileHandler) multipartError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return h.tooLargeError()
+	}
+	return &requestError{http.StatusBadRequest, problem.CodeBadRequest, "body is not a valid multipart form"}
+}
+
+func (h *PostFileHandler) tooLargeError() error {
+	msg := fmt.Sprintf("image must not be larger than %d bytes", h.cfg.MaxBytes)
+	return &requestError{http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge, msg}
+}
+
+type PostFilePresignHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.PresignUploadInput, usecase.PresignUploadOutput]
+}
+
+func NewPostFilePresignHandler(logger *slog.Logger, uc usecase.Usecase[usecase.PresignUploadInput, usecase.PresignUploadOutput]) *PostFilePresignHandler {
+	return &PostFilePresignHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PostFilePresignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ContentType string `json:"content_type"`
+		Size        int64  `json:"size"`
+	}
+	if err := h.readJSON(w, r, &body); err != nil {
+		h.badRequestResponse(w, r, err)
 		return
 	}
-	input := usecase.UploadInput{
-		RankId:   r.PathValue("id"),
-		Filename: handler.Filename,
-		File:     bytes.NewReader(buf),
+	input := usecase.PresignUploadInput{
+		RankId:      r.PathValue("id"),
+		ContentType: body.ContentType,
+		Size:        body.Size,
 	}
 	output, err := h.uc.Execute(r.Context(), input)
 	if err != nil {
-		h.serverErrorResponse(w, r, err)
+		h.errorResponse(w, r, err)
 		return
 	}
 	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
@@ -54,7 +151,36 @@ func (h *PostFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (*PostFileHandler) isImage(buf []byte) bool {
-	contentType := http.DetectContentType(buf)
-	return strings.HasPrefix(contentType, "image/")
+type PostFileConfirmHandler struct {
+	baseHandler
+	uc usecase.Usecase[usecase.ConfirmUploadInput, usecase.UploadOutput]
+}
+
+func NewPostFileConfirmHandler(logger *slog.Logger, uc usecase.Usecase[usecase.ConfirmUploadInput, usecase.UploadOutput]) *PostFileConfirmHandler {
+	return &PostFileConfirmHandler{
+		baseHandler: baseHandler{logger},
+		uc:          uc,
+	}
+}
+
+func (h *PostFileConfirmHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		UploadId string `json:"upload_id"`
+	}
+	if err := h.readJSON(w, r, &body); err != nil {
+		h.badRequestResponse(w, r, err)
+		return
+	}
+	input := usecase.ConfirmUploadInput{
+		RankId:   r.PathValue("id"),
+		UploadId: body.UploadId,
+	}
+	output, err := h.uc.Execute(r.Context(), input)
+	if err != nil {
+		h.errorResponse(w, r, err)
+		return
+	}
+	if err := h.writeJSON(w, http.StatusOK, output, nil); err != nil {
+		h.serverErrorResponse(w, r, err)
+	}
 }
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"io"
 	"log"
@@ -11,16 +12,19 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/josimarz/ranking-backend/internal/domain/usecase"
+	"github.com/josimarz/ranking-backend/internal/imaging"
+	"github.com/josimarz/ranking-backend/internal/infra/db/inmemory"
 	"github.com/josimarz/ranking-backend/internal/infra/storage"
 )
 
 func TestPostFileHandler(t *testing.T) {
 	logger := slog.New(slog.DiscardHandler)
 	storage := storage.NewInMemoryStorage()
-	uc := usecase.NewUploadUsecase(storage)
-	h := NewPostFileHandler(logger, uc)
+	uc := usecase.NewUploadUsecase(storage, imaging.DefaultVariants(), imaging.DefaultLimits(), usecase.NewImageSigner(storage, nil, &inmemory.RankInMemoryRepository{}, time.Minute))
+	h := NewPostFileHandler(logger, uc, DefaultUploadConfig())
 	t.Run("ServeHTTP", func(t *testing.T) {
 		t.Run("200", func(t *testing.T) {
 			body := new(bytes.Buffer)
@@ -100,6 +104,123 @@ func TestPostFileHandler(t *testing.T) {
 				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnsupportedMediaType)
 			}
 		})
+		t.Run("415 svg", func(t *testing.T) {
+			svg := `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`
+			rr := postForm(t, h, "image", strings.NewReader(svg))
+			if status := rr.Code; status != http.StatusUnsupportedMediaType {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnsupportedMediaType)
+			}
+		})
+		t.Run("415 not multipart", func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/rank/{id}/file", getFile())
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "image/png")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if status := rr.Code; status != http.StatusUnsupportedMediaType {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusUnsupportedMediaType)
+			}
+		})
+		t.Run("413", func(t *testing.T) {
+			h := NewPostFileHandler(logger, uc, UploadConfig{MaxBytes: 32, AllowedTypes: DefaultUploadConfig().AllowedTypes})
+			rr := postForm(t, h, "image", getFile())
+			if status := rr.Code; status != http.StatusRequestEntityTooLarge {
+				t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusRequestEntityTooLarge)
+			}
+			rr = postForm(t, h, "other", strings.NewReader(strings.Repeat("x", multipartOverhead+64)))
+			if status := rr.Code; status != http.StatusRequestEntityTooLarge {
+				t.Errorf("handler returned wrong status code for a large form: got %v, want %v", status, http.StatusRequestEntityTooLarge)
+			}
+		})
+	})
+}
+
+func postForm(t *testing.T, h http.Handler, field string, content io.Reader) *httptest.ResponseRecorder {
+	t.Helper()
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest("POST", "/rank/{id}/file", body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.SetPathValue("id", "910cbfa5-526f-4781-8e89-76d0a35ca861")
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPostFilePresignHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	h := NewPostFilePresignHandler(logger, usecase.NewPresignUploadUsecase(storage.NewInMemoryStorage(), usecase.NewImageSigner(storage.NewInMemoryStorage(), nil, &inmemory.RankInMemoryRepository{}, time.Minute)))
+	t.Run("ServeHTTP", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			body string
+			want int
+		}{
+			"200": {`{"content_type":"image/png","size":1024}`, http.StatusOK},
+			"400": {`{"content_type":`, http.StatusBadRequest},
+			"422": {`{"content_type":"text/html","size":1024}`, http.StatusUnprocessableEntity},
+		} {
+			t.Run(name, func(t *testing.T) {
+				req, err := http.NewRequest("POST", "/rank/{id}/file:presign", strings.NewReader(tc.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.SetPathValue("id", "910cbfa5-526f-4781-8e89-76d0a35ca861")
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+				if status := rr.Code; status != tc.want {
+					t.Errorf("handler returned wrong status code: got %v, want %v", status, tc.want)
+				}
+			})
+		}
+	})
+}
+
+func TestPostFileConfirmHandler(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	files := storage.NewInMemoryStorage()
+	rankId := "910cbfa5-526f-4781-8e89-76d0a35ca861"
+	uploadId := "6b7f1d6e-5a63-4c8f-9d0e-2f1b3c4d5e6f"
+	if _, err := files.Upload(context.Background(), rankId+"/"+uploadId+"/original.png", getFile(), storage.UploadOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	h := NewPostFileConfirmHandler(logger, usecase.NewConfirmUploadUsecase(usecase.NewUploadUsecase(files, imaging.DefaultVariants(), imaging.DefaultLimits(), usecase.NewImageSigner(files, nil, &inmemory.RankInMemoryRepository{}, time.Minute))))
+	t.Run("ServeHTTP", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			body string
+			want int
+		}{
+			"200": {`{"upload_id":"` + uploadId + `"}`, http.StatusOK},
+			"404": {`{"upload_id":"7e6d5c4b-3a29-4180-9f8e-7d6c5b4a3928"}`, http.StatusNotFound},
+			"422": {`{"upload_id":"invalid"}`, http.StatusUnprocessableEntity},
+		} {
+			t.Run(name, func(t *testing.T) {
+				req, err := http.NewRequest("POST", "/rank/{id}/file:confirm", strings.NewReader(tc.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				req.SetPathValue("id", rankId)
+				rr := httptest.NewRecorder()
+				h.ServeHTTP(rr, req)
+				if status := rr.Code; status != tc.want {
+					t.Errorf("handler returned wrong status code: got %v, want %v", status, tc.want)
+				}
+			})
+		}
 	})
 }
 
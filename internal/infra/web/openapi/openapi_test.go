@@ -0,0 +1,67 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type child struct {
+	Value int `json:"value"`
+}
+
+type parent struct {
+	Name     string            `json:"name"`
+	Optional string            `json:"optional,omitempty"`
+	Tags     []string          `json:"tags"`
+	Scores   map[string]int    `json:"scores"`
+	Child    *child            `json:"child"`
+	Children []child           `json:"children"`
+	Labels   map[string]string `json:"-"`
+}
+
+func TestDocument(t *testing.T) {
+	doc := New(Info{Title: "Test", Version: "1.0.0"})
+	doc.Add("GET /parent/{parentId}/child/{id}", &Operation{
+		OperationId: "findChild",
+		Responses: map[string]*Response{
+			"200": doc.JSONResponse("ok", parent{}),
+		},
+	})
+	t.Run("Has", func(t *testing.T) {
+		if !doc.Has("GET /parent/{parentId}/child/{id}") {
+			t.Error("Has() got false, want true")
+		}
+		if doc.Has("DELETE /parent/{parentId}/child/{id}") {
+			t.Error("Has() got true, want false")
+		}
+	})
+	t.Run("Parameters", func(t *testing.T) {
+		op := (*doc.Paths["/parent/{parentId}/child/{id}"])["get"]
+		if len(op.Parameters) != 2 || op.Parameters[0].Name != "parentId" || op.Parameters[1].Name != "id" {
+			t.Errorf("operation got wrong parameters: %v", op.Parameters)
+		}
+	})
+	t.Run("Wildcard", func(t *testing.T) {
+		doc.Add("GET /files/{path...}", &Operation{OperationId: "getFile"})
+		if !doc.Has("GET /files/{path...}") {
+			t.Error("Has() got false, want true")
+		}
+		op, ok := (*doc.Paths["/files/{path}"])["get"]
+		if !ok || len(op.Parameters) != 1 || op.Parameters[0].Name != "path" {
+			t.Errorf("operation got wrong parameters: %v", op)
+		}
+	})
+	t.Run("SchemaOf", func(t *testing.T) {
+		got, err := json.Marshal(doc.Components.Schemas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"Child":{"type":"object","properties":{"value":{"type":"integer"}},"required":["value"]},` +
+			`"Parent":{"type":"object","properties":{"child":{"$ref":"#/components/schemas/Child"},"children":{"type":"array","items":{"$ref":"#/components/schemas/Child"}},` +
+			`"name":{"type":"string"},"optional":{"type":"string"},"scores":{"type":"object","additionalProperties":{"type":"integer"}},"tags":{"type":"array","items":{"type":"string"}}},` +
+			`"required":["name","tags","scores","child","children"]}}`
+		if string(got) != want {
+			t.Errorf("SchemaOf() got %s, want %s", got, want)
+		}
+	})
+}
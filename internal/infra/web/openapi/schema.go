@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (d *Document) SchemaOf(v any) *Schema {
+	if s, ok := v.(*Schema); ok {
+		return s
+	}
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	s := d.schemaOfType(t)
+	if typ, ok := s.Type.(string); ok && nullable {
+		s.Type = []string{typ, "null"}
+	}
+	return s
+}
+
+func (d *Document) schemaOfType(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return d.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	name := schemaName(t)
+	if name != "" {
+		if _, ok := d.Components.Schemas[name]; ok {
+			return &Schema{Ref: "#/components/schemas/" + name}
+		}
+		d.Components.Schemas[name] = &Schema{}
+	}
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		key, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && key == "" && f.Type.Kind() == reflect.Struct {
+			embedded := d.inlineSchema(f.Type)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if key == "" {
+			key = f.Name
+		}
+		s.Properties[key] = d.schemaOf(f.Type)
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			s.Required = append(s.Required, key)
+		}
+	}
+	if name == "" {
+		return s
+	}
+	d.Components.Schemas[name] = s
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (d *Document) inlineSchema(t reflect.Type) *Schema {
+	s := d.structSchema(t)
+	if s.Ref == "" {
+		return s
+	}
+	return d.Components.Schemas[schemaName(t)]
+}
+
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	if name == "" {
+		return ""
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
@@ -0,0 +1,65 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	CodeBadRequest           = "bad_request"
+	CodeValidationFailed     = "validation_failed"
+	CodeNotFound             = "not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeConflict             = "conflict"
+	CodeForbidden            = "forbidden"
+	CodeRequestTooLarge      = "request_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeRateLimited          = "rate_limited"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+	CodeInternalError        = "internal_error"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
+
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "/problems/" + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func (p *Problem) Write(w http.ResponseWriter, r *http.Request, headers http.Header) error {
+	if p.Instance == "" {
+		p.Instance = r.URL.RequestURI()
+	}
+	output, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	for key, values := range headers {
+		w.Header()[key] = values
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	w.Write(output)
+	return nil
+}
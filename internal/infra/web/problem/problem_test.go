@@ -0,0 +1,31 @@
+package problem
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProblem(t *testing.T) {
+	p := New(http.StatusNotFound, CodeNotFound, "rank not found: 1")
+	t.Run("Write", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/rank/1?x=y", nil)
+		rr := httptest.NewRecorder()
+		if err := p.Write(rr, req, http.Header{"Cache-Control": {"no-store"}}); err != nil {
+			t.Fatal(err)
+		}
+		if status := rr.Code; status != http.StatusNotFound {
+			t.Errorf("Write() wrote wrong status code: got %v, want %v", status, http.StatusNotFound)
+		}
+		if got := rr.Header().Get("Content-Type"); got != ContentType {
+			t.Errorf("Write() wrote wrong content type: got %v, want %v", got, ContentType)
+		}
+		if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+			t.Errorf("Write() wrote wrong cache control: got %v, want %v", got, "no-store")
+		}
+		want := `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"rank not found: 1","instance":"/rank/1?x=y","code":"not_found"}`
+		if body := rr.Body.String(); body != want {
+			t.Errorf("Write() wrote wrong body: got %v, want %v", body, want)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	encodingGzip = "gzip"
+	encodingZstd = "zstd"
+)
+
+type CompressConfig struct {
+	// MinSize is the smallest body, in bytes, worth compressing.
+	MinSize int
+	// Encodings lists the supported encodings by order of preference.
+	Encodings []string
+	// SkipContentTypes lists media types, or type/ prefixes, that are
+	// already compressed.
+	SkipContentTypes []string
+}
+
+func DefaultCompressConfig() CompressConfig {
+	return CompressConfig{
+		MinSize:   1024,
+		Encodings: []string{encodingZstd, encodingGzip},
+		SkipContentTypes: []string{
+			"image/",
+			"video/",
+			"audio/",
+			"font/woff2",
+			"application/gzip",
+			"application/zip",
+			"application/zstd",
+			"application/octet-stream",
+		},
+	}
+}
+
+var (
+	gzipWriters = sync.Pool{New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}}
+	zstdWriters = sync.Pool{New: func() any {
+		w, _ := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		return w
+	}}
+)
+
+// encoder is the common part of gzip.Writer and zstd.Encoder.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// Compress encodes response bodies with the encoding the client prefers among
+// cfg.Encodings. Bodies smaller than cfg.MinSize are sent as they are.
+func Compress(cfg CompressConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), cfg.Encodings)
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			cw := &compressWriter{ResponseWriter: w, cfg: cfg, encoding: encoding}
+			// close is not deferred so that, on panic, a body still in the
+			// buffer is dropped and Recover can write its own response.
+			next.ServeHTTP(cw, r)
+			cw.close()
+		})
+	}
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality
+// in the Accept-Encoding header, breaking ties by the order of supported.
+func negotiateEncoding(header string, supported []string) string {
+	if header == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		qualities[name] = q
+	}
+	best, bestQ := "", 0.0
+	for _, encoding := range supported {
+		q, ok := qualities[encoding]
+		if !ok {
+			q, ok = qualities["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the first cfg.MinSize bytes of the body to decide
+// whether the response is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	cfg      CompressConfig
+	encoding string
+	status   int
+	buf      bytes.Buffer
+	decided  bool
+	enc      encoder
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buf.Write(b)
+		if w.buf.Len() < w.cfg.MinSize {
+			return len(b), nil
+		}
+		if err := w.decide(w.compressible()); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(w.buf.Len() >= w.cfg.MinSize && w.compressible())
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *compressWriter) compressible() bool {
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || strings.Contains(h.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+	mediaType, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	return !slices.ContainsFunc(w.cfg.SkipContentTypes, func(skip string) bool {
+		if strings.HasSuffix(skip, "/") {
+			return strings.HasPrefix(mediaType, skip)
+		}
+		return mediaType == skip
+	})
+}
+
+// decide writes the header and the buffered body, through an encoder when
+// compress is true.
+func (w *compressWriter) decide(compress bool) error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if compress {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		// The encoded body is a different representation, so a strong
+		// validator of the identity body would be wrong for it.
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.enc = w.newEncoder()
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(w.buf.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressWriter) newEncoder() encoder {
+	var enc encoder
+	switch w.encoding {
+	case encodingZstd:
+		enc = zstdWriters.Get().(*zstd.Encoder)
+	default:
+		enc = gzipWriters.Get().(*gzip.Writer)
+	}
+	enc.Reset(w.ResponseWriter)
+	return enc
+}
+
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.status == 0 && w.buf.Len() == 0 {
+			// Nothing was written; let net/http send its default response.
+			return
+		}
+		w.decide(false)
+	}
+	if w.enc == nil {
+		return
+	}
+	w.enc.Close()
+	w.enc.Reset(io.Discard)
+	switch enc := w.enc.(type) {
+	case *zstd.Encoder:
+		zstdWriters.Put(enc)
+	case *gzip.Writer:
+		gzipWriters.Put(enc)
+	}
+	w.enc = nil
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompress(t *testing.T) {
+	large := `{"name":"` + strings.Repeat("a", 2048) + `"}`
+	newHandler := func(contentType, body string) http.Handler {
+		return Compress(DefaultCompressConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("ETag", `"abc"`)
+			io.WriteString(w, body)
+		}))
+	}
+	send := func(h http.Handler, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/rank/1/table", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	t.Run("gzip", func(t *testing.T) {
+		rr := send(newHandler("application/json", large), "gzip, deflate")
+		if got := rr.Header().Get("Content-Encoding"); got != "gzip" {
+			t.Fatalf("handler returned wrong Content-Encoding header: got %q, want %q", got, "gzip")
+		}
+		zr, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if body, _ := io.ReadAll(zr); string(body) != large {
+			t.Errorf("decompressed body does not match the original one")
+		}
+		if got := rr.Header().Get("ETag"); got != `W/"abc"` {
+			t.Errorf("handler returned wrong ETag header: got %v, want %v", got, `W/"abc"`)
+		}
+		if got := rr.Header().Get("Vary"); got != "Accept-Encoding" {
+			t.Errorf("handler returned wrong Vary header: got %v, want %v", got, "Accept-Encoding")
+		}
+	})
+	t.Run("zstd", func(t *testing.T) {
+		rr := send(newHandler("application/json", large), "gzip, zstd")
+		if got := rr.Header().Get("Content-Encoding"); got != "zstd" {
+			t.Fatalf("handler returned wrong Content-Encoding header: got %q, want %q", got, "zstd")
+		}
+		zr, err := zstd.NewReader(rr.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		if body, _ := io.ReadAll(zr); string(body) != large {
+			t.Errorf("decompressed body does not match the original one")
+		}
+	})
+	t.Run("identity", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			contentType    string
+			body           string
+			acceptEncoding string
+		}{
+			{"no accept-encoding", "application/json", large, ""},
+			{"unsupported encoding", "application/json", large, "br"},
+			{"refused encodings", "application/json", large, "gzip;q=0, zstd;q=0"},
+			{"small body", "application/json", `{"name":"a"}`, "gzip"},
+			{"compressed content type", "image/png", large, "gzip"},
+		}
+		for _, tt := range tests {
+			rr := send(newHandler(tt.contentType, tt.body), tt.acceptEncoding)
+			if got := rr.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("%v: handler returned Content-Encoding %q, want none", tt.name, got)
+			}
+			if rr.Body.String() != tt.body {
+				t.Errorf("%v: handler returned wrong body", tt.name)
+			}
+		}
+	})
+	t.Run("not modified", func(t *testing.T) {
+		h := Compress(DefaultCompressConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotModified)
+		}))
+		rr := send(h, "gzip")
+		if rr.Code != http.StatusNotModified || rr.Header().Get("Content-Encoding") != "" || rr.Body.Len() != 0 {
+			t.Errorf("handler returned wrong response: got %v %q %q", rr.Code, rr.Header().Get("Content-Encoding"), rr.Body.String())
+		}
+	})
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	supported := []string{"zstd", "gzip"}
+	tests := map[string]string{
+		"":                       "",
+		"gzip":                   "gzip",
+		"gzip, zstd":             "zstd",
+		"zstd;q=0.5, gzip":       "gzip",
+		"*":                      "zstd",
+		"*;q=0.1, gzip;q=0.5":    "gzip",
+		"identity":               "",
+		"GZIP;q=1.0, zstd;q=0.0": "gzip",
+	}
+	for header, want := range tests {
+		if got := negotiateEncoding(header, supported); got != want {
+			t.Errorf("negotiateEncoding(%q) got %q, want %q", header, got, want)
+		}
+	}
+}
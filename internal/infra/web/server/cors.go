@@ -0,0 +1,147 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+		AllowedHeaders: []string{"Content-Type", "Authorization", "X-Request-Id", "Idempotency-Key"},
+		ExposedHeaders: []string{"X-Request-Id", "Idempotent-Replayed"},
+		MaxAge:         10 * time.Minute,
+	}
+}
+
+// ErrCORSWildcardCredentials is returned by CORSConfig.Validate for
+// configurations that would let any site make credentialed requests.
+var ErrCORSWildcardCredentials = errors.New("cors: credentials cannot be allowed for every origin")
+
+// Validate rejects allowing credentials along with the "*" origin.
+func (cfg CORSConfig) Validate() error {
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowedOrigins, "*") {
+		return ErrCORSWildcardCredentials
+	}
+	return nil
+}
+
+// CORS answers cross-origin requests as cfg allows. Credentials are never
+// allowed for the "*" origin, even when cfg was not validated.
+func CORS(cfg CORSConfig) Middleware {
+	wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+			origin := r.Header.Get("Origin")
+			if origin == "" || !cfg.isOriginAllowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if wildcard {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+			method := r.Header.Get("Access-Control-Request-Method")
+			if r.Method != http.MethodOptions || method == "" {
+				if len(cfg.ExposedHeaders) > 0 {
+					w.Header().Set("Access-Control-Expose-Headers", strings.Join(cfg.ExposedHeaders, ", "))
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			if !slices.Contains(cfg.AllowedMethods, method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(cfg.AllowedMethods, ", "))
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				if slices.Contains(cfg.AllowedHeaders, "*") {
+					w.Header().Set("Access-Control-Allow-Headers", headers)
+				} else {
+					w.Header().Set("Access-Control-Allow-Headers", strings.Join(cfg.AllowedHeaders, ", "))
+				}
+			}
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (cfg CORSConfig) isOriginAllowed(origin string) bool {
+	for _, allowed := range cfg.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+type optionsHandler struct {
+	methods []string
+}
+
+func (h *optionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Allow", strings.Join(h.methods, ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func optionsHandlers(handlers Handlers) Handlers {
+	paths := make(map[string]string)
+	methods := make(map[string][]string)
+	for pattern := range handlers {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			continue
+		}
+		key := normalizePath(path)
+		if _, ok := paths[key]; !ok {
+			paths[key] = path
+		}
+		methods[key] = append(methods[key], method)
+	}
+	options := make(Handlers)
+	for key, path := range paths {
+		if slices.Contains(methods[key], http.MethodOptions) {
+			continue
+		}
+		allow := append(methods[key], http.MethodOptions)
+		if slices.Contains(allow, http.MethodGet) {
+			allow = append(allow, http.MethodHead)
+		}
+		slices.Sort(allow)
+		options[http.MethodOptions+" "+path] = &optionsHandler{slices.Compact(allow)}
+	}
+	return options
+}
+
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "{$}" && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "{}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://app.example.com"}
+	cfg.AllowCredentials = true
+	cfg.MaxAge = time.Hour
+	s := NewServer(":8080", Handlers{
+		"GET /rank/{id}":                    &mockHandler{},
+		"PUT /rank/{id}":                    &mockHandler{},
+		"GET /rank/{rankId}/attribute/{id}": &mockHandler{},
+	}, DefaultConfig())
+	s.Use(CORS(cfg))
+	h := s.Handler()
+	t.Run("preflight", func(t *testing.T) {
+		for _, path := range []string{"/rank/1", "/rank/1/attribute/2"} {
+			req := httptest.NewRequest("OPTIONS", path, nil)
+			req.Header.Set("Origin", "https://app.example.com")
+			req.Header.Set("Access-Control-Request-Method", "PUT")
+			req.Header.Set("Access-Control-Request-Headers", "content-type")
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+			if status := rr.Code; status != http.StatusNoContent {
+				t.Errorf("handler returned wrong status code for %v: got %v, want %v", path, status, http.StatusNoContent)
+			}
+			want := map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "GET, POST, PUT, PATCH, DELETE",
+				"Access-Control-Allow-Headers":     "Content-Type, Authorization, X-Request-Id, Idempotency-Key",
+				"Access-Control-Max-Age":           "3600",
+			}
+			for key, value := range want {
+				if got := rr.Header().Get(key); got != value {
+					t.Errorf("handler returned wrong %v header for %v: got %v, want %v", key, path, got, value)
+				}
+			}
+		}
+	})
+	t.Run("allow", func(t *testing.T) {
+		req := httptest.NewRequest("OPTIONS", "/rank/1", nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got, want := rr.Header().Get("Allow"), "GET, HEAD, OPTIONS, PUT"; got != want {
+			t.Errorf("handler returned wrong Allow header: got %v, want %v", got, want)
+		}
+	})
+	t.Run("simple", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/rank/1", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("handler returned wrong Access-Control-Allow-Origin header: got %v, want %v", got, "https://app.example.com")
+		}
+		if got := rr.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-Id, Idempotent-Replayed" {
+			t.Errorf("handler returned wrong Access-Control-Expose-Headers header: got %v, want %v", got, "X-Request-Id, Idempotent-Replayed")
+		}
+	})
+	t.Run("disallowed origin", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/rank/1", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("handler returned wrong Access-Control-Allow-Origin header: got %v, want empty", got)
+		}
+	})
+	t.Run("wildcard", func(t *testing.T) {
+		cfg := DefaultCORSConfig()
+		cfg.AllowedOrigins = []string{"*"}
+		h := CORS(cfg)(&mockHandler{})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://any.example.com")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("handler returned wrong Access-Control-Allow-Origin header: got %v, want %v", got, "*")
+		}
+	})
+	t.Run("wildcard credentials", func(t *testing.T) {
+		cfg := DefaultCORSConfig()
+		cfg.AllowedOrigins = []string{"https://app.example.com", "*"}
+		cfg.AllowCredentials = true
+		if err := cfg.Validate(); !errors.Is(err, ErrCORSWildcardCredentials) {
+			t.Errorf("Validate() got %v, want %v", err, ErrCORSWildcardCredentials)
+		}
+		h := CORS(cfg)(&mockHandler{})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("handler returned wrong Access-Control-Allow-Origin header: got %v, want %v", got, "*")
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+			t.Errorf("handler returned wrong Access-Control-Allow-Credentials header: got %v, want empty", got)
+		}
+		cfg.AllowedOrigins = []string{"https://app.example.com"}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("Validate() got %v, want %v", err, nil)
+		}
+	})
+}
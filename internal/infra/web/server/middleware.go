@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+)
+
+type Middleware func(http.Handler) http.Handler
+
+type contextKey string
+
+const (
+	requestIdKey    = contextKey("request_id")
+	requestIdHeader = "X-Request-Id"
+	maxRequestIdLen = 128
+)
+
+func RequestIdFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIdKey).(string); ok {
+		return id
+	}
+	return ""
+}
+
+func RequestId() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(requestIdHeader)
+			if id == "" || len(id) > maxRequestIdLen {
+				id = uuid.NewString()
+			}
+			w.Header().Set(requestIdHeader, id)
+			ctx := context.WithValue(r.Context(), requestIdKey, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func AccessLog(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+			logger.InfoContext(r.Context(), "request completed",
+				"method", r.Method,
+				"uri", r.URL.RequestURI(),
+				"status", rw.Status(),
+				"latency", time.Since(start),
+				"bytes", rw.bytes,
+				"remote_addr", r.RemoteAddr,
+			)
+		})
+	}
+}
+
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logger.ErrorContext(r.Context(), fmt.Sprintf("panic: %v", rec), "method", r.Method, "uri", r.URL.RequestURI())
+				w.Header().Set("Connection", "close")
+				msg := "the server has encountered a problem and could not process your request"
+				problem.New(http.StatusInternalServerError, problem.CodeInternalError, msg).Write(w, r, nil)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(h slog.Handler) slog.Handler {
+	return &contextHandler{h}
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestIdFromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
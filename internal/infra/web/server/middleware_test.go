@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestId(t *testing.T) {
+	var got string
+	h := RequestId()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = RequestIdFromContext(r.Context())
+	}))
+	t.Run("incoming", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-Id", "abc-123")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got != "abc-123" {
+			t.Errorf("request id in context: got %v, want %v", got, "abc-123")
+		}
+		if header := rr.Header().Get("X-Request-Id"); header != "abc-123" {
+			t.Errorf("request id header: got %v, want %v", header, "abc-123")
+		}
+	})
+	t.Run("generated", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		if got == "" || rr.Header().Get("X-Request-Id") != got {
+			t.Errorf("request id was not generated: got %v, header %v", got, rr.Header().Get("X-Request-Id"))
+		}
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(NewContextHandler(slog.NewTextHandler(buf, nil)))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	})
+	h := RequestId()(AccessLog(logger)(next))
+	req := httptest.NewRequest("POST", "/rank", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	out := buf.String()
+	for _, want := range []string{"method=POST", "uri=/rank", "status=201", "bytes=5", "latency=", "request_id=abc-123"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("access log %q does not contain %q", out, want)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	h := Recover(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if status := rr.Code; status != http.StatusInternalServerError {
+		t.Errorf("handler returned wrong status code: got %v, want %v", status, http.StatusInternalServerError)
+	}
+	want := `{"type":"/problems/internal-error","title":"Internal Server Error","status":500,"detail":"the server has encountered a problem and could not process your request","instance":"/","code":"internal_error"}`
+	if body := strings.TrimSpace(rr.Body.String()); body != want {
+		t.Errorf("handler returned wrong body: got %v, want %v", body, want)
+	}
+}
+
+func TestServerUse(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	s := NewServer(":8080", Handlers{"GET /": &mockHandler{}}, DefaultConfig())
+	s.Use(mw("first"), mw("second"))
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got := strings.Join(order, ","); got != "first,second" {
+		t.Errorf("middlewares ran in wrong order: got %v, want %v", got, "first,second")
+	}
+}
+
+func TestContextHandler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(NewContextHandler(slog.NewTextHandler(buf, nil))).With("app", "api")
+	ctx := context.WithValue(context.Background(), requestIdKey, "abc-123")
+	logger.ErrorContext(ctx, "failed")
+	if out := buf.String(); !strings.Contains(out, "request_id=abc-123") || !strings.Contains(out, "app=api") {
+		t.Errorf("log record %q is missing context attributes", out)
+	}
+}
@@ -0,0 +1,266 @@
+package server
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
+)
+
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+type RateLimitConfig struct {
+	Read         Limit
+	Write        Limit
+	Upload       Limit
+	APIKeyHeader string
+	// APIKeys are the keys that get a bucket of their own. Requests with any
+	// other key are limited by client address, so that made up keys can not
+	// be used to get fresh buckets.
+	APIKeys    []string
+	TrustProxy bool
+	// TrustedProxies are the addresses of proxies, besides the one the
+	// request comes from, whose X-Forwarded-For entries are trusted.
+	TrustedProxies []netip.Prefix
+	Subject        func(*http.Request) string
+	Now            func() time.Time
+}
+
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Read:         Limit{Requests: 300, Period: time.Minute},
+		Write:        Limit{Requests: 60, Period: time.Minute},
+		Upload:       Limit{Requests: 10, Period: time.Minute},
+		APIKeyHeader: "X-Api-Key",
+		Now:          time.Now,
+	}
+}
+
+func RateLimit(cfg RateLimitConfig, store RateLimitStore) Middleware {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	apiKeys := cfg.apiKeys()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			class, limit := cfg.classify(r)
+			if limit.Requests <= 0 || limit.Period <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := class + ":" + cfg.clientKey(r, apiKeys)
+			res, err := store.Take(r.Context(), key, limit, cfg.Now())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				problem.New(http.StatusTooManyRequests, problem.CodeRateLimited, "rate limit exceeded").Write(w, r, nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// uploadSuffixes end the paths of the routes that store or fetch images, which
+// are limited as uploads.
+var uploadSuffixes = []string{"/file", "/file:presign", "/file:confirm", "/images:import"}
+
+func (cfg RateLimitConfig) classify(r *http.Request) (string, Limit) {
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return "read", cfg.Read
+	case r.Method == http.MethodPost && slices.ContainsFunc(uploadSuffixes, func(suffix string) bool {
+		return strings.HasSuffix(r.URL.Path, suffix)
+	}):
+		return "upload", cfg.Upload
+	default:
+		return "write", cfg.Write
+	}
+}
+
+// Client returns a function that identifies the client of a request the way
+// the rate limiter does.
+func (cfg RateLimitConfig) Client() func(*http.Request) string {
+	apiKeys := cfg.apiKeys()
+	return func(r *http.Request) string {
+		return cfg.clientKey(r, apiKeys)
+	}
+}
+
+func (cfg RateLimitConfig) apiKeys() map[string]bool {
+	apiKeys := make(map[string]bool, len(cfg.APIKeys))
+	for _, key := range cfg.APIKeys {
+		apiKeys[key] = true
+	}
+	return apiKeys
+}
+
+func (cfg RateLimitConfig) clientKey(r *http.Request, apiKeys map[string]bool) string {
+	if cfg.APIKeyHeader != "" {
+		if key := r.Header.Get(cfg.APIKeyHeader); apiKeys[key] {
+			return "key:" + key
+		}
+	}
+	if cfg.Subject != nil {
+		if sub := cfg.Subject(r); sub != "" {
+			return "sub:" + sub
+		}
+	}
+	return "ip:" + cfg.clientIP(r)
+}
+
+// clientIP returns the address of the client. Behind a trusted proxy, that is
+// the rightmost X-Forwarded-For entry not added by a trusted proxy, as the
+// entries to its left are sent by the client and can be anything.
+func (cfg RateLimitConfig) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !cfg.TrustProxy {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		if !cfg.trusted(addr) || i == 0 {
+			return addr.Unmap().String()
+		}
+	}
+	return host
+}
+
+func (cfg RateLimitConfig) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range cfg.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+const (
+	rateLimitSweepInterval = time.Minute
+	// maxRateLimitBuckets bounds the memory used by the in-memory store. When
+	// full, the least recently used bucket is dropped.
+	maxRateLimitBuckets = 100_000
+)
+
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+	period time.Duration
+}
+
+type InMemoryRateLimitStore struct {
+	mu        sync.Mutex
+	max       int
+	buckets   map[string]*list.Element
+	order     *list.List
+	lastSweep time.Time
+}
+
+func NewInMemoryRateLimitStore() *InMemoryRateLimitStore {
+	return &InMemoryRateLimitStore{
+		max:     maxRateLimitBuckets,
+		buckets: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (s *InMemoryRateLimitStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	rate := limit.rate()
+	burst := float64(limit.Requests)
+	var b *bucket
+	if elem, ok := s.buckets[key]; ok {
+		b = elem.Value.(*bucket)
+		s.order.MoveToFront(elem)
+	} else {
+		for s.order.Len() >= s.max {
+			s.remove(s.order.Back())
+		}
+		b = &bucket{key: key, tokens: burst, last: now, period: limit.Period}
+		s.buckets[key] = s.order.PushFront(b)
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*rate)
+		b.last = now
+	}
+	res := RateLimitResult{Limit: limit.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / rate * float64(time.Second))
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((burst - b.tokens) / rate * float64(time.Second))
+	return res, nil
+}
+
+func (s *InMemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for _, elem := range s.buckets {
+		if b := elem.Value.(*bucket); now.Sub(b.last) > b.period {
+			s.remove(elem)
+		}
+	}
+}
+
+func (s *InMemoryRateLimitStore) remove(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.buckets, elem.Value.(*bucket).key)
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRateLimitStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryRateLimitStore()
+	limit := Limit{Requests: 2, Period: 2 * time.Second}
+	now := time.Now()
+	t.Run("Take", func(t *testing.T) {
+		for i, want := range []bool{true, true, false} {
+			res, err := store.Take(ctx, "key", limit, now)
+			if err != nil || res.Allowed != want {
+				t.Errorf("Take() #%d got (%v, %v), want (%v, %v)", i, res.Allowed, err, want, nil)
+			}
+		}
+		res, _ := store.Take(ctx, "key", limit, now)
+		if res.RetryAfter != time.Second {
+			t.Errorf("Take() got retry after %v, want %v", res.RetryAfter, time.Second)
+		}
+		if res, _ := store.Take(ctx, "key", limit, now.Add(time.Second)); !res.Allowed {
+			t.Errorf("Take() after refill got %v, want %v", res.Allowed, true)
+		}
+		if res, _ := store.Take(ctx, "other", limit, now); !res.Allowed || res.Remaining != 1 {
+			t.Errorf("Take() for other key got (%v, %v), want (%v, %v)", res.Allowed, res.Remaining, true, 1)
+		}
+	})
+	t.Run("max", func(t *testing.T) {
+		store := NewInMemoryRateLimitStore()
+		store.max = 2
+		store.Take(ctx, "a", limit, now)
+		store.Take(ctx, "b", limit, now)
+		store.Take(ctx, "a", limit, now)
+		store.Take(ctx, "c", limit, now)
+		if _, ok := store.buckets["b"]; ok || len(store.buckets) != 2 {
+			t.Errorf("Take() kept %v buckets, want the least recently used one dropped", len(store.buckets))
+		}
+	})
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Now()
+	cfg := DefaultRateLimitConfig()
+	cfg.Read = Limit{Requests: 2, Period: time.Minute}
+	cfg.Write = Limit{Requests: 1, Period: time.Minute}
+	cfg.Upload = Limit{Requests: 1, Period: time.Hour}
+	cfg.APIKeys = []string{"secret"}
+	cfg.Now = func() time.Time { return now }
+	h := RateLimit(cfg, NewInMemoryRateLimitStore())(&mockHandler{})
+	send := func(method, path, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+	t.Run("read", func(t *testing.T) {
+		for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+			if rr := send("GET", "/rank/1/table", "10.0.0.1:1234", ""); rr.Code != want {
+				t.Errorf("request #%d returned wrong status code: got %v, want %v", i, rr.Code, want)
+			}
+		}
+		rr := send("GET", "/rank/1/table", "10.0.0.1:4321", "")
+		want := map[string]string{
+			"RateLimit-Limit":     "2",
+			"RateLimit-Remaining": "0",
+			"RateLimit-Reset":     "60",
+			"Retry-After":         "30",
+			"Content-Type":        "application/problem+json",
+		}
+		for key, value := range want {
+			if got := rr.Header().Get(key); got != value {
+				t.Errorf("handler returned wrong %v header: got %v, want %v", key, got, value)
+			}
+		}
+		if rr := send("GET", "/rank/1/table", "10.0.0.2:1234", ""); rr.Code != http.StatusOK {
+			t.Errorf("request from other client returned wrong status code: got %v, want %v", rr.Code, http.StatusOK)
+		}
+	})
+	t.Run("write and upload", func(t *testing.T) {
+		if rr := send("POST", "/rank", "10.0.0.3:1234", ""); rr.Code != http.StatusOK {
+			t.Errorf("write returned wrong status code: got %v, want %v", rr.Code, http.StatusOK)
+		}
+		if rr := send("POST", "/rank/1/file", "10.0.0.3:1234", ""); rr.Code != http.StatusOK {
+			t.Errorf("upload returned wrong status code: got %v, want %v", rr.Code, http.StatusOK)
+		}
+		if rr := send("PUT", "/rank/1", "10.0.0.3:1234", ""); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("write returned wrong status code: got %v, want %v", rr.Code, http.StatusTooManyRequests)
+		}
+		for _, path := range []string{"/rank/2/file", "/rank/2/file:presign", "/rank/2/file:confirm", "/rank/2/images:import"} {
+			if rr := send("POST", path, "10.0.0.3:1234", ""); rr.Code != http.StatusTooManyRequests {
+				t.Errorf("upload to %v returned wrong status code: got %v, want %v", path, rr.Code, http.StatusTooManyRequests)
+			}
+		}
+	})
+	t.Run("api key", func(t *testing.T) {
+		if rr := send("DELETE", "/rank/1", "10.0.0.3:1234", "secret"); rr.Code != http.StatusOK {
+			t.Errorf("request with api key returned wrong status code: got %v, want %v", rr.Code, http.StatusOK)
+		}
+		if rr := send("DELETE", "/rank/1", "10.0.0.4:1234", "secret"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("request with api key returned wrong status code: got %v, want %v", rr.Code, http.StatusTooManyRequests)
+		}
+		if rr := send("DELETE", "/rank/1", "10.0.0.5:1234", "made-up"); rr.Code != http.StatusOK {
+			t.Errorf("request with unknown api key returned wrong status code: got %v, want %v", rr.Code, http.StatusOK)
+		}
+		if rr := send("DELETE", "/rank/1", "10.0.0.5:1234", "other-made-up"); rr.Code != http.StatusTooManyRequests {
+			t.Errorf("request with unknown api key returned wrong status code: got %v, want %v", rr.Code, http.StatusTooManyRequests)
+		}
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	cfg := DefaultRateLimitConfig()
+	cfg.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+	tests := []struct {
+		trustProxy bool
+		forwarded  []string
+		want       string
+	}{
+		{false, []string{"203.0.113.9"}, "10.0.0.1"},
+		{true, nil, "10.0.0.1"},
+		{true, []string{"203.0.113.9"}, "203.0.113.9"},
+		{true, []string{"198.51.100.1, 203.0.113.9"}, "203.0.113.9"},
+		{true, []string{"198.51.100.1", "203.0.113.9, 10.1.0.7"}, "203.0.113.9"},
+		{true, []string{"10.1.0.8, 10.1.0.7"}, "10.1.0.8"},
+		{true, []string{"not-an-ip"}, "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		cfg.TrustProxy = tt.trustProxy
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		for _, value := range tt.forwarded {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		if got := cfg.clientIP(req); got != tt.want {
+			t.Errorf("clientIP(%v) with trust proxy %v got %v, want %v", tt.forwarded, tt.trustProxy, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimitClient(t *testing.T) {
+	cfg := DefaultRateLimitConfig()
+	cfg.APIKeys = []string{"known"}
+	client := cfg.Client()
+	tests := []struct {
+		apiKey string
+		want   string
+	}{
+		{"known", "key:known"},
+		{"made-up", "ip:10.0.0.1"},
+		{"", "ip:10.0.0.1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/rank", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Api-Key", tt.apiKey)
+		if got := client(req); got != tt.want {
+			t.Errorf("Client()(%v) got %v, want %v", tt.apiKey, got, tt.want)
+		}
+	}
+}
@@ -1,24 +1,173 @@
 package server
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/josimarz/ranking-backend/internal/infra/web/problem"
 )
 
 type Handlers map[string]http.Handler
 
+type ShutdownFunc func(context.Context) error
+
+type Config struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       time.Minute,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   20 * time.Second,
+	}
+}
+
 type Server struct {
-	addr     string
-	handlers Handlers
+	addr        string
+	handlers    Handlers
+	cfg         Config
+	middlewares []Middleware
+	onShutdown  []ShutdownFunc
+}
+
+func NewServer(addr string, handlers Handlers, cfg Config) *Server {
+	return &Server{
+		addr:     addr,
+		handlers: handlers,
+		cfg:      cfg,
+	}
+}
+
+func (s *Server) Use(mw ...Middleware) {
+	s.middlewares = append(s.middlewares, mw...)
 }
 
-func NewServer(addr string, handlers Handlers) *Server {
-	return &Server{addr, handlers}
+func (s *Server) OnShutdown(fn ShutdownFunc) {
+	s.onShutdown = append(s.onShutdown, fn)
 }
 
 func (s *Server) Start() error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	return s.Run(ctx)
+}
+
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := s.httpServer()
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.Serve(ln)
+	}()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	for _, fn := range s.onShutdown {
+		err = errors.Join(err, fn(shutdownCtx))
+	}
+	return err
+}
+
+func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	for pattern, handler := range s.handlers {
 		mux.Handle(pattern, handler)
 	}
-	return http.ListenAndServe(s.addr, mux)
+	for pattern, handler := range optionsHandlers(s.handlers) {
+		mux.Handle(pattern, handler)
+	}
+	var h http.Handler = problemMux{mux}
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		h = s.middlewares[i](h)
+	}
+	return h
+}
+
+func (s *Server) httpServer() *http.Server {
+	return &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadTimeout:       s.cfg.ReadTimeout,
+		ReadHeaderTimeout: s.cfg.ReadHeaderTimeout,
+		WriteTimeout:      s.cfg.WriteTimeout,
+		IdleTimeout:       s.cfg.IdleTimeout,
+		MaxHeaderBytes:    s.cfg.MaxHeaderBytes,
+	}
+}
+
+// problemMux answers requests that match no route, or none for their method,
+// with problem+json errors instead of the plain text ones of http.ServeMux.
+type problemMux struct {
+	*http.ServeMux
+}
+
+func (m problemMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, pattern := m.Handler(r)
+	if pattern != "" {
+		m.ServeMux.ServeHTTP(w, r)
+		return
+	}
+	uw := &unmatchedWriter{ResponseWriter: w}
+	h.ServeHTTP(uw, r)
+	switch uw.status {
+	case http.StatusNotFound:
+		problem.New(http.StatusNotFound, problem.CodeNotFound, "no route matches the request").Write(w, r, nil)
+	case http.StatusMethodNotAllowed:
+		problem.New(http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed, r.Method+" is not allowed on this route").Write(w, r, nil)
+	}
+}
+
+// unmatchedWriter holds back the plain text 404 and 405 responses of
+// http.ServeMux, keeping headers like Allow, and passes anything else, like
+// redirects to canonical paths, through.
+type unmatchedWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *unmatchedWriter) WriteHeader(status int) {
+	w.status = status
+	if !w.held() {
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.held() {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *unmatchedWriter) held() bool {
+	return w.status == http.StatusNotFound || w.status == http.StatusMethodNotAllowed
 }
@@ -1,8 +1,13 @@
 package server
 
 import (
+	"context"
+	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 type mockHandler struct{}
@@ -16,10 +21,93 @@ func (h *mockHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type slowHandler struct {
+	started chan struct{}
+}
+
+func (h *slowHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	close(h.started)
+	time.Sleep(200 * time.Millisecond)
+	w.Write([]byte("done"))
+}
+
 func TestServer(t *testing.T) {
 	addr := ":8080"
 	handlers := Handlers{
 		"GET /": &mockHandler{},
 	}
-	NewServer(addr, handlers)
+	NewServer(addr, handlers, DefaultConfig())
+}
+
+func TestServerShutdown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &slowHandler{started: make(chan struct{})}
+	s := NewServer(ln.Addr().String(), Handlers{"GET /slow": h}, DefaultConfig())
+	closed := false
+	s.OnShutdown(func(ctx context.Context) error {
+		closed = true
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Serve(ctx, ln)
+	}()
+	type result struct {
+		body string
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		res, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		results <- result{string(body), err}
+	}()
+	<-h.started
+	cancel()
+	if res := <-results; res.err != nil || res.body != "done" {
+		t.Errorf("in-flight request got (%v, %v), want (%v, %v)", res.body, res.err, "done", nil)
+	}
+	if err := <-errs; err != nil {
+		t.Errorf("Serve() got %v, want %v", err, nil)
+	}
+	if !closed {
+		t.Error("shutdown hook was not called")
+	}
+}
+
+func TestServerHandler(t *testing.T) {
+	h := NewServer(":8080", Handlers{"GET /rank/{id}": &mockHandler{}}, DefaultConfig()).Handler()
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/rank/1", http.StatusOK, "Hello, World!"},
+		{"GET", "/unknown", http.StatusNotFound, `{"type":"/problems/not-found","title":"Not Found","status":404,"detail":"no route matches the request","instance":"/unknown","code":"not_found"}`},
+		{"DELETE", "/rank/1", http.StatusMethodNotAllowed, `{"type":"/problems/method-not-allowed","title":"Method Not Allowed","status":405,"detail":"DELETE is not allowed on this route","instance":"/rank/1","code":"method_not_allowed"}`},
+		{"GET", "/rank//1/../2", http.StatusTemporaryRedirect, ""},
+	}
+	for _, tt := range tests {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, nil))
+		if rr.Code != tt.status {
+			t.Errorf("%v %v returned wrong status code: got %v, want %v", tt.method, tt.path, rr.Code, tt.status)
+		}
+		if tt.body != "" && rr.Body.String() != tt.body {
+			t.Errorf("%v %v returned wrong body: got %v, want %v", tt.method, tt.path, rr.Body.String(), tt.body)
+		}
+		if tt.status == http.StatusMethodNotAllowed && rr.Header().Get("Allow") == "" {
+			t.Errorf("%v %v returned no Allow header", tt.method, tt.path)
+		}
+	}
 }
@@ -0,0 +1,41 @@
+package mergepatch
+
+import (
+	"encoding/json"
+)
+
+const ContentType = "application/merge-patch+json"
+
+// Apply merges patch into doc following RFC 7396: object members are merged
+// recursively, null removes a member and any other value replaces the target.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target, p any
+	if len(doc) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = merge(t[key], value)
+	}
+	return t
+}
@@ -0,0 +1,44 @@
+package mergepatch
+
+import (
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// Examples from RFC 7396, Appendix A.
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Fatalf("Apply(%s, %s) got error %v", tt.doc, tt.patch, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Apply(%s, %s) got %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := Apply([]byte(`{}`), []byte(`{"a":`)); err == nil {
+			t.Error("Apply() with a malformed patch got nil error")
+		}
+	})
+}
@@ -12,4 +12,18 @@ aws s3api create-bucket \
     --region us-east-1 \
     --no-cli-pager \
     --acl public-read \
-    --bucket ranking
\ No newline at end of file
+    --bucket ranking
+
+aws dynamodb update-time-to-live \
+    --endpoint-url $ENDPOINT_URL \
+    --region us-east-1 \
+    --table-name $TABLE_NAME \
+    --time-to-live-specification Enabled=true,AttributeName=expires \
+    --no-cli-pager
+
+aws s3api put-bucket-cors \
+    --endpoint-url $ENDPOINT_URL \
+    --region us-east-1 \
+    --no-cli-pager \
+    --bucket ranking \
+    --cors-configuration '{"CORSRules":[{"AllowedOrigins":["*"],"AllowedMethods":["GET","PUT"],"AllowedHeaders":["*"],"MaxAgeSeconds":3000}]}'